@@ -0,0 +1,86 @@
+// The eval subcommand: run a suite of prompts and score skill selection.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/minhyannv/agent-skills-go/pkg/eval"
+	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+)
+
+// evalUsage describes the eval subcommand.
+const evalUsage = "Usage: agent-skills-go eval [-scripted] [-json FILE] [-markdown FILE] [agent flags] <suite.yaml>"
+
+// runEval runs a suite and prints the Markdown report. It exits 0 when every
+// case passes, 1 when any fails and 2 on usage or setup errors.
+func runEval(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	scripted := flags.Bool("scripted", false, "Replay each case's script instead of calling the configured model")
+	jsonOut := flags.String("json", "", "Write the JSON report to this file (- for stdout)")
+	markdownOut := flags.String("markdown", "", "Write the Markdown report to this file instead of stdout")
+	cfg, err := parseCLIConfig(flags, args)
+	if err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		_, _ = fmt.Fprintln(stderr, evalUsage)
+		return 2
+	}
+	suite, err := eval.LoadSuite(flags.Arg(0))
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	opts := eval.Options{Config: cfg, Scripted: *scripted}
+	if cfg.Verbose {
+		opts.Logger = loggerpkg.NewWriterLogger(stderr)
+	}
+	report, err := eval.Run(ctx, suite, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if path := strings.TrimSpace(*jsonOut); path != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 2
+		}
+		if err := writeReport(path, append(data, '\n'), stdout); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+	// The Markdown report goes to stdout unless the JSON report does.
+	if markdownPath := strings.TrimSpace(*markdownOut); markdownPath != "" || strings.TrimSpace(*jsonOut) != "-" {
+		if err := writeReport(markdownPath, []byte(report.Markdown()), stdout); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+	if report.Passed < report.Total {
+		return 1
+	}
+	return 0
+}
+
+// writeReport writes data to path, or to stdout when path is empty or "-".
+func writeReport(path string, data []byte, stdout io.Writer) error {
+	if path == "" || path == "-" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
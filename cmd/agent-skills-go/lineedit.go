@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// lineReader reads REPL input one line at a time.
+type lineReader interface {
+	// ReadLine prints prompt and returns the next line without its newline,
+	// or io.EOF at the end of input.
+	ReadLine(prompt string) (string, error)
+}
+
+// completeFunc returns the candidates for the word that ends at the end of
+// before, and the byte offset where that word starts.
+type completeFunc func(before string) (start int, candidates []string)
+
+// newLineReader returns a line editor with history and tab completion when in
+// is a terminal, and a plain line scanner otherwise.
+func newLineReader(in io.Reader, out io.Writer, complete completeFunc) lineReader {
+	if file, ok := in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		return &lineEditor{fd: int(file.Fd()), in: bufio.NewReader(file), out: out, complete: complete}
+	}
+	return &scanReader{scanner: bufio.NewScanner(in), out: out}
+}
+
+// scanReader reads lines from a non-terminal input.
+type scanReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (r *scanReader) ReadLine(prompt string) (string, error) {
+	_, _ = fmt.Fprint(r.out, prompt)
+	if r.scanner.Scan() {
+		return r.scanner.Text(), nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+// maxHistory caps the lines kept for the up and down keys.
+const maxHistory = 500
+
+// lineEditor edits a line in raw terminal mode. It supports the arrow keys,
+// Home/End, Ctrl-A/E/K/U/W, history and tab completion. The terminal is only
+// raw while a line is read, so output of the agent is unaffected; raw mode
+// also turns off output processing, so the editor writes "\r\n" itself.
+type lineEditor struct {
+	fd       int
+	in       *bufio.Reader
+	out      io.Writer
+	complete completeFunc
+	history  []string
+
+	prompt string
+	buf    []rune
+	pos    int
+}
+
+func (e *lineEditor) ReadLine(prompt string) (string, error) {
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		// Fall back to the terminal's own line editing.
+		_, _ = fmt.Fprint(e.out, prompt)
+		line, err := e.in.ReadString('\n')
+		if err != nil && (line == "" || err != io.EOF) {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	defer func() { _ = term.Restore(e.fd, state) }()
+
+	e.prompt, e.buf, e.pos = prompt, nil, 0
+	historyPos := len(e.history)
+	draft := ""
+	e.redraw()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			_, _ = fmt.Fprint(e.out, "\r\n")
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			_, _ = fmt.Fprint(e.out, "\r\n")
+			line := string(e.buf)
+			if strings.TrimSpace(line) != "" && (len(e.history) == 0 || e.history[len(e.history)-1] != line) {
+				e.history = append(e.history, line)
+				if len(e.history) > maxHistory {
+					e.history = e.history[1:]
+				}
+			}
+			return line, nil
+		case 3: // Ctrl-C discards the line.
+			_, _ = fmt.Fprint(e.out, "^C\r\n")
+			e.buf, e.pos = nil, 0
+			historyPos = len(e.history)
+		case 4: // Ctrl-D ends input on an empty line and deletes otherwise.
+			if len(e.buf) == 0 {
+				_, _ = fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.pos, e.pos+1)
+		case 127, 8: // Backspace
+			e.delete(e.pos-1, e.pos)
+		case 1: // Ctrl-A
+			e.pos = 0
+		case 5: // Ctrl-E
+			e.pos = len(e.buf)
+		case 11: // Ctrl-K
+			e.delete(e.pos, len(e.buf))
+		case 21: // Ctrl-U
+			e.delete(0, e.pos)
+		case 23: // Ctrl-W deletes the word before the cursor.
+			start := e.pos
+			for start > 0 && e.buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && e.buf[start-1] != ' ' {
+				start--
+			}
+			e.delete(start, e.pos)
+		case '\t':
+			e.completeWord()
+		case 27:
+			switch e.escape() {
+			case 'A':
+				if historyPos > 0 {
+					if historyPos == len(e.history) {
+						draft = string(e.buf)
+					}
+					historyPos--
+					e.setLine(e.history[historyPos])
+				}
+			case 'B':
+				if historyPos < len(e.history) {
+					historyPos++
+					if historyPos == len(e.history) {
+						e.setLine(draft)
+					} else {
+						e.setLine(e.history[historyPos])
+					}
+				}
+			case 'C':
+				if e.pos < len(e.buf) {
+					e.pos++
+				}
+			case 'D':
+				if e.pos > 0 {
+					e.pos--
+				}
+			case 'H':
+				e.pos = 0
+			case 'F':
+				e.pos = len(e.buf)
+			case '~': // Delete
+				e.delete(e.pos, e.pos+1)
+			}
+		default:
+			if unicode.IsPrint(r) {
+				e.buf = append(e.buf[:e.pos], append([]rune{r}, e.buf[e.pos:]...)...)
+				e.pos++
+			}
+		}
+		e.redraw()
+	}
+}
+
+// escape reads the rest of an escape sequence and returns its final byte:
+// A-D for the arrows, H and F for Home and End, and ~ for Delete. Other
+// sequences return 0.
+func (e *lineEditor) escape() byte {
+	b, err := e.in.ReadByte()
+	if err != nil || (b != '[' && b != 'O') {
+		return 0
+	}
+	var params []byte
+	for {
+		b, err = e.in.ReadByte()
+		if err != nil {
+			return 0
+		}
+		if b < '0' || b > '?' {
+			break
+		}
+		params = append(params, b)
+	}
+	switch {
+	case b == '~' && string(params) == "3":
+		return '~'
+	case b == '~' && (string(params) == "1" || string(params) == "7"):
+		return 'H'
+	case b == '~' && (string(params) == "4" || string(params) == "8"):
+		return 'F'
+	case b == '~':
+		return 0
+	}
+	return b
+}
+
+// delete removes the runes in [from, to), clamped to the line.
+func (e *lineEditor) delete(from, to int) {
+	from, to = max(from, 0), min(to, len(e.buf))
+	if from >= to {
+		return
+	}
+	e.buf = append(e.buf[:from], e.buf[to:]...)
+	if e.pos > to {
+		e.pos -= to - from
+	} else if e.pos > from {
+		e.pos = from
+	}
+}
+
+func (e *lineEditor) setLine(line string) {
+	e.buf = []rune(line)
+	e.pos = len(e.buf)
+}
+
+// completeWord completes the word before the cursor: a single candidate is
+// inserted with a trailing space, several are completed to their common
+// prefix and listed when that adds nothing.
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+	before := string(e.buf[:e.pos])
+	start, candidates := e.complete(before)
+	if len(candidates) == 0 {
+		_, _ = fmt.Fprint(e.out, "\a")
+		return
+	}
+	word := before[start:]
+	replacement := candidates[0] + " "
+	if len(candidates) > 1 {
+		replacement = commonPrefix(candidates)
+	}
+	if replacement != word {
+		e.replaceBeforeCursor(utf8.RuneCountInString(before[:start]), replacement)
+		return
+	}
+	_, _ = fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+}
+
+// replaceBeforeCursor replaces the runes from start to the cursor with text.
+func (e *lineEditor) replaceBeforeCursor(start int, text string) {
+	inserted := []rune(text)
+	tail := append([]rune{}, e.buf[e.pos:]...)
+	e.buf = append(append(e.buf[:start], inserted...), tail...)
+	e.pos = start + len(inserted)
+}
+
+// redraw rewrites the prompt and line and places the cursor.
+func (e *lineEditor) redraw() {
+	var sb strings.Builder
+	sb.WriteString("\r")
+	sb.WriteString(e.prompt)
+	sb.WriteString(string(e.buf))
+	sb.WriteString("\x1b[K")
+	if back := len(e.buf) - e.pos; back > 0 {
+		fmt.Fprintf(&sb, "\x1b[%dD", back)
+	}
+	_, _ = io.WriteString(e.out, sb.String())
+}
+
+// commonPrefix returns the longest prefix shared by values.
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+// completions returns the values of options that start with prefix, sorted
+// and without duplicates.
+func completions(prefix string, options []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, option := range options {
+		if strings.HasPrefix(option, prefix) && !seen[option] {
+			seen[option] = true
+			out = append(out, option)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
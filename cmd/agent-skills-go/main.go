@@ -1,80 +1,247 @@
-// Package main provides a single-file CLI for AgentLoop.
+// Package main provides the CLI for AgentLoop.
 package main
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/minhyannv/agent-skills-go/pkg/agent"
 	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	"github.com/minhyannv/agent-skills-go/pkg/installer"
 	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+	"github.com/minhyannv/agent-skills-go/pkg/mcp"
+	"github.com/minhyannv/agent-skills-go/pkg/prompt"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
 )
 
 // main is the program entry point.
 func main() {
-	config, err := parseCLIConfig()
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		os.Exit(runSubcommand(args[0], args[1:]))
+	}
+
+	config, err := parseCLIConfig(flag.CommandLine, args)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	appLogger := loggerpkg.NewWriterLogger(os.Stderr)
-	app, err := agent.New(context.Background(), config, agent.WithLogger(appLogger))
+	app, err := agent.New(context.Background(), config,
+		agent.WithLogger(appLogger),
+		agent.WithSkillReloadHandler(func(reload agent.SkillReload) { printSkillReload(os.Stdout, reload) }),
+	)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	defer func() { _ = app.Close() }()
+	printSkillDiagnostics(os.Stderr, app.SkillDiagnostics())
 
 	if err := runREPL(app, replOptions{
 		Verbose: config.Verbose,
 		Logger:  appLogger,
 	}, os.Stdin, os.Stdout); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		_ = app.Close()
 		os.Exit(1)
 	}
 }
 
+// runSubcommand dispatches non-REPL modes and returns the process exit code.
+func runSubcommand(name string, args []string) int {
+	switch name {
+	case "serve-mcp":
+		return runServeMCP(args)
+	case "skills":
+		return runSkills(args, os.Stdout, os.Stderr)
+	case "eval":
+		return runEval(args, os.Stdout, os.Stderr)
+	case "prompt":
+		return runPrompt(args, os.Stdout, os.Stderr)
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "Error: unknown command %q (available: serve-mcp, skills, eval, prompt)\n", name)
+		return 2
+	}
+}
+
+// runServeMCP exposes the built-in tools and skills as an MCP server over stdio.
+// Stdout carries the protocol, so all logging goes to stderr.
+func runServeMCP(args []string) int {
+	cfg, err := parseCLIConfig(flag.NewFlagSet("serve-mcp", flag.ExitOnError), args)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	cfg = configpkg.Normalize(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	appLogger := loggerpkg.NewWriterLogger(os.Stderr)
+	skillList, diags, err := agent.LoadSkills(cfg, nil, appLogger)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	printSkillDiagnostics(os.Stderr, diags)
+	// LoadSkills has already validated the policy.
+	scan, _ := skills.ParseScanPolicy(cfg.SkillsScan)
+	registry := tools.New(tools.Context{
+		MaxReadBytes: tools.DefaultMaxReadBytes,
+		Verbose:      cfg.Verbose,
+		AllowedDirs:  configpkg.ToolAllowedDirs(cfg),
+		Ctx:          ctx,
+		Logger:       appLogger,
+		Skills:       skillList,
+		ResourceScan: scan,
+	})
+
+	server := mcp.NewServer(mcp.Implementation{Name: "agent-skills-go", Version: "dev"}, registry, skillList, appLogger)
+	loggerpkg.Debug(cfg.Verbose, appLogger, "serving mcp over stdio", map[string]any{
+		"tools":  len(registry.Definitions()),
+		"skills": len(skillList),
+	})
+	if err := server.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
 // parseCLIConfig loads env + flags into runtime config.
-func parseCLIConfig() (configpkg.Config, error) {
+func parseCLIConfig(flags *flag.FlagSet, args []string) (configpkg.Config, error) {
 	_ = godotenv.Load()
 
 	defaults := configpkg.DefaultConfig()
+	defaults.SkillsSystemDirs = bundledSkillDirs(defaults.AllowedDir)
 	defaults.SkillsDirs = discoverDefaultSkills(defaults.AllowedDir)
+	defaults.SkillsTrustedKeys = installer.DefaultTrustedKeys()
+	defaults.UserInstructions = installer.DefaultUserInstructions()
 	skillsDirs := make(stringSliceFlag, 0, len(defaults.SkillsDirs))
 	for _, dir := range defaults.SkillsDirs {
 		_ = skillsDirs.Set(dir)
 	}
 
-	flag.Var(&skillsDirs, "skills_dirs", "Skill directory. Repeat this flag for multiple directories; comma-separated values are not supported")
-	maxTurns := flag.Int("max_turns", defaults.MaxTurns, "Max tool-call turns")
-	verbose := flag.Bool("verbose", defaults.Verbose, "Verbose tool-call logging")
-	allowedDir := flag.String("allowed_dir", defaults.AllowedDir, "Base directory for file operations (set empty to disable restriction)")
-	flag.Parse()
+	flags.Var(&skillsDirs, "skills_dirs", "Skill directory. Repeat this flag for multiple directories; comma-separated values are not supported")
+	skillsLenient := flags.Bool("skills_lenient", defaults.SkillsLenient, "Skip skills that fail to parse and print warnings instead of exiting")
+	skillsDuplicates := flags.String("skills_duplicates", defaults.SkillsDuplicates, "Policy for skills with the same name: first (earliest -skills_dirs wins), error, or namespace")
+	skillsPromptTokens := flags.Int("skills_prompt_tokens", defaults.SkillsPromptTokens, "Token budget for the skill listing; larger catalogs are shortlisted per turn (0 lists every skill)")
+	skillsTopN := flags.Int("skills_top_n", defaults.SkillsTopN, "Number of relevant skills listed per turn when the catalog exceeds -skills_prompt_tokens")
+	skillsReload := flags.Duration("skills_reload_interval", defaults.SkillsReloadInterval, "How often to check skill directories for changes, applied before the next message (0 disables)")
+	skillsTrustedKeys := flags.String("skills_trusted_keys", defaults.SkillsTrustedKeys, "File of ed25519 public keys; skills signed by them are verified, other non-system skills are untrusted")
+	skillsSignatureMismatch := flags.String("skills_signature_mismatch", defaults.SkillsSignatureMismatch, "What to do with a skill whose signature does not match its files: refuse (skip it) or warn (load it as untrusted)")
+	skillsLock := flags.String("skills_lock", defaults.SkillsLock, "Project lockfile the loaded skills are checked against; a missing file checks nothing")
+	skillsLockMode := flags.String("skills_lock_mode", defaults.SkillsLockMode, "What to do when skills differ from -skills_lock: warn, refuse (exit) or off")
+	skillsScan := flags.String("skills_scan", defaults.SkillsScan, "Prompt-injection scan of skill files and resources: off, warn, quarantine (load untrusted, withhold resources) or block, plus rule=action overrides, e.g. warn,pipe_to_shell=block")
+	promptTemplate := flags.String("prompt_template", defaults.PromptTemplate, "text/template file replacing the default system prompt template")
+	promptSections := promptSectionFlag{}
+	flags.Var(promptSections, "prompt_section", "Replace one system prompt section with a template file, as name=FILE (identity, tools, skill_rules, safety, instructions, environment or skills). Repeat for several sections")
+	projectInstructions := flags.String("project_instructions", strings.Join(defaults.ProjectInstructions, ","), "Comma-separated instruction file names looked up from the working directory up to -allowed_dir; the first found in each directory is used (empty disables)")
+	userInstructions := flags.String("user_instructions", defaults.UserInstructions, "User-level instruction file included before the project files")
+	instructionsMaxBytes := flags.Int("instructions_max_bytes", defaults.InstructionsMaxBytes, "Cap on all instruction files in the system prompt; the most specific files are kept first")
+	toolResultEnvelope := flags.String("tool_result_envelope", defaults.ToolResultEnvelope, "Wrap tool results as data the model must not obey: untrusted (files, command output, MCP and script tools), all, or off")
+	maxTurns := flags.Int("max_turns", defaults.MaxTurns, "Max tool-call turns")
+	verbose := flags.Bool("verbose", defaults.Verbose, "Verbose tool-call logging")
+	allowedDir := flags.String("allowed_dir", defaults.AllowedDir, "Base directory for file operations (set empty to disable restriction)")
+	auditLog := flags.String("audit_log", defaults.AuditLog, "Append a JSON line per tool call decision, including calls denied by a skill's allowed-tools, to this file")
+	mcpConfig := flags.String("mcp_config", "", "JSON file with MCP servers to mount ({\"mcpServers\": {...}})")
+	overlayDir := flags.String("overlay_dir", defaults.OverlayDir, "Stage all writes under -allowed_dir in this directory until /apply (copy-on-write mode)")
+	if err := flags.Parse(args); err != nil {
+		return configpkg.Config{}, err
+	}
 
 	cfg := defaults
 	cfg.SkillsDirs = skillsDirs.values()
+	cfg.SkillsLenient = *skillsLenient
+	cfg.SkillsDuplicates = strings.TrimSpace(*skillsDuplicates)
+	cfg.SkillsPromptTokens = *skillsPromptTokens
+	cfg.SkillsTopN = *skillsTopN
+	cfg.SkillsReloadInterval = *skillsReload
+	cfg.SkillsTrustedKeys = strings.TrimSpace(*skillsTrustedKeys)
+	cfg.SkillsSignatureMismatch = strings.TrimSpace(*skillsSignatureMismatch)
+	cfg.SkillsLock = strings.TrimSpace(*skillsLock)
+	cfg.SkillsLockMode = strings.TrimSpace(*skillsLockMode)
+	cfg.SkillsScan = strings.TrimSpace(*skillsScan)
+	cfg.PromptTemplate = strings.TrimSpace(*promptTemplate)
+	if len(promptSections) > 0 {
+		cfg.PromptSections = promptSections
+	}
+	cfg.ProjectInstructions = strings.Split(*projectInstructions, ",")
+	cfg.UserInstructions = strings.TrimSpace(*userInstructions)
+	cfg.InstructionsMaxBytes = *instructionsMaxBytes
+	cfg.ToolResultEnvelope = strings.TrimSpace(*toolResultEnvelope)
 	cfg.MaxTurns = *maxTurns
 	cfg.Verbose = *verbose
 	cfg.AllowedDir = strings.TrimSpace(*allowedDir)
+	cfg.OverlayDir = strings.TrimSpace(*overlayDir)
+	cfg.AuditLog = strings.TrimSpace(*auditLog)
+	if path := strings.TrimSpace(*mcpConfig); path != "" {
+		servers, err := configpkg.LoadMCPServers(path)
+		if err != nil {
+			return configpkg.Config{}, fmt.Errorf("load mcp config: %w", err)
+		}
+		cfg.MCPServers = servers
+	}
 	cfg.APIKey = strings.TrimSpace(os.Getenv("OPENAI_API_KEY"))
 	cfg.BaseURL = strings.TrimSpace(os.Getenv("OPENAI_BASE_URL"))
 	cfg.Model = strings.TrimSpace(os.Getenv("OPENAI_MODEL"))
 	return cfg, nil
 }
 
+// printSkillDiagnostics reports skill loading problems, one per line.
+func printSkillDiagnostics(out io.Writer, diags []skills.Diagnostic) {
+	for _, d := range diags {
+		_, _ = fmt.Fprintf(out, "skills: %s\n", d)
+	}
+}
+
+// printSkillReload reports a skill reload with its changes and diagnostics.
+func printSkillReload(out io.Writer, reload agent.SkillReload) {
+	if reload.Err != nil {
+		_, _ = fmt.Fprintf(out, "skills: reload failed, keeping previous skills: %v\n", reload.Err)
+		return
+	}
+	summary := fmt.Sprintf("skills: reloaded %d skill(s)", reload.Skills)
+	for _, part := range []struct {
+		label string
+		names []string
+	}{{"added", reload.Added}, {"removed", reload.Removed}, {"changed", reload.Changed}} {
+		if len(part.names) > 0 {
+			summary += fmt.Sprintf("; %s: %s", part.label, strings.Join(part.names, ", "))
+		}
+	}
+	_, _ = fmt.Fprintln(out, summary)
+	printSkillDiagnostics(out, reload.Diagnostics)
+}
+
+// discoverDefaultSkills returns the bundled skills under baseDir and the
+// installer's skills home, when they exist.
 func discoverDefaultSkills(baseDir string) []string {
-	candidates := []string{
+	return existingDirs(append(bundledSkillDirs(baseDir), installer.DefaultHome()))
+}
+
+// bundledSkillDirs returns the skills shipped with the repository, the only
+// ones trusted without a signature.
+func bundledSkillDirs(baseDir string) []string {
+	return existingDirs([]string{
 		filepath.Join(baseDir, "skills", ".system", "skill-creator"),
 		filepath.Join(baseDir, "skills", ".system", "skill-installer"),
-	}
+	})
+}
 
+func existingDirs(candidates []string) []string {
 	out := make([]string, 0, len(candidates))
 	for _, dir := range candidates {
 		info, err := os.Stat(dir)
@@ -114,6 +281,28 @@ func (f stringSliceFlag) values() []string {
 	return out
 }
 
+// promptSectionFlag collects repeatable -prompt_section name=FILE flags.
+type promptSectionFlag map[string]string
+
+func (f promptSectionFlag) String() string {
+	parts := make([]string, 0, len(f))
+	for name, path := range f {
+		parts = append(parts, name+"="+path)
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ",")
+}
+
+func (f promptSectionFlag) Set(value string) error {
+	name, path, ok := strings.Cut(value, "=")
+	name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("expected name=FILE, got %q", value)
+	}
+	f[name] = path
+	return nil
+}
+
 // replOptions configures REPL behavior.
 type replOptions struct {
 	Verbose bool
@@ -136,52 +325,124 @@ func runREPL(app *agent.AgentLoop, opts replOptions, in io.Reader, out io.Writer
 		loggerpkg.Debug(opts.Verbose, opts.Logger, "repl start", nil)
 	}
 
-	scanner := bufio.NewScanner(in)
+	reader := newLineReader(in, out, func(before string) (int, []string) {
+		return completeInput(before, app.Skills())
+	})
 	printWelcome(out)
+	printInstructionFiles(app.Instructions(), out)
 
 	for {
-		_, _ = fmt.Fprint(out, "> ")
-		if !scanner.Scan() {
+		line, err := reader.ReadLine("> ")
+		if err == io.EOF {
 			break
 		}
+		if err != nil {
+			return fmt.Errorf("read input: %w", err)
+		}
 
-		input := strings.TrimSpace(scanner.Text())
+		input := strings.TrimSpace(line)
 		if input == "" {
 			continue
 		}
 
+		var skillNames []string
 		if strings.HasPrefix(input, "/") {
-			handled, shouldQuit := handleCommand(input, app, out)
-			if shouldQuit {
-				break
+			if skill, request, ok := skillCommand(input, app); ok {
+				input, skillNames = request, []string{skill.Name}
+			} else {
+				handled, shouldQuit := handleCommand(input, app, out)
+				if shouldQuit {
+					break
+				}
+				if handled {
+					continue
+				}
 			}
-			if handled {
-				continue
+		}
+		for _, skill := range skills.Mentions(input, app.Skills()) {
+			if !slices.Contains(skillNames, skill.Name) {
+				skillNames = append(skillNames, skill.Name)
 			}
 		}
+		if len(skillNames) > 0 {
+			_, _ = fmt.Fprintf(out, "Loading skill(s): %s\n", strings.Join(skillNames, ", "))
+		}
 
-		finalMessage, err := app.Run(input)
+		finalMessage, err := app.RunWithSkills(input, skillNames)
 		if err != nil {
 			_, _ = fmt.Fprintf(out, "Error: %v\n\n", err)
 			continue
 		}
 
 		_, _ = fmt.Fprintf(out, "%s\n\n", finalMessage.Content)
+		printPendingChanges(app, out)
 	}
+	return nil
+}
+
+// replCommands are the built-in REPL commands. They take precedence over
+// skills with the same name.
+var replCommands = []string{"/help", "/h", "/clear", "/c", "/diff", "/apply", "/discard", "/skills", "/memory", "/quit", "/exit", "/q"}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("read input: %w", err)
+// skillCommand resolves "/skill-name request" to the skill and the request to
+// run with it. Without a request, the skill's default prompt from
+// agents/openai.yaml is used, or a plain request to use the skill.
+func skillCommand(input string, app *agent.AgentLoop) (*skills.Skill, string, bool) {
+	name, request, _ := strings.Cut(strings.TrimPrefix(input, "/"), " ")
+	if slices.Contains(replCommands, "/"+strings.ToLower(name)) {
+		return nil, "", false
 	}
-	return nil
+	skill := app.Skill(name)
+	if skill == nil {
+		return nil, "", false
+	}
+	request = strings.TrimSpace(request)
+	if request == "" {
+		request = skill.Interface.DefaultPrompt
+	}
+	if strings.TrimSpace(request) == "" {
+		request = fmt.Sprintf("Use the $%s skill.", skill.Name)
+	}
+	return skill, request, true
+}
+
+// completeInput completes the word before the cursor: $skill mentions
+// anywhere, and commands or /skill-name as the first word.
+func completeInput(before string, list []*skills.Skill) (int, []string) {
+	start := strings.LastIndex(before, " ") + 1
+	word := before[start:]
+	var options []string
+	switch {
+	case strings.HasPrefix(word, "$"):
+		for _, skill := range list {
+			options = append(options, "$"+skill.Name)
+		}
+	case start == 0 && strings.HasPrefix(word, "/"):
+		options = append(options, replCommands...)
+		for _, skill := range list {
+			options = append(options, "/"+skill.Name)
+		}
+	case strings.TrimSpace(before[:start]) == "/skills":
+		options = []string{"reload"}
+	}
+	return start, completions(word, options)
 }
 
 func printWelcome(out io.Writer) {
 	_, _ = fmt.Fprintln(out, "=== Agent Skills Go - Interactive Mode ===")
 	_, _ = fmt.Fprintln(out, "Type your message and press Enter. Commands:")
-	_, _ = fmt.Fprintln(out, "  /help  - Show this help message")
-	_, _ = fmt.Fprintln(out, "  /clear - Clear conversation history")
-	_, _ = fmt.Fprintln(out, "  /quit  - Exit the program")
-	_, _ = fmt.Fprintln(out, "  /exit  - Exit the program")
+	_, _ = fmt.Fprintln(out, "  /help    - Show this help message")
+	_, _ = fmt.Fprintln(out, "  /clear   - Clear conversation history")
+	_, _ = fmt.Fprintln(out, "  /diff    - Show pending overlay changes (with -overlay_dir)")
+	_, _ = fmt.Fprintln(out, "  /apply   - Write pending overlay changes to disk")
+	_, _ = fmt.Fprintln(out, "  /discard - Drop pending overlay changes")
+	_, _ = fmt.Fprintln(out, "  /skills  - List the loaded skills")
+	_, _ = fmt.Fprintln(out, "  /skills reload - Reload skills from disk (history is kept)")
+	_, _ = fmt.Fprintln(out, "  /memory  - Show the instruction files (AGENTS.md) in the system prompt")
+	_, _ = fmt.Fprintln(out, "  /<skill> [request] - Run a request with that skill loaded")
+	_, _ = fmt.Fprintln(out, "  /quit    - Exit the program")
+	_, _ = fmt.Fprintln(out, "  /exit    - Exit the program")
+	_, _ = fmt.Fprintln(out, "Mention $<skill> in a message to load that skill. Tab completes commands and skill names.")
 	_, _ = fmt.Fprintln(out)
 }
 
@@ -190,7 +451,7 @@ func handleCommand(
 	app *agent.AgentLoop,
 	out io.Writer,
 ) (bool, bool) {
-	cmd := strings.ToLower(input)
+	cmd := strings.ToLower(strings.Join(strings.Fields(input), " "))
 	switch cmd {
 	case "/help", "/h":
 		printHelp(out)
@@ -200,6 +461,55 @@ func handleCommand(
 		_, _ = fmt.Fprintln(out, "Conversation history cleared.")
 		_, _ = fmt.Fprintln(out)
 		return true, false
+	case "/diff":
+		changes, err := app.Changeset()
+		if err != nil {
+			_, _ = fmt.Fprintf(out, "Error: %v\n\n", err)
+			return true, false
+		}
+		if changes.Empty() {
+			_, _ = fmt.Fprintln(out, "No pending changes.")
+		} else {
+			_, _ = fmt.Fprint(out, changes.Summary())
+			_, _ = fmt.Fprint(out, changes.Diff())
+		}
+		_, _ = fmt.Fprintln(out)
+		return true, false
+	case "/apply":
+		changes, err := app.ApplyChanges()
+		if err != nil {
+			_, _ = fmt.Fprintf(out, "Error: %v\n", err)
+			var partial *tools.CommitError
+			if errors.As(err, &partial) {
+				for _, path := range partial.Applied {
+					_, _ = fmt.Fprintf(out, "  applied: %s\n", path)
+				}
+				_, _ = fmt.Fprintln(out, "Remaining changes are still staged; see /diff.")
+			}
+			_, _ = fmt.Fprintln(out)
+			return true, false
+		}
+		_, _ = fmt.Fprintf(out, "Applied %d change(s).\n\n", len(changes.Changes))
+		return true, false
+	case "/discard":
+		if err := app.DiscardChanges(); err != nil {
+			_, _ = fmt.Fprintf(out, "Error: %v\n\n", err)
+			return true, false
+		}
+		_, _ = fmt.Fprintln(out, "Pending changes discarded.")
+		_, _ = fmt.Fprintln(out)
+		return true, false
+	case "/skills reload":
+		// The reload handler prints the result.
+		_, _ = app.ReloadSkills()
+		_, _ = fmt.Fprintln(out)
+		return true, false
+	case "/skills":
+		printSkillCatalog(app.Skills(), out)
+		return true, false
+	case "/memory":
+		printInstructions(app.Instructions(), out)
+		return true, false
 	case "/quit", "/exit", "/q":
 		_, _ = fmt.Fprintln(out, "Goodbye!")
 		return true, true
@@ -209,11 +519,104 @@ func handleCommand(
 	}
 }
 
+// printSkillCatalog lists the loaded skills with their display names and
+// short descriptions from agents/openai.yaml.
+func printSkillCatalog(list []*skills.Skill, out io.Writer) {
+	if len(list) == 0 {
+		_, _ = fmt.Fprintln(out, "No skills loaded.")
+		_, _ = fmt.Fprintln(out)
+		return
+	}
+	_, _ = fmt.Fprintf(out, "Skills (%d):\n", len(list))
+	for _, skill := range list {
+		label := skill.Name
+		if display := skill.Interface.DisplayName; display != "" && display != skill.Name {
+			label += " (" + display + ")"
+		}
+		if skill.Trust == skills.TrustUntrusted {
+			label += " [untrusted]"
+		}
+		description := skill.ShortDescription()
+		if description == "" {
+			description = skill.Description
+		}
+		if runes := []rune(description); len(runes) > 100 {
+			description = string(runes[:97]) + "..."
+		}
+		_, _ = fmt.Fprintf(out, "  %s - %s\n", label, description)
+	}
+	_, _ = fmt.Fprintln(out, "Run /<skill> [request] or mention $<skill> in a message to load a skill.")
+	_, _ = fmt.Fprintln(out)
+}
+
+// printInstructionFiles lists the instruction files in the system prompt, if any.
+func printInstructionFiles(list []prompt.Instruction, out io.Writer) {
+	if len(list) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintln(out, "Instructions loaded (later files take precedence):")
+	for _, instruction := range list {
+		_, _ = fmt.Fprintf(out, "  %s\n", instructionLabel(instruction))
+	}
+	_, _ = fmt.Fprintln(out, "Use /memory to view them.")
+	_, _ = fmt.Fprintln(out)
+}
+
+// printInstructions shows each instruction file as the model sees it.
+func printInstructions(list []prompt.Instruction, out io.Writer) {
+	if len(list) == 0 {
+		_, _ = fmt.Fprintln(out, "No instruction files loaded.")
+		_, _ = fmt.Fprintln(out)
+		return
+	}
+	for _, instruction := range list {
+		_, _ = fmt.Fprintf(out, "=== %s ===\n", instructionLabel(instruction))
+		if instruction.Content != "" {
+			_, _ = fmt.Fprintln(out, instruction.Content)
+		}
+		_, _ = fmt.Fprintln(out)
+	}
+}
+
+// instructionLabel describes an instruction file: its path, scope and size.
+func instructionLabel(instruction prompt.Instruction) string {
+	label := fmt.Sprintf("%s (%s, %d bytes", instruction.Path, instruction.Scope, instruction.Size)
+	switch {
+	case instruction.Truncated && instruction.Content == "":
+		label += ", omitted: size limit reached"
+	case instruction.Truncated:
+		label += fmt.Sprintf(", truncated to %d bytes", len(instruction.Content))
+	}
+	return label + ")"
+}
+
+// printPendingChanges summarizes staged overlay changes after a turn.
+func printPendingChanges(app *agent.AgentLoop, out io.Writer) {
+	if !app.OverlayEnabled() {
+		return
+	}
+	changes, err := app.Changeset()
+	if err != nil || changes.Empty() {
+		return
+	}
+	_, _ = fmt.Fprintf(out, "Pending changes (%d):\n%s", len(changes.Changes), changes.Summary())
+	_, _ = fmt.Fprintln(out, "Use /diff to review, /apply to write them to disk, or /discard to drop them.")
+	_, _ = fmt.Fprintln(out)
+}
+
 func printHelp(out io.Writer) {
 	_, _ = fmt.Fprintln(out, "Commands:")
-	_, _ = fmt.Fprintln(out, "  /help  - Show this help message")
-	_, _ = fmt.Fprintln(out, "  /clear - Clear conversation history")
-	_, _ = fmt.Fprintln(out, "  /quit  - Exit the program")
-	_, _ = fmt.Fprintln(out, "  /exit  - Exit the program")
+	_, _ = fmt.Fprintln(out, "  /help    - Show this help message")
+	_, _ = fmt.Fprintln(out, "  /clear   - Clear conversation history")
+	_, _ = fmt.Fprintln(out, "  /diff    - Show pending overlay changes (with -overlay_dir)")
+	_, _ = fmt.Fprintln(out, "  /apply   - Write pending overlay changes to disk")
+	_, _ = fmt.Fprintln(out, "  /discard - Drop pending overlay changes")
+	_, _ = fmt.Fprintln(out, "  /skills  - List the loaded skills")
+	_, _ = fmt.Fprintln(out, "  /skills reload - Reload skills from disk (history is kept)")
+	_, _ = fmt.Fprintln(out, "  /memory  - Show the instruction files (AGENTS.md) in the system prompt")
+	_, _ = fmt.Fprintln(out, "  /<skill> [request] - Run a request with that skill loaded")
+	_, _ = fmt.Fprintln(out, "  /quit    - Exit the program")
+	_, _ = fmt.Fprintln(out, "  /exit    - Exit the program")
+	_, _ = fmt.Fprintln(out, "Mention $<skill> in a message to load that skill. Tab completes commands and skill names.")
 	_, _ = fmt.Fprintln(out)
 }
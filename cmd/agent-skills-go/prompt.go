@@ -0,0 +1,55 @@
+// The prompt subcommand: print the effective system prompt.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/minhyannv/agent-skills-go/pkg/agent"
+	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// promptUsage describes the prompt subcommand.
+const promptUsage = "Usage: agent-skills-go prompt [agent flags]"
+
+// runPrompt builds the agent from the usual flags, including -prompt_template
+// and -prompt_section, and prints its system prompt. The model is never
+// called, so no API key is needed.
+func runPrompt(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("prompt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	cfg, err := parseCLIConfig(flags, args)
+	if err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		_, _ = fmt.Fprintln(stderr, promptUsage)
+		return 2
+	}
+	cfg.SkillsReloadInterval = 0
+
+	app, err := agent.New(context.Background(), cfg,
+		agent.WithLogger(loggerpkg.NewWriterLogger(stderr)),
+		agent.WithChatCompleter(noModel{}),
+	)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer func() { _ = app.Close() }()
+	printSkillDiagnostics(stderr, app.SkillDiagnostics())
+	_, _ = fmt.Fprintln(stdout, app.SystemPrompt)
+	return 0
+}
+
+// noModel stands in for the model when only the prompt is needed.
+type noModel struct{}
+
+func (noModel) New(context.Context, openai.ChatCompletionNewParams, ...option.RequestOption) (*openai.ChatCompletion, error) {
+	return nil, errors.New("no model configured")
+}
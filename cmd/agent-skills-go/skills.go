@@ -0,0 +1,510 @@
+// Skill management subcommands: validate, install, update, remove, list,
+// lock, sync, keygen, sign and verify.
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	"github.com/minhyannv/agent-skills-go/pkg/installer"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+)
+
+// skillsUsage lists the skills subcommands.
+const skillsUsage = `Usage:
+  agent-skills-go skills validate [-json] <dir>...
+  agent-skills-go skills install [-home DIR] [-ref REF] [-path SUBPATH] [-force] <repo|archive|dir>
+  agent-skills-go skills update [-home DIR] [name...]
+  agent-skills-go skills remove [-home DIR] <name>...
+  agent-skills-go skills list [-home DIR] [-json]
+  agent-skills-go skills lock [-skills_lock FILE] [-cache DIR] [-skills_dirs DIR]...
+  agent-skills-go skills sync [-skills_lock FILE] [-cache DIR] [-home DIR]
+  agent-skills-go skills keygen <keyfile>
+  agent-skills-go skills sign -key KEYFILE <dir>...
+  agent-skills-go skills verify [-keys FILE] <dir>...`
+
+// runSkills dispatches skill management commands.
+func runSkills(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprintln(stderr, skillsUsage)
+		return 2
+	}
+	switch args[0] {
+	case "validate":
+		return runSkillsValidate(args[1:], stdout, stderr)
+	case "install":
+		return runSkillsInstall(args[1:], stdout, stderr)
+	case "update":
+		return runSkillsUpdate(args[1:], stdout, stderr)
+	case "remove":
+		return runSkillsRemove(args[1:], stdout, stderr)
+	case "list":
+		return runSkillsList(args[1:], stdout, stderr)
+	case "lock":
+		return runSkillsLock(args[1:], stdout, stderr)
+	case "sync":
+		return runSkillsSync(args[1:], stdout, stderr)
+	case "keygen":
+		return runSkillsKeygen(args[1:], stdout, stderr)
+	case "sign":
+		return runSkillsSign(args[1:], stdout, stderr)
+	case "verify":
+		return runSkillsVerify(args[1:], stdout, stderr)
+	default:
+		_, _ = fmt.Fprintf(stderr, "Error: unknown skills command %q\n%s\n", args[0], skillsUsage)
+		return 2
+	}
+}
+
+// skillReport is the JSON output of skills validate for one skill.
+type skillReport struct {
+	Dir         string              `json:"dir"`
+	Valid       bool                `json:"valid"`
+	Diagnostics []skills.Diagnostic `json:"diagnostics"`
+}
+
+// runSkillsValidate validates each skill dir (or every skill below each dir).
+// It exits 0 when all skills are valid, 1 when any has errors, and 2 on usage errors.
+func runSkillsValidate(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("skills validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "Print results as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		_, _ = fmt.Fprintln(stderr, "Usage: agent-skills-go skills validate [-json] <dir>...")
+		return 2
+	}
+
+	var reports []skillReport
+	for _, root := range flags.Args() {
+		dirs, err := skills.FindSkillDirs(root)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 2
+		}
+		for _, dir := range dirs {
+			diags := skills.Validate(dir)
+			if diags == nil {
+				diags = []skills.Diagnostic{}
+			}
+			reports = append(reports, skillReport{Dir: dir, Valid: !skills.HasErrors(diags), Diagnostics: diags})
+		}
+	}
+
+	exit := 0
+	for _, report := range reports {
+		if !report.Valid {
+			exit = 1
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 2
+		}
+		return exit
+	}
+	for _, report := range reports {
+		status := "ok"
+		if !report.Valid {
+			status = "invalid"
+		}
+		_, _ = fmt.Fprintf(stdout, "%s: %s\n", report.Dir, status)
+		for _, d := range report.Diagnostics {
+			_, _ = fmt.Fprintf(stdout, "  %s\n", d)
+		}
+	}
+	return exit
+}
+
+// skillsFlagSet returns a flag set with the shared -home flag.
+func skillsFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet("skills "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	home := flags.String("home", installer.DefaultHome(), "Skills home directory ($CODEX_HOME/skills or ~/.codex/skills)")
+	return flags, home
+}
+
+// runSkillsInstall installs one skill from a local git repo, archive or directory.
+func runSkillsInstall(args []string, stdout, stderr io.Writer) int {
+	flags, home := skillsFlagSet("install", stderr)
+	ref := flags.String("ref", "", "Git revision to install (git sources only; default HEAD)")
+	subpath := flags.String("path", "", "Skill directory inside the source")
+	force := flags.Bool("force", false, "Replace an installed skill with the same name")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		_, _ = fmt.Fprintln(stderr, skillsUsage)
+		return 2
+	}
+
+	src, err := installer.ParseSource(flags.Arg(0), *ref, *subpath)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+	inst := installer.New(*home)
+	entry, err := inst.Install(context.Background(), src, installer.InstallOptions{Force: *force})
+	if err != nil {
+		printInstallError(stderr, err)
+		return 1
+	}
+	_, _ = fmt.Fprintf(stdout, "Installed %s into %s\n", entry.Name, filepath.Join(inst.Home(), entry.Name))
+	return 0
+}
+
+// runSkillsUpdate reinstalls the named skills, or every locked skill, from their recorded sources.
+func runSkillsUpdate(args []string, stdout, stderr io.Writer) int {
+	flags, home := skillsFlagSet("update", stderr)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	inst := installer.New(*home)
+	names := flags.Args()
+	if len(names) == 0 {
+		list, err := inst.List()
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, item := range list {
+			if item.Entry != nil {
+				names = append(names, item.Name)
+			}
+		}
+	}
+
+	exit := 0
+	for _, name := range names {
+		_, changed, err := inst.Update(context.Background(), name)
+		switch {
+		case err != nil:
+			printInstallError(stderr, err)
+			exit = 1
+		case changed:
+			_, _ = fmt.Fprintf(stdout, "Updated %s\n", name)
+		default:
+			_, _ = fmt.Fprintf(stdout, "%s is up to date\n", name)
+		}
+	}
+	return exit
+}
+
+// runSkillsRemove deletes installed skills.
+func runSkillsRemove(args []string, stdout, stderr io.Writer) int {
+	flags, home := skillsFlagSet("remove", stderr)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		_, _ = fmt.Fprintln(stderr, skillsUsage)
+		return 2
+	}
+	inst := installer.New(*home)
+	exit := 0
+	for _, name := range flags.Args() {
+		if err := inst.Remove(name); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			exit = 1
+			continue
+		}
+		_, _ = fmt.Fprintf(stdout, "Removed %s\n", name)
+	}
+	return exit
+}
+
+// runSkillsList prints the skills in the skills home with their provenance.
+func runSkillsList(args []string, stdout, stderr io.Writer) int {
+	flags, home := skillsFlagSet("list", stderr)
+	asJSON := flags.Bool("json", false, "Print results as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	list, err := installer.New(*home).List()
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		if list == nil {
+			list = []installer.Installed{}
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(list); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if len(list) == 0 {
+		_, _ = fmt.Fprintf(stdout, "No skills installed in %s\n", *home)
+		return 0
+	}
+	for _, item := range list {
+		line := item.Name
+		switch {
+		case item.Entry == nil:
+			line += " (unmanaged)"
+		default:
+			line += " <- " + item.Entry.Source.String()
+			if item.Entry.Commit != "" {
+				line += " (" + shortCommit(item.Entry.Commit) + ")"
+			}
+			if item.Missing {
+				line += " [missing]"
+			} else if item.Modified {
+				line += " [modified]"
+			}
+		}
+		_, _ = fmt.Fprintln(stdout, line)
+	}
+	return 0
+}
+
+// runSkillsKeygen writes a new ed25519 signing key to keyfile and its public
+// key, in trusted keys file format, to keyfile.pub.
+func runSkillsKeygen(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("skills keygen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		_, _ = fmt.Fprintln(stderr, "Usage: agent-skills-go skills keygen <keyfile>")
+		return 2
+	}
+	path := flags.Arg(0)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if _, err := os.Stat(path); err == nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %s already exists\n", path)
+		return 1
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(priv)+"\n"), 0o600); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	line := fmt.Sprintf("%s %s\n", base64.StdEncoding.EncodeToString(pub), skills.KeyID(pub))
+	if err := os.WriteFile(path+".pub", []byte(line), 0o644); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	_, _ = fmt.Fprintf(stdout, "Wrote key %s to %s and %s.pub\n", skills.KeyID(pub), path, path)
+	_, _ = fmt.Fprintf(stdout, "Append %s.pub to %s to trust skills it signs.\n", path, installer.DefaultTrustedKeys())
+	return 0
+}
+
+// readSigningKey reads a private key written by skills keygen.
+func readSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(raw) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%s: not a base64 ed25519 private key", path)
+	}
+	return ed25519.PrivateKey(raw), nil
+}
+
+// runSkillsSign signs each skill dir (or every skill below each dir).
+func runSkillsSign(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("skills sign", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	keyPath := flags.String("key", "", "Private key file written by skills keygen")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *keyPath == "" || flags.NArg() == 0 {
+		_, _ = fmt.Fprintln(stderr, "Usage: agent-skills-go skills sign -key KEYFILE <dir>...")
+		return 2
+	}
+	key, err := readSigningKey(*keyPath)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	exit := 0
+	for _, root := range flags.Args() {
+		dirs, err := skills.FindSkillDirs(root)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 2
+		}
+		for _, dir := range dirs {
+			if err := skills.SignDir(dir, key); err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error: %s: %v\n", dir, err)
+				exit = 1
+				continue
+			}
+			_, _ = fmt.Fprintf(stdout, "Signed %s\n", dir)
+		}
+	}
+	return exit
+}
+
+// runSkillsVerify prints the trust level of each skill dir (or every skill
+// below each dir). It exits 1 when any signature does not match.
+func runSkillsVerify(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("skills verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	keysPath := flags.String("keys", installer.DefaultTrustedKeys(), "Trusted public keys file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		_, _ = fmt.Fprintln(stderr, "Usage: agent-skills-go skills verify [-keys FILE] <dir>...")
+		return 2
+	}
+	keys, err := skills.LoadTrustedKeys(*keysPath)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	exit := 0
+	for _, root := range flags.Args() {
+		dirs, err := skills.FindSkillDirs(root)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 2
+		}
+		for _, dir := range dirs {
+			keyID, err := skills.VerifyDir(dir, keys)
+			switch {
+			case err == nil:
+				_, _ = fmt.Fprintf(stdout, "%s: verified (key %s)\n", dir, keyID)
+			case errors.Is(err, skills.ErrUnsigned), errors.Is(err, skills.ErrUnknownKey):
+				_, _ = fmt.Fprintf(stdout, "%s: untrusted (%v)\n", dir, err)
+			default:
+				_, _ = fmt.Fprintf(stdout, "%s: %v\n", dir, err)
+				exit = 1
+			}
+		}
+	}
+	return exit
+}
+
+// runSkillsLock pins the skills the agent would load, with the same skill
+// flags, in the project lockfile and copies them into the cache.
+func runSkillsLock(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("skills lock", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	cache := flags.String("cache", installer.DefaultCache(), "Directory keeping a copy of each locked skill for sync (empty disables)")
+	cfg, err := parseCLIConfig(flags, args)
+	if err != nil {
+		return 2
+	}
+	cfg = configpkg.Normalize(cfg)
+	if cfg.SkillsLock == "" {
+		_, _ = fmt.Fprintln(stderr, "Error: -skills_lock is empty")
+		return 2
+	}
+	scan, err := skills.ParseScanPolicy(cfg.SkillsScan)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: skills scan: %v\n", err)
+		return 2
+	}
+	list, diags, err := skills.Load(cfg.SkillsDirs, skills.LoadOptions{
+		Lenient:    cfg.SkillsLenient,
+		Duplicates: skills.DuplicatePolicy(cfg.SkillsDuplicates),
+		Scan:       scan,
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: load skills: %v\n", err)
+		return 1
+	}
+	printSkillDiagnostics(stderr, diags)
+	lock, err := installer.Lock(list, cfg.SkillsLock, strings.TrimSpace(*cache))
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := installer.WriteProjectLock(cfg.SkillsLock, lock); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	for _, entry := range lock.Skills {
+		version := entry.Version
+		if version == "" {
+			version = "-"
+		}
+		_, _ = fmt.Fprintf(stdout, "%s\t%s\t%s\t%s\n", entry.Name, version, entry.Digest, entry.Dir)
+	}
+	_, _ = fmt.Fprintf(stdout, "Locked %d skill(s) in %s\n", len(lock.Skills), cfg.SkillsLock)
+	return 0
+}
+
+// runSkillsSync restores every skill in the project lockfile to its locked
+// content, from the cache or from its recorded source. It exits 1 when any
+// skill could not be restored.
+func runSkillsSync(args []string, stdout, stderr io.Writer) int {
+	flags, home := skillsFlagSet("sync", stderr)
+	lockPath := flags.String("skills_lock", configpkg.DefaultConfig().SkillsLock, "Project lockfile to restore")
+	cache := flags.String("cache", installer.DefaultCache(), "Directory holding copies of locked skills (empty fetches from sources only)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	lock, err := installer.ReadProjectLock(*lockPath)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	lockDir, err := filepath.Abs(filepath.Dir(*lockPath))
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	exit := 0
+	for _, result := range installer.New(*home).Sync(context.Background(), lock, lockDir, strings.TrimSpace(*cache)) {
+		switch result.Action {
+		case "failed":
+			_, _ = fmt.Fprintf(stderr, "Error: %s: %s\n", result.Name, result.Err)
+			exit = 1
+		case "restored":
+			_, _ = fmt.Fprintf(stdout, "Restored %s from %s\n", result.Name, result.From)
+		default:
+			_, _ = fmt.Fprintf(stdout, "%s is up to date\n", result.Name)
+		}
+	}
+	return exit
+}
+
+// printInstallError prints validation diagnostics one per line.
+func printInstallError(stderr io.Writer, err error) {
+	var verr *installer.ValidationError
+	if errors.As(err, &verr) {
+		_, _ = fmt.Fprintf(stderr, "Error: skill %s is invalid:\n", verr.Skill)
+		for _, d := range verr.Diagnostics {
+			_, _ = fmt.Fprintf(stderr, "  %s\n", d)
+		}
+		return
+	}
+	_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,299 @@
+// Tests for the agent loop: per-turn prompt updates, failed turns, skill
+// preloading and reloads. They live in agent_test because eval imports agent.
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minhyannv/agent-skills-go/pkg/agent"
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	"github.com/minhyannv/agent-skills-go/pkg/eval"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// Prefixes of the update messages sent ahead of a turn.
+const (
+	listingUpdatePrefix     = "Skills picked for the next request."
+	environmentUpdatePrefix = "The environment changed."
+)
+
+// recordingModel is a scripted model that keeps the messages of every
+// request and fails the request after fail is set.
+type recordingModel struct {
+	*eval.ScriptedModel
+	requests [][]openai.ChatCompletionMessageParamUnion
+	fail     bool
+}
+
+func (m *recordingModel) New(ctx context.Context, body openai.ChatCompletionNewParams, opts ...option.RequestOption) (*openai.ChatCompletion, error) {
+	m.requests = append(m.requests, append([]openai.ChatCompletionMessageParamUnion(nil), body.Messages...))
+	if m.fail {
+		m.fail = false
+		return nil, errors.New("model unavailable")
+	}
+	return m.ScriptedModel.New(ctx, body, opts...)
+}
+
+// last returns the messages of the latest request.
+func (m *recordingModel) last() []openai.ChatCompletionMessageParamUnion {
+	return m.requests[len(m.requests)-1]
+}
+
+// testConfig returns a config that loads the skills under skillsDir and
+// nothing else from the machine.
+func testConfig(t *testing.T, skillsDir string) configpkg.Config {
+	t.Helper()
+	cfg := configpkg.DefaultConfig()
+	cfg.SkillsDirs = []string{skillsDir}
+	cfg.SkillsReloadInterval = 0
+	cfg.SkillsLock = ""
+	cfg.ProjectInstructions, cfg.UserInstructions = nil, ""
+	cfg.AllowedDir = t.TempDir()
+	return cfg
+}
+
+// newAgent starts an agent answering with turns.
+func newAgent(t *testing.T, cfg configpkg.Config, turns []eval.Turn, opts ...agent.AgentOption) (*agent.AgentLoop, *recordingModel) {
+	t.Helper()
+	model := &recordingModel{ScriptedModel: eval.NewScriptedModel(turns)}
+	app, err := agent.New(context.Background(), cfg, append(opts, agent.WithChatCompleter(model))...)
+	if err != nil {
+		t.Fatalf("new agent: %v", err)
+	}
+	t.Cleanup(func() { _ = app.Close() })
+	return app, model
+}
+
+// writeSkill writes dir/name/SKILL.md.
+func writeSkill(t *testing.T, dir, name, frontMatter, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name, "SKILL.md")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	content := "---\nname: " + name + "\n" + frontMatter + "---\n" + body
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	return path
+}
+
+// text returns the text content of a system, user or tool message.
+func text(message openai.ChatCompletionMessageParamUnion) string {
+	switch {
+	case message.OfSystem != nil:
+		return message.OfSystem.Content.OfString.Value
+	case message.OfUser != nil:
+		return message.OfUser.Content.OfString.Value
+	case message.OfTool != nil:
+		return message.OfTool.Content.OfString.Value
+	}
+	return ""
+}
+
+// updates returns the system messages after the system prompt that start with prefix.
+func updates(messages []openai.ChatCompletionMessageParamUnion, prefix string) []string {
+	var found []string
+	for _, message := range messages[1:] {
+		if message.OfSystem != nil && strings.HasPrefix(text(message), prefix) {
+			found = append(found, text(message))
+		}
+	}
+	return found
+}
+
+// contains reports whether a message of messages contains s.
+func contains(messages []openai.ChatCompletionMessageParamUnion, s string) bool {
+	for _, message := range messages {
+		if strings.Contains(text(message), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestShortlistUpdates verifies that a shortlisted listing is sent only when
+// it changes and that a failed turn leaves the history and the shortlist as
+// they were.
+func TestShortlistUpdates(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"pdf", "docx", "xlsx"} {
+		description := "Read, fill and convert " + name + " documents, with page layout, fonts, tables and embedded images."
+		writeSkill(t, dir, name, "description: "+description+"\n", "# "+name+"\n")
+	}
+	cfg := testConfig(t, dir)
+	cfg.SkillsPromptTokens = 100
+	cfg.SkillsTopN = 1
+	app, model := newAgent(t, cfg, []eval.Turn{{Content: "one"}, {Content: "two"}, {Content: "three"}})
+
+	if _, err := app.Run("convert this pdf"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := updates(model.last(), listingUpdatePrefix); len(got) != 1 || !strings.Contains(got[0], "pdf") || strings.Contains(got[0], "docx") {
+		t.Fatalf("expected one listing update with pdf, got %q", got)
+	}
+	if _, err := app.Run("and another pdf"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := updates(model.last(), listingUpdatePrefix); len(got) != 1 {
+		t.Fatalf("expected no update for an unchanged listing, got %q", got)
+	}
+
+	model.fail = true
+	if _, err := app.Run("now a docx"); err == nil {
+		t.Fatal("expected the turn to fail")
+	}
+	if got := updates(model.last(), listingUpdatePrefix); len(got) != 2 || !strings.Contains(got[1], "docx") {
+		t.Fatalf("expected the failed turn to send the docx listing, got %q", got)
+	}
+
+	// A follow-up matches no skill, so the last successful shortlist fills it.
+	if _, err := app.Run("go ahead"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	messages := model.last()
+	if contains(messages, "now a docx") {
+		t.Fatal("expected the failed turn to be dropped from the history")
+	}
+	if got := updates(messages, listingUpdatePrefix); len(got) != 1 || strings.Contains(got[0], "docx") {
+		t.Fatalf("expected the pdf shortlist to survive the failed turn, got %q", got)
+	}
+}
+
+// TestReloadSkills verifies that a reload picks up an edited SKILL.md in the
+// system prompt and in load_skill while keeping the history.
+func TestReloadSkills(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSkill(t, dir, "pdf", "description: Fill PDF forms\n", "Use pdftk.\n")
+	var reloads []agent.SkillReload
+	app, model := newAgent(t, testConfig(t, dir), []eval.Turn{
+		{Content: "hi"},
+		{ToolCalls: []eval.ToolCall{{Name: "load_skill", Arguments: map[string]any{"name": "pdf"}}}},
+		{Content: "done"},
+	}, agent.WithSkillReloadHandler(func(r agent.SkillReload) { reloads = append(reloads, r) }))
+
+	if _, err := app.Run("hello"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	writeSkill(t, dir, "pdf", "description: Merge and split PDF files\n", "Use qpdf.\n")
+	reload, err := app.ReloadSkills()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(reload.Changed) != 1 || reload.Changed[0] != "pdf" || len(reloads) != 1 {
+		t.Fatalf("expected pdf to be reported as changed, got %+v (handler saw %d)", reload, len(reloads))
+	}
+	if skill := app.Skill("pdf"); skill == nil || skill.Description != "Merge and split PDF files" || skill.SkillFilePath != path {
+		t.Fatalf("expected the edited skill, got %+v", skill)
+	}
+
+	if _, err := app.Run("split the pdf"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	messages := model.last()
+	if prompt := text(messages[0]); !strings.Contains(prompt, "Merge and split PDF files") || strings.Contains(prompt, "Fill PDF forms") {
+		t.Fatalf("expected the system prompt to list the edited skill:\n%s", prompt)
+	}
+	if !contains(messages, "hello") {
+		t.Fatal("expected the reload to keep the history")
+	}
+	if !contains(messages, "Use qpdf.") || contains(messages, "Use pdftk.") {
+		t.Fatal("expected load_skill to return the edited body")
+	}
+}
+
+// TestRunWithSkillsPreloads verifies that skills named for a turn, as with
+// $skill mentions, are loaded before the model answers and become active.
+func TestRunWithSkillsPreloads(t *testing.T) {
+	dir := t.TempDir()
+	writeSkill(t, dir, "pdf", "description: Fill PDF forms\nallowed-tools: read_file\n", "Use pdftk.\n")
+	cfg := testConfig(t, dir)
+	notes := filepath.Join(cfg.AllowedDir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("notes\n"), 0o644); err != nil {
+		t.Fatalf("write notes: %v", err)
+	}
+	var events []tools.AuditEvent
+	app, model := newAgent(t, cfg, []eval.Turn{
+		{ToolCalls: []eval.ToolCall{{Name: "read_file", Arguments: map[string]any{"path": notes}}}},
+		{Content: "done"},
+	}, agent.WithAuditHook(func(e tools.AuditEvent) { events = append(events, e) }))
+
+	if _, err := app.RunWithSkills("fill the form", []string{"docx"}); err == nil || len(model.requests) != 0 {
+		t.Fatalf("expected an unknown skill to fail before any request, got %v", err)
+	}
+	if _, err := app.RunWithSkills("fill the form", []string{"pdf"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	first := model.requests[0]
+	var loaded bool
+	for i, message := range first {
+		if message.OfAssistant == nil || len(message.OfAssistant.ToolCalls) != 1 {
+			continue
+		}
+		call := message.OfAssistant.ToolCalls[0].Function
+		loaded = call.Name == "load_skill" && strings.Contains(call.Arguments, `"pdf"`) &&
+			i+1 < len(first) && strings.Contains(text(first[i+1]), "Use pdftk.")
+	}
+	if !loaded || !strings.Contains(text(first[len(first)-3]), "fill the form") {
+		t.Fatalf("expected the user message followed by a load_skill call and its result, got %d messages", len(first))
+	}
+	if len(events) != 2 || events[0].Tool != "load_skill" || !events[0].Allowed {
+		t.Fatalf("expected an audited load_skill call, got %+v", events)
+	}
+	if events[1].Tool != "read_file" || events[1].Skill != "pdf" || !events[1].Allowed {
+		t.Fatalf("expected read_file to run under pdf, got %+v", events[1])
+	}
+}
+
+// TestEnvironmentUpdates verifies that the environment section is sent again
+// only when it changes, here through the working directory.
+func TestEnvironmentUpdates(t *testing.T) {
+	dir := t.TempDir()
+	writeSkill(t, dir, "pdf", "description: Fill PDF forms\n", "Use pdftk.\n")
+	start := t.TempDir()
+	moved := filepath.Join(t.TempDir(), "moved")
+	if err := os.Mkdir(moved, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	// Registered after the temporary directories, so it runs before they are removed.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	chdir := func(dir string) {
+		t.Helper()
+		if err := os.Chdir(dir); err != nil {
+			t.Fatalf("chdir: %v", err)
+		}
+	}
+
+	chdir(start)
+	app, model := newAgent(t, testConfig(t, dir), []eval.Turn{{Content: "one"}, {Content: "two"}, {Content: "three"}})
+	if _, err := app.Run("first"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := updates(model.last(), environmentUpdatePrefix); len(got) != 0 {
+		t.Fatalf("expected no update for the environment in the system prompt, got %q", got)
+	}
+	chdir(moved)
+	if _, err := app.Run("second"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := updates(model.last(), environmentUpdatePrefix); len(got) != 1 || !strings.Contains(got[0], "moved") {
+		t.Fatalf("expected one update with the new working directory, got %q", got)
+	}
+	if _, err := app.Run("third"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := updates(model.last(), environmentUpdatePrefix); len(got) != 1 {
+		t.Fatalf("expected no update for an unchanged environment, got %q", got)
+	}
+}
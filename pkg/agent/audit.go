@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+)
+
+// auditLog appends tool call decisions to a file as JSON lines.
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func openAuditLog(path string) (*auditLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	return &auditLog{file: file}, nil
+}
+
+func (l *auditLog) record(event tools.AuditEvent) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.file.Write(append(line, '\n'))
+}
+
+func (l *auditLog) Close() error {
+	return l.file.Close()
+}
+
+// auditHook combines the audit log and the WithAuditHook callback.
+func auditHook(log *auditLog, hook func(tools.AuditEvent)) func(tools.AuditEvent) {
+	switch {
+	case log == nil:
+		return hook
+	case hook == nil:
+		return log.record
+	default:
+		return func(event tools.AuditEvent) {
+			log.record(event)
+			hook(event)
+		}
+	}
+}
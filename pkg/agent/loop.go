@@ -2,14 +2,15 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	"github.com/minhyannv/agent-skills-go/pkg/mcp"
 	"github.com/minhyannv/agent-skills-go/pkg/prompt"
 	"github.com/minhyannv/agent-skills-go/pkg/skills"
 	"github.com/minhyannv/agent-skills-go/pkg/tools"
 	"github.com/openai/openai-go/option"
-	"path/filepath"
 	"strings"
 
 	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
@@ -19,11 +20,38 @@ import (
 // AgentLoop holds agent runtime state.
 type AgentLoop struct {
 	config       configpkg.Config
-	client       openai.Client
+	completer    ChatCompleter
 	tools        *tools.Registry
+	overlay      *tools.OverlayFS
+	mcpClients   []*mcp.Client
+	audit        *auditLog
+	skills       []*skills.Skill
+	skillsFS     []skills.FSRoot
+	skillDiags   []skills.Diagnostic
+	shortlist    *skillShortlist
+	prompt       *prompt.Template
+	instructions []prompt.Instruction
 	SystemPrompt string
 	history      []openai.ChatCompletionMessageParamUnion
 
+	// fingerprints are the skill fingerprints taken at load time, which
+	// reloads compare against.
+	fingerprints map[string]string
+
+	// environment is the environment section the model last saw, and
+	// promptEnvironment the one in SystemPrompt.
+	environment       string
+	promptEnvironment string
+	// listing is the skills section the model last saw when the catalog is
+	// shortlisted, and promptListing the one in SystemPrompt.
+	listing       string
+	promptListing string
+
+	// watcher signals skill changes, applied by Run at the next turn.
+	watcher       *skills.Watcher
+	stopWatcher   context.CancelFunc
+	onSkillReload func(SkillReload)
+
 	ctx     context.Context
 	logger  loggerpkg.Logger
 	verbose bool
@@ -46,84 +74,156 @@ func New(ctx context.Context, cfg configpkg.Config, opts ...AgentOption) (*Agent
 		"model":       cfg.Model,
 		"base_url":    cfg.BaseURL,
 	})
-	if cfg.APIKey == "" {
-		return nil, errors.New("APIKey is not set")
+	if _, err := tools.ParseEnvelopeMode(cfg.ToolResultEnvelope); err != nil {
+		return nil, err
 	}
-	if strings.TrimSpace(cfg.Model) == "" {
-		return nil, errors.New("Model is not set")
+	completer := deps.completer
+	if completer == nil {
+		if cfg.APIKey == "" {
+			return nil, errors.New("APIKey is not set")
+		}
+		if strings.TrimSpace(cfg.Model) == "" {
+			return nil, errors.New("Model is not set")
+		}
+		client := newOpenAIClient(cfg)
+		completer = &client.Chat.Completions
 	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	loggerpkg.Debug(cfg.Verbose, deps.logger, "loading skills", map[string]any{
-		"skills_dirs": cfg.SkillsDirs,
+	promptTemplate, err := prompt.LoadTemplate(cfg.PromptTemplate, cfg.PromptSections)
+	if err != nil {
+		return nil, err
+	}
+	instructions, err := prompt.LoadInstructions(prompt.InstructionOptions{
+		Names:      cfg.ProjectInstructions,
+		WorkingDir: ".",
+		Root:       cfg.AllowedDir,
+		UserFile:   cfg.UserInstructions,
+		MaxBytes:   cfg.InstructionsMaxBytes,
 	})
-	skillList, err := skills.LoadFromDirs(cfg.SkillsDirs)
 	if err != nil {
-		return nil, fmt.Errorf("load skills: %w", err)
+		return nil, err
 	}
-	if cfg.Verbose {
-		loggerpkg.Debug(cfg.Verbose, deps.logger, "skills loaded", map[string]any{
-			"count": len(skillList),
+	for _, instruction := range instructions {
+		loggerpkg.Debug(cfg.Verbose, deps.logger, "instructions loaded", map[string]any{
+			"path":      instruction.Path,
+			"scope":     instruction.Scope,
+			"bytes":     instruction.Size,
+			"truncated": instruction.Truncated,
 		})
-		for _, skill := range skillList {
-			loggerpkg.Debug(cfg.Verbose, deps.logger, "skill discovered", map[string]any{
-				"name":        skill.Name,
-				"path":        skill.SkillFilePath,
-				"description": skill.Description,
-			})
-		}
 	}
-
-	systemPrompt := prompt.BuildSystemPrompt(skillList)
-	if strings.TrimSpace(systemPrompt) == "" {
-		return nil, errors.New("system prompt is empty")
+	skillList, skillDiags, err := LoadSkills(cfg, deps.skillsFS, deps.logger)
+	if err != nil {
+		return nil, err
 	}
-	loggerpkg.Debug(cfg.Verbose, deps.logger, "system prompt ready", map[string]any{
-		"bytes": len(systemPrompt),
-	})
+	skillIndex := skills.NewIndex(skillList)
+	// LoadSkills has already rejected an invalid policy.
+	resourceScan, _ := skills.ParseScanPolicy(cfg.SkillsScan)
 
-	client := newOpenAIClient(cfg)
-
-	allowedDirs := []string{}
-	if cfg.AllowedDir != "" {
-		allowedDirs = append(allowedDirs, cfg.AllowedDir)
-		for _, dir := range cfg.SkillsDirs {
-			if abs, err := filepath.Abs(dir); err == nil {
-				allowedDirs = append(allowedDirs, abs)
-			} else {
-				allowedDirs = append(allowedDirs, dir)
-			}
-		}
-	}
+	allowedDirs := configpkg.ToolAllowedDirs(cfg)
 	loggerpkg.Debug(cfg.Verbose, deps.logger, "allowed dirs resolved", map[string]any{
 		"allowed_dirs": allowedDirs,
 	})
 
+	fileSystem, overlay, err := newFileSystem(cfg, deps.fileSystem, allowedDirs)
+	if err != nil {
+		return nil, err
+	}
+	if overlay != nil {
+		loggerpkg.Debug(cfg.Verbose, deps.logger, "overlay mode enabled", map[string]any{
+			"root":        overlay.Root(),
+			"overlay_dir": cfg.OverlayDir,
+		})
+	}
+
+	var audit *auditLog
+	if cfg.AuditLog != "" {
+		if audit, err = openAuditLog(cfg.AuditLog); err != nil {
+			return nil, err
+		}
+	}
+
 	toolCtx := tools.Context{
 		MaxReadBytes: tools.DefaultMaxReadBytes,
 		Verbose:      cfg.Verbose,
 		AllowedDirs:  allowedDirs,
+		FS:           fileSystem,
 		Ctx:          ctx,
 		Logger:       deps.logger,
+		Skills:       skillList,
+		SkillIndex:   skillIndex,
+		ResourceScan: resourceScan,
+		Audit:        auditHook(audit, deps.audit),
 	}
 	registeredTools := tools.New(toolCtx)
+	mcpClients, err := connectMCPServers(ctx, cfg, registeredTools, deps.logger)
+	if err != nil {
+		if audit != nil {
+			_ = audit.Close()
+		}
+		return nil, err
+	}
 	loggerpkg.Debug(cfg.Verbose, deps.logger, "tools registered", map[string]any{
 		"count": len(registeredTools.Definitions()),
 	})
 
-	return &AgentLoop{
-		config:       cfg,
-		client:       client,
-		tools:        registeredTools,
-		SystemPrompt: systemPrompt,
-		history:      []openai.ChatCompletionMessageParamUnion{openai.SystemMessage(systemPrompt)},
+	a := &AgentLoop{
+		config:        cfg,
+		completer:     completer,
+		tools:         registeredTools,
+		overlay:       overlay,
+		mcpClients:    mcpClients,
+		audit:         audit,
+		onSkillReload: deps.onSkillReload,
+		skillsFS:      deps.skillsFS,
+		prompt:        promptTemplate,
+		instructions:  instructions,
 
 		ctx:     ctx,
 		logger:  deps.logger,
 		verbose: cfg.Verbose,
-	}, nil
+	}
+	// Fail on template errors here; later renders fall back to the default.
+	env := prompt.CurrentEnvironment(cfg)
+	if _, err := a.renderSystemPrompt(skillList, prompt.SkillListing{Total: len(skillList)}, env); err != nil {
+		_ = a.Close()
+		return nil, err
+	}
+	a.fingerprints = fingerprintSkills(skillList)
+	a.useSkills(skillList, skillIndex, skillDiags, env)
+	if strings.TrimSpace(a.SystemPrompt) == "" {
+		_ = a.Close()
+		return nil, errors.New("system prompt is empty")
+	}
+	loggerpkg.Debug(cfg.Verbose, deps.logger, "system prompt ready", map[string]any{
+		"bytes": len(a.SystemPrompt),
+	})
+	a.startSkillWatcher(ctx)
+	return a, nil
+}
+
+// newFileSystem picks the tool filesystem and, in overlay mode, wraps it copy-on-write.
+func newFileSystem(cfg configpkg.Config, injected tools.FileSystem, allowedDirs []string) (tools.FileSystem, *tools.OverlayFS, error) {
+	fileSystem := injected
+	if fileSystem == nil {
+		fileSystem = tools.NewOSFS(allowedDirs)
+	}
+	if overlay, ok := fileSystem.(*tools.OverlayFS); ok {
+		return overlay, overlay, nil
+	}
+	if cfg.OverlayDir == "" {
+		return fileSystem, nil, nil
+	}
+	if cfg.AllowedDir == "" {
+		return nil, nil, errors.New("overlay mode requires AllowedDir")
+	}
+	overlay, err := tools.NewDirOverlayFS(fileSystem, cfg.AllowedDir, cfg.OverlayDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("init overlay: %w", err)
+	}
+	return overlay, overlay, nil
 }
 
 func newOpenAIClient(cfg configpkg.Config) openai.Client {
@@ -140,11 +240,11 @@ func newOpenAIClient(cfg configpkg.Config) openai.Client {
 // runOnce performs one model completion request.
 func (a *AgentLoop) runOnce(params openai.ChatCompletionNewParams) (openai.ChatCompletionMessage, error) {
 	a.debugf("[verbose] iteration: sending request")
-	completion, err := a.client.Chat.Completions.New(a.ctx, params)
+	completion, err := a.completer.New(a.ctx, params)
 	if err != nil {
 		return openai.ChatCompletionMessage{}, err
 	}
-	if len(completion.Choices) == 0 {
+	if completion == nil || len(completion.Choices) == 0 {
 		return openai.ChatCompletionMessage{}, errors.New("empty completion choices")
 	}
 	return completion.Choices[0].Message, nil
@@ -180,12 +280,45 @@ func (a *AgentLoop) runIteration(
 // Run processes one user input and returns a single final assistant message.
 // Conversation state is persisted inside AgentLoop and can be reset via Reset.
 func (a *AgentLoop) Run(userInput string) (openai.ChatCompletionMessage, error) {
+	return a.RunWithSkills(userInput, nil)
+}
+
+// RunWithSkills is Run with the named skills loaded before the model answers,
+// as if it had called load_skill for each, so explicit requests such as
+// $skill-name mentions do not depend on the model's choice. The last skill
+// becomes the active skill.
+func (a *AgentLoop) RunWithSkills(userInput string, skillNames []string) (openai.ChatCompletionMessage, error) {
 	userInput = strings.TrimSpace(userInput)
 	if userInput == "" {
 		return openai.ChatCompletionMessage{}, errors.New("user input is required")
 	}
+	// Describing the environment runs git, so it is done once for the turn.
+	env := prompt.CurrentEnvironment(a.config)
+	a.reloadIfChanged(env)
+	for _, name := range skillNames {
+		if a.Skill(name) == nil {
+			return openai.ChatCompletionMessage{}, fmt.Errorf("unknown skill: %s", name)
+		}
+	}
 	previousLen := len(a.history)
+	listing := a.listing
+	var picked []*skills.Skill
+	if a.shortlist != nil {
+		var update *openai.ChatCompletionMessageParamUnion
+		listing, picked, update = a.listingUpdate(userInput)
+		a.debugf("[verbose] skill shortlist: %v", namesOf(picked))
+		if update != nil {
+			a.history = append(a.history, *update)
+		}
+	}
+	environment, update := a.environmentUpdate(env)
+	if update != nil {
+		a.debugf("[verbose] environment changed; sending an update")
+		a.history = append(a.history, *update)
+	}
 	a.history = append(a.history, openai.UserMessage(userInput))
+	a.tools.StartTurn()
+	a.history = a.loadSkillsForTurn(a.history, skillNames)
 
 	finalMessage, err := a.runIteration(a.history, a.config.MaxTurns)
 	if err != nil {
@@ -193,13 +326,97 @@ func (a *AgentLoop) Run(userInput string) (openai.ChatCompletionMessage, error)
 		return openai.ChatCompletionMessage{}, err
 	}
 
+	a.environment = environment
+	a.listing = listing
+	if a.shortlist != nil {
+		a.shortlist.commit(picked)
+	}
 	a.history = append(a.history, finalMessage.ToParam())
 	return finalMessage, nil
 }
 
-// Reset clears conversation history and keeps only the system prompt.
+// loadSkillsForTurn appends an assistant load_skill call for each skill and
+// its result. The calls go through the registry, so they are audited and
+// activate the skill like a call made by the model.
+func (a *AgentLoop) loadSkillsForTurn(messages []openai.ChatCompletionMessageParamUnion, skillNames []string) []openai.ChatCompletionMessageParamUnion {
+	if len(skillNames) == 0 {
+		return messages
+	}
+	calls := make([]openai.ChatCompletionMessageToolCall, len(skillNames))
+	for i, name := range skillNames {
+		args, _ := json.Marshal(map[string]string{"name": name})
+		calls[i] = openai.ChatCompletionMessageToolCall{
+			ID:       fmt.Sprintf("user_load_skill_%d_%d", len(messages), i),
+			Type:     "function",
+			Function: openai.ChatCompletionMessageToolCallFunction{Name: "load_skill", Arguments: string(args)},
+		}
+	}
+	a.debugf("[verbose] loading requested skills: %v", skillNames)
+	message := openai.ChatCompletionMessage{Role: "assistant", ToolCalls: calls}
+	return a.appendToolResponses(append(messages, message.ToParam()), calls)
+}
+
+// Skills returns the loaded skills, sorted by name.
+func (a *AgentLoop) Skills() []*skills.Skill {
+	return append([]*skills.Skill(nil), a.skills...)
+}
+
+// Skill returns the loaded skill called name, or nil.
+func (a *AgentLoop) Skill(name string) *skills.Skill {
+	for _, skill := range a.skills {
+		if skill.Name == name {
+			return skill
+		}
+	}
+	return nil
+}
+
+// Close stops the skill watcher, shuts down connections to MCP servers and
+// closes the audit log.
+func (a *AgentLoop) Close() error {
+	a.stopSkillWatcher()
+	var errs []error
+	for _, client := range a.mcpClients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close mcp server %s: %w", client.Name(), err))
+		}
+	}
+	a.mcpClients = nil
+	if a.overlay != nil {
+		if err := a.overlay.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close overlay: %w", err))
+		}
+	}
+	if a.audit != nil {
+		if err := a.audit.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close audit log: %w", err))
+		}
+		a.audit = nil
+	}
+	return errors.Join(errs...)
+}
+
+// Instructions returns the instruction files included in the system prompt,
+// in precedence order.
+func (a *AgentLoop) Instructions() []prompt.Instruction {
+	return append([]prompt.Instruction(nil), a.instructions...)
+}
+
+// SkillDiagnostics returns the problems found while loading skills, such as
+// skipped files in lenient mode and duplicate names.
+func (a *AgentLoop) SkillDiagnostics() []skills.Diagnostic {
+	return append([]skills.Diagnostic(nil), a.skillDiags...)
+}
+
+// Reset clears conversation history and the active skill and keeps only the system prompt.
 func (a *AgentLoop) Reset() {
+	a.tools.SetActiveSkill(nil)
+	if a.shortlist != nil {
+		a.shortlist.commit(nil)
+	}
 	a.history = []openai.ChatCompletionMessageParamUnion{openai.SystemMessage(a.SystemPrompt)}
+	a.environment = a.promptEnvironment
+	a.listing = a.promptListing
 }
 
 func (a *AgentLoop) debugf(format string, args ...any) {
@@ -224,6 +441,7 @@ func (a *AgentLoop) appendToolResponses(
 		if err != nil {
 			output = fmt.Sprintf(`{"ok":false,"error":%q}`, err.Error())
 		}
+		output = a.tools.WrapResult(tools.EnvelopeMode(a.config.ToolResultEnvelope), call, output)
 		updated = append(updated, openai.ToolMessage(output, call.ID))
 	}
 	return updated
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+	"github.com/minhyannv/agent-skills-go/pkg/mcp"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+)
+
+// connectMCPServers connects to every configured MCP server and mounts its tools.
+// On failure, already opened connections are closed.
+func connectMCPServers(ctx context.Context, cfg configpkg.Config, registry *tools.Registry, logger loggerpkg.Logger) ([]*mcp.Client, error) {
+	clients := make([]*mcp.Client, 0, len(cfg.MCPServers))
+	closeAll := func() {
+		for _, client := range clients {
+			_ = client.Close()
+		}
+	}
+
+	for _, server := range cfg.MCPServers {
+		loggerpkg.Debug(cfg.Verbose, logger, "connecting mcp server", map[string]any{
+			"name":      server.Name,
+			"transport": server.Transport,
+		})
+		client, err := mcp.Connect(ctx, server, mcp.ClientOptions{})
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("connect mcp: %w", err)
+		}
+		clients = append(clients, client)
+		if err := mcp.Mount(ctx, registry, client, logger); err != nil {
+			closeAll()
+			return nil, fmt.Errorf("mount mcp server %s: %w", server.Name, err)
+		}
+	}
+	return clients, nil
+}
+
+// filterSkillsByDependencies drops skills whose MCP dependencies name a server
+// that is not configured, so the model is never offered a skill it cannot run.
+// Dependency types other than mcp are not checked.
+func filterSkillsByDependencies(cfg configpkg.Config, skillList []*skills.Skill, logger loggerpkg.Logger) []*skills.Skill {
+	servers := make(map[string]bool, len(cfg.MCPServers))
+	for _, server := range cfg.MCPServers {
+		servers[server.Name] = true
+	}
+	available := func(dep skills.Dependency) bool {
+		return dep.Type != skills.DependencyTypeMCP || servers[dep.Value]
+	}
+
+	out := skillList[:0:0]
+	for _, skill := range skillList {
+		missing := skill.MissingDependencies(available)
+		if len(missing) == 0 {
+			out = append(out, skill)
+			continue
+		}
+		names := make([]string, 0, len(missing))
+		for _, dep := range missing {
+			names = append(names, dep.Value)
+		}
+		loggerpkg.Warn(logger, "skill skipped: missing mcp servers", map[string]any{
+			"skill":   skill.Name,
+			"missing": names,
+		})
+	}
+	return out
+}
@@ -1,12 +1,32 @@
 package agent
 
-import loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+import (
+	"context"
+	"io/fs"
+
+	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
 
 // AgentOption configures optional runtime dependencies for AgentLoop.
 type AgentOption func(*agentDeps)
 
 type agentDeps struct {
-	logger loggerpkg.Logger
+	logger        loggerpkg.Logger
+	fileSystem    tools.FileSystem
+	onSkillReload func(SkillReload)
+	audit         func(tools.AuditEvent)
+	skillsFS      []skills.FSRoot
+	completer     ChatCompleter
+}
+
+// ChatCompleter sends chat completion requests. *openai.ChatCompletionService,
+// the Chat.Completions field of an openai.Client, implements it.
+type ChatCompleter interface {
+	New(ctx context.Context, body openai.ChatCompletionNewParams, opts ...option.RequestOption) (*openai.ChatCompletion, error)
 }
 
 // WithLogger injects a logger dependency.
@@ -15,3 +35,54 @@ func WithLogger(l loggerpkg.Logger) AgentOption {
 		d.logger = l
 	}
 }
+
+// WithFileSystem injects the filesystem used by read_file and write_file.
+// When unset, tools use the OS filesystem restricted to the allowed directories.
+func WithFileSystem(fsys tools.FileSystem) AgentOption {
+	return func(d *agentDeps) {
+		d.fileSystem = fsys
+	}
+}
+
+// WithSkillReloadHandler registers a callback for every skill reload, whether
+// triggered by the watcher (see Config.SkillsReloadInterval) or ReloadSkills.
+// It runs on the goroutine that called Run or ReloadSkills.
+func WithSkillReloadHandler(fn func(SkillReload)) AgentOption {
+	return func(d *agentDeps) {
+		d.onSkillReload = fn
+	}
+}
+
+// WithAuditHook receives every tool call decision, including calls denied by
+// the active skill's allowed-tools. It is called in addition to Config.AuditLog.
+func WithAuditHook(fn func(tools.AuditEvent)) AgentOption {
+	return func(d *agentDeps) {
+		d.audit = fn
+	}
+}
+
+// WithSkillsFS loads skills from root in fsys, such as an embed.FS of bundled
+// skills, after Config.SkillsDirs. Their files are read through fsys and
+// exposed to the tools as skill://<name>/<path>. Repeat it for several roots.
+func WithSkillsFS(fsys fs.FS, root string) AgentOption {
+	return func(d *agentDeps) {
+		d.skillsFS = append(d.skillsFS, skills.FSRoot{FS: fsys, Root: root})
+	}
+}
+
+// WithSystemSkillsFS is WithSkillsFS for skills bundled with the application,
+// which get system trust without a signature.
+func WithSystemSkillsFS(fsys fs.FS, root string) AgentOption {
+	return func(d *agentDeps) {
+		d.skillsFS = append(d.skillsFS, skills.FSRoot{FS: fsys, Root: root, System: true})
+	}
+}
+
+// WithChatCompleter replaces the OpenAI client built from Config, for example
+// with a scripted model in tests and evaluations. Config.APIKey and
+// Config.BaseURL are not used then, and Config.Model is only sent along.
+func WithChatCompleter(c ChatCompleter) AgentOption {
+	return func(d *agentDeps) {
+		d.completer = c
+	}
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"errors"
+
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+)
+
+// ErrNoOverlay is returned by changeset methods when overlay mode is disabled.
+var ErrNoOverlay = errors.New("overlay mode is not enabled")
+
+// OverlayEnabled reports whether tool writes are staged in a copy-on-write overlay.
+func (a *AgentLoop) OverlayEnabled() bool {
+	return a.overlay != nil
+}
+
+// Changeset returns the files added, modified and deleted by tools so far,
+// including writes made by run_shell subprocesses. Call it after Run to review.
+func (a *AgentLoop) Changeset() (tools.Changeset, error) {
+	if a.overlay == nil {
+		return tools.Changeset{}, ErrNoOverlay
+	}
+	return a.overlay.Changes()
+}
+
+// ApplyChanges commits all staged changes to disk at once and returns what was applied.
+func (a *AgentLoop) ApplyChanges() (tools.Changeset, error) {
+	if a.overlay == nil {
+		return tools.Changeset{}, ErrNoOverlay
+	}
+	changes, err := a.overlay.Changes()
+	if err != nil {
+		return tools.Changeset{}, err
+	}
+	if err := a.overlay.Commit(); err != nil {
+		return tools.Changeset{}, err
+	}
+	a.debugf("[verbose] overlay: applied %d change(s)", len(changes.Changes))
+	return changes, nil
+}
+
+// DiscardChanges drops all staged changes, leaving the real tree untouched.
+func (a *AgentLoop) DiscardChanges() error {
+	if a.overlay == nil {
+		return ErrNoOverlay
+	}
+	a.debugf("[verbose] overlay: discarding staged changes")
+	return a.overlay.Discard()
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"github.com/minhyannv/agent-skills-go/pkg/prompt"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+	"github.com/openai/openai-go"
+
+	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+)
+
+// systemPrompt renders the prompt template listing list in env. A template
+// that fails to render is reported and the default template is used instead.
+// The environment and skills sections it contains become the ones the model
+// last saw.
+func (a *AgentLoop) systemPrompt(list []*skills.Skill, listing prompt.SkillListing, env prompt.Environment) string {
+	data := a.promptData(list, listing)
+	data.Environment = env
+	tmpl := a.template()
+	out, err := tmpl.Render(data)
+	if err != nil {
+		loggerpkg.Warn(a.logger, "prompt template failed; using the default", map[string]any{"error": err.Error()})
+		tmpl = prompt.DefaultTemplate()
+		out, _ = tmpl.Render(data)
+	}
+	a.promptEnvironment, _ = tmpl.RenderSection("environment", data)
+	a.environment = a.promptEnvironment
+	a.promptListing, _ = tmpl.RenderSection("skills", data)
+	a.listing = a.promptListing
+	return out
+}
+
+func (a *AgentLoop) renderSystemPrompt(list []*skills.Skill, listing prompt.SkillListing, env prompt.Environment) (string, error) {
+	data := a.promptData(list, listing)
+	data.Environment = env
+	return a.template().Render(data)
+}
+
+func (a *AgentLoop) template() *prompt.Template {
+	if a.prompt == nil {
+		return prompt.DefaultTemplate()
+	}
+	return a.prompt
+}
+
+// promptData returns the template data for a prompt listing list. Skill
+// script tools are left out of Tools; load_skill lists them. Environment is
+// left empty: describing it runs git, so only renders of the environment
+// section fill it in.
+func (a *AgentLoop) promptData(list []*skills.Skill, listing prompt.SkillListing) prompt.Data {
+	data := prompt.NewData(list, listing).WithConfig(a.config)
+	data.Instructions = a.instructions
+	data.ToolEnvelope = a.config.ToolResultEnvelope != string(tools.EnvelopeOff)
+	scripts := map[string]bool{}
+	for _, skill := range a.skills {
+		for _, script := range skill.Scripts {
+			scripts[tools.ScriptToolName(skill, script)] = true
+		}
+	}
+	data.Tools = nil
+	for _, name := range a.tools.Names() {
+		if !scripts[name] {
+			data.Tools = append(data.Tools, name)
+		}
+	}
+	return data
+}
+
+// environmentUpdate renders the environment section for env, the environment
+// of the coming turn. When it differs from the one the model last saw, it also
+// returns a message carrying it. The system prompt is left alone, so a cached
+// prompt prefix stays valid; the update is kept in the history like any other
+// message.
+func (a *AgentLoop) environmentUpdate(env prompt.Environment) (string, *openai.ChatCompletionMessageParamUnion) {
+	data := a.promptData(a.skills, prompt.SkillListing{Total: len(a.skills)})
+	data.Environment = env
+	block, err := a.template().RenderSection("environment", data)
+	if err != nil || block == "" || block == a.environment {
+		return a.environment, nil
+	}
+	message := openai.SystemMessage("The environment changed. This replaces the Environment section of the system prompt:\n\n" + block)
+	return block, &message
+}
+
+// listingUpdate shortlists the skills for userInput and returns their listing
+// with the skills picked. When the listing differs from the one the model last
+// saw, it also returns a message carrying it, leaving the system prompt alone
+// as environmentUpdate does.
+func (a *AgentLoop) listingUpdate(userInput string) (string, []*skills.Skill, *openai.ChatCompletionMessageParamUnion) {
+	picked, listing := a.shortlist.pick(userInput)
+	block, err := a.template().RenderSection("skills", a.promptData(picked, listing))
+	if err != nil || block == "" || block == a.listing {
+		return a.listing, picked, nil
+	}
+	message := openai.SystemMessage("Skills picked for the next request. This replaces the Available Skills list of the system prompt:\n\n" + block)
+	return block, picked, &message
+}
@@ -0,0 +1,241 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	"github.com/minhyannv/agent-skills-go/pkg/installer"
+	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+	"github.com/minhyannv/agent-skills-go/pkg/prompt"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/openai/openai-go"
+)
+
+// SkillReload describes a reload of the skill catalog.
+type SkillReload struct {
+	// Skills is the number of skills loaded after the reload.
+	Skills int
+	// Added, Removed and Changed list skill names, sorted.
+	Added   []string
+	Removed []string
+	Changed []string
+	// Diagnostics are the loader diagnostics of the new catalog.
+	Diagnostics []skills.Diagnostic
+	// Err is set when the reload failed; the previous skills stay in use.
+	Err error
+}
+
+// Empty reports whether the reload changed no skills.
+func (r SkillReload) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// LoadSkills loads the skills an agent built from cfg would use: the skill
+// directories and fsRoots, scanned, checked against the project lock and
+// assigned a trust level, without the skills whose MCP dependencies are not
+// configured. Programs serving the same skills, like serve-mcp, call it so
+// they see the agent's catalog. logger may be nil.
+func LoadSkills(cfg configpkg.Config, fsRoots []skills.FSRoot, logger loggerpkg.Logger) ([]*skills.Skill, []skills.Diagnostic, error) {
+	loggerpkg.Debug(cfg.Verbose, logger, "loading skills", map[string]any{
+		"skills_dirs": cfg.SkillsDirs,
+		"lenient":     cfg.SkillsLenient,
+		"duplicates":  cfg.SkillsDuplicates,
+		"fs_roots":    len(fsRoots),
+	})
+	scan, err := skills.ParseScanPolicy(cfg.SkillsScan)
+	if err != nil {
+		return nil, nil, fmt.Errorf("skills scan: %w", err)
+	}
+	lockMode, err := installer.ParseLockMode(cfg.SkillsLockMode)
+	if err != nil {
+		return nil, nil, fmt.Errorf("skills lock: %w", err)
+	}
+	mismatch, err := skills.ParseMismatchPolicy(cfg.SkillsSignatureMismatch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("skills signature: %w", err)
+	}
+	skillList, diags, err := skills.Load(cfg.SkillsDirs, skills.LoadOptions{
+		Lenient:    cfg.SkillsLenient,
+		Duplicates: skills.DuplicatePolicy(cfg.SkillsDuplicates),
+		FS:         fsRoots,
+		Scan:       scan,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("load skills: %w", err)
+	}
+	if lockMode != installer.LockOff {
+		lockDiags, err := installer.CheckProjectLock(cfg.SkillsLock, skillList, lockMode == installer.LockRefuse)
+		if err != nil {
+			return nil, nil, err
+		}
+		diags = append(diags, lockDiags...)
+	}
+	keys, err := skills.LoadTrustedKeys(cfg.SkillsTrustedKeys)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load trusted keys: %w", err)
+	}
+	skillList, trustDiags := skills.ApplyTrust(skillList, skills.TrustOptions{
+		Keys:           keys,
+		RefuseMismatch: mismatch == skills.MismatchRefuse,
+		SystemDirs:     cfg.SkillsSystemDirs,
+	})
+	diags = append(diags, trustDiags...)
+	skillList = filterSkillsByDependencies(cfg, skillList, logger)
+	if cfg.Verbose {
+		loggerpkg.Debug(cfg.Verbose, logger, "skills loaded", map[string]any{
+			"count": len(skillList),
+		})
+		for _, skill := range skillList {
+			loggerpkg.Debug(cfg.Verbose, logger, "skill discovered", map[string]any{
+				"name":        skill.Name,
+				"path":        skill.SkillFilePath,
+				"description": skill.Description,
+				"trust":       skill.Trust,
+			})
+		}
+	}
+	return skillList, diags, nil
+}
+
+// useSkills installs a skill catalog: the shortlist, when the catalog exceeds
+// the prompt budget, and the system prompt, rendered in env, at the head of
+// the history.
+func (a *AgentLoop) useSkills(skillList []*skills.Skill, index *skills.Index, diags []skills.Diagnostic, env prompt.Environment) {
+	a.skills = skillList
+	a.skillDiags = diags
+	a.shortlist = newSkillShortlist(a.config, skillList, index)
+	if a.shortlist != nil {
+		loggerpkg.Debug(a.verbose, a.logger, "skill listing exceeds budget; shortlisting per turn", map[string]any{
+			"skills":     len(skillList),
+			"max_tokens": a.config.SkillsPromptTokens,
+			"top_n":      a.config.SkillsTopN,
+		})
+		picked, listing := a.shortlist.pick("")
+		a.shortlist.commit(picked)
+		a.SystemPrompt = a.systemPrompt(picked, listing, env)
+	} else {
+		a.SystemPrompt = a.systemPrompt(skillList, prompt.SkillListing{Total: len(skillList)}, env)
+	}
+	system := openai.SystemMessage(a.SystemPrompt)
+	if len(a.history) == 0 {
+		a.history = []openai.ChatCompletionMessageParamUnion{system}
+	} else {
+		a.history[0] = system
+	}
+}
+
+// ReloadSkills reloads the skill directories, rebuilds the system prompt and
+// updates the skill tools. The conversation history is kept. On failure the
+// previous skills stay in use. The reload is also passed to the handler set
+// with WithSkillReloadHandler.
+func (a *AgentLoop) ReloadSkills() (SkillReload, error) {
+	reload := a.reloadSkills(prompt.CurrentEnvironment(a.config))
+	return reload, reload.Err
+}
+
+// reloadSkills is ReloadSkills with the system prompt rendered in env.
+func (a *AgentLoop) reloadSkills(env prompt.Environment) SkillReload {
+	reload := a.loadSkills(env)
+	if a.onSkillReload != nil {
+		a.onSkillReload(reload)
+	}
+	return reload
+}
+
+func (a *AgentLoop) loadSkills(env prompt.Environment) SkillReload {
+	skillList, diags, err := LoadSkills(a.config, a.skillsFS, a.logger)
+	if err != nil {
+		return SkillReload{Skills: len(a.skills), Err: err}
+	}
+	fingerprints := fingerprintSkills(skillList)
+	reload := diffSkills(a.fingerprints, fingerprints)
+	reload.Diagnostics = diags
+	a.fingerprints = fingerprints
+
+	index := skills.NewIndex(skillList)
+	a.tools.SetSkills(skillList, index)
+	a.useSkills(skillList, index, diags, env)
+	loggerpkg.Debug(a.verbose, a.logger, "skills reloaded", map[string]any{
+		"count":   reload.Skills,
+		"added":   reload.Added,
+		"removed": reload.Removed,
+		"changed": reload.Changed,
+	})
+	return reload
+}
+
+// reloadIfChanged reloads skills when the watcher saw a change, rendering the
+// system prompt in env. It runs at turn boundaries so the catalog never
+// changes while the model is working.
+func (a *AgentLoop) reloadIfChanged(env prompt.Environment) {
+	if a.watcher == nil {
+		return
+	}
+	select {
+	case <-a.watcher.Changes():
+		a.reloadSkills(env)
+	default:
+	}
+}
+
+// startSkillWatcher polls the skill directories until ctx is done or Close is called.
+func (a *AgentLoop) startSkillWatcher(ctx context.Context) {
+	if a.config.SkillsReloadInterval <= 0 || len(a.config.SkillsDirs) == 0 {
+		return
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	a.watcher = skills.NewWatcher(a.config.SkillsDirs, a.config.SkillsReloadInterval)
+	a.stopWatcher = cancel
+	go a.watcher.Run(watchCtx)
+}
+
+// stopSkillWatcher stops the watcher goroutine, if any.
+func (a *AgentLoop) stopSkillWatcher() {
+	if a.stopWatcher != nil {
+		a.stopWatcher()
+		a.stopWatcher = nil
+	}
+}
+
+// fingerprintSkills returns the Fingerprint of each skill by name. A skill
+// whose fingerprint cannot be computed maps to "", which never matches.
+// Fingerprints are taken when skills are loaded: SKILL.md is read again, so a
+// later call would already see an edit.
+func fingerprintSkills(list []*skills.Skill) map[string]string {
+	fingerprints := make(map[string]string, len(list))
+	for _, skill := range list {
+		fingerprint, err := skill.Fingerprint()
+		if err != nil {
+			fingerprint = ""
+		}
+		fingerprints[skill.Name] = fingerprint
+	}
+	return fingerprints
+}
+
+// diffSkills compares two catalogs given as fingerprints by skill name. A
+// skill counts as changed when its fingerprint differs, or when either
+// fingerprint could not be computed.
+func diffSkills(before, after map[string]string) SkillReload {
+	reload := SkillReload{Skills: len(after)}
+	for name, fingerprint := range after {
+		prev, ok := before[name]
+		switch {
+		case !ok:
+			reload.Added = append(reload.Added, name)
+		case prev == "" || prev != fingerprint:
+			reload.Changed = append(reload.Changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			reload.Removed = append(reload.Removed, name)
+		}
+	}
+	sort.Strings(reload.Added)
+	sort.Strings(reload.Removed)
+	sort.Strings(reload.Changed)
+	return reload
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	"github.com/minhyannv/agent-skills-go/pkg/prompt"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+)
+
+// skillShortlist picks the skills listed in the system prompt for each user turn
+// when the whole catalog does not fit in Config.SkillsPromptTokens.
+type skillShortlist struct {
+	index     *skills.Index
+	total     int
+	topN      int
+	maxTokens int
+	// previous is the last shortlist. Follow-up turns such as "go ahead" match
+	// nothing, so earlier picks fill the remaining slots.
+	previous []*skills.Skill
+}
+
+// newSkillShortlist returns nil when every skill fits in the prompt budget.
+func newSkillShortlist(cfg configpkg.Config, list []*skills.Skill, index *skills.Index) *skillShortlist {
+	if cfg.SkillsPromptTokens <= 0 || len(list) == 0 {
+		return nil
+	}
+	if prompt.EstimateTokens(prompt.ToPromptMarkdown(list)) <= cfg.SkillsPromptTokens {
+		return nil
+	}
+	return &skillShortlist{
+		index:     index,
+		total:     len(list),
+		topN:      cfg.SkillsTopN,
+		maxTokens: cfg.SkillsPromptTokens,
+	}
+}
+
+// pick ranks the skills against query and returns those to list for the turn.
+// The shortlist is not changed; commit records the picks once the turn they
+// were listed for has succeeded.
+func (s *skillShortlist) pick(query string) ([]*skills.Skill, prompt.SkillListing) {
+	picked := make([]*skills.Skill, 0, s.topN)
+	seen := map[*skills.Skill]bool{}
+	add := func(skill *skills.Skill) {
+		if len(picked) < s.topN && !seen[skill] {
+			seen[skill] = true
+			picked = append(picked, skill)
+		}
+	}
+	for _, result := range s.index.Search(query, s.topN) {
+		add(result.Skill)
+	}
+	for _, skill := range s.previous {
+		add(skill)
+	}
+	picked = prompt.FitSkills(picked, s.maxTokens)
+	return picked, prompt.SkillListing{Total: s.total, MaxTokens: s.maxTokens}
+}
+
+// commit makes picked the shortlist that fills the slots of later turns.
+func (s *skillShortlist) commit(picked []*skills.Skill) {
+	s.previous = picked
+}
+
+// namesOf returns the names of the skills in list.
+func namesOf(list []*skills.Skill) []string {
+	names := make([]string, 0, len(list))
+	for _, skill := range list {
+		names = append(names, skill.Name)
+	}
+	return names
+}
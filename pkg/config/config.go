@@ -2,21 +2,95 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 // Config holds all runtime configuration for the agent.
 type Config struct {
 	SkillsDirs []string
-	MaxTurns   int
-	Verbose    bool
-	AllowedDir string
+	// SkillsLenient skips skills that fail to parse instead of failing startup.
+	SkillsLenient bool
+	// SkillsDuplicates is the policy for repeated skill names: first, error or namespace.
+	SkillsDuplicates string
+	// SkillsPromptTokens caps the skill listing in the system prompt. When all
+	// skills do not fit, only the SkillsTopN most relevant to each user turn are
+	// listed. 0 lists every skill.
+	SkillsPromptTokens int
+	// SkillsTopN is the shortlist size used when the catalog exceeds SkillsPromptTokens.
+	SkillsTopN int
+	// SkillsReloadInterval is how often skill directories are polled for changes,
+	// which are applied at the next turn. 0 disables the watcher.
+	SkillsReloadInterval time.Duration
+	// SkillsTrustedKeys is a file of ed25519 public keys whose signed skills are
+	// verified. Other skills, except those in SkillsSystemDirs, are untrusted
+	// and cannot run commands or write files. A missing file trusts no keys.
+	SkillsTrustedKeys string
+	// SkillsSystemDirs are the directories of skills bundled with the
+	// application, trusted without a signature.
+	SkillsSystemDirs []string
+	// SkillsSignatureMismatch is what happens to a skill whose signature does not
+	// match its files: refuse skips it, warn loads it as untrusted.
+	SkillsSignatureMismatch string
+	// SkillsLock is the project lockfile the loaded skills are checked against.
+	// A missing file checks nothing.
+	SkillsLock string
+	// SkillsLockMode is what happens when skills differ from SkillsLock: warn
+	// reports the differences, refuse fails startup and reloads, off skips the check.
+	SkillsLockMode string
+	// SkillsScan is the prompt-injection scan policy for skill files and
+	// resources: an action (off, warn, quarantine or block) optionally
+	// followed by rule=action overrides, e.g. "warn,pipe_to_shell=block".
+	SkillsScan string
+	// PromptTemplate is a text/template file replacing the default system
+	// prompt template. Empty uses the built-in one.
+	PromptTemplate string
+	// PromptSections maps prompt section names (identity, tools, skill_rules,
+	// safety, instructions, environment, skills) to files replacing those sections.
+	PromptSections map[string]string
+	// ProjectInstructions are the instruction file names, such as AGENTS.md,
+	// looked up from the working directory up to AllowedDir and included in
+	// the system prompt. Empty disables project instructions.
+	ProjectInstructions []string
+	// UserInstructions is a user-level instruction file included before the
+	// project files. Empty or missing is skipped.
+	UserInstructions string
+	// InstructionsMaxBytes caps the instruction files in the prompt together.
+	InstructionsMaxBytes int
+	// ToolResultEnvelope selects the tool results wrapped as data before they
+	// reach the model: untrusted (outside content such as files and command
+	// output), all, or off.
+	ToolResultEnvelope string
+	MaxTurns           int
+	Verbose            bool
+	AllowedDir         string
+	// OverlayDir enables copy-on-write mode: writes under AllowedDir land here
+	// until they are applied or discarded.
+	OverlayDir string
+	// AuditLog is a file that receives one JSON line per tool call decision,
+	// including calls denied by a skill's allowed-tools. Empty disables it.
+	AuditLog string
+	// MCPServers lists Model Context Protocol servers whose tools are mounted at startup.
+	MCPServers []MCPServerConfig
 
 	APIKey  string
 	BaseURL string
 	Model   string
 }
 
+// Defaults for the skill listing budget.
+const (
+	DefaultSkillsPromptTokens = 4000
+	DefaultSkillsTopN         = 8
+)
+
+// DefaultInstructionsMaxBytes is the default cap on instruction files.
+const DefaultInstructionsMaxBytes = 32 * 1024
+
+// DefaultSkillsReloadInterval is the default skill watcher poll interval.
+const DefaultSkillsReloadInterval = 2 * time.Second
+
 // DefaultConfig returns a baseline configuration without side effects.
 func DefaultConfig() Config {
 	wd, err := os.Getwd()
@@ -24,16 +98,57 @@ func DefaultConfig() Config {
 		wd = "."
 	}
 	return Config{
-		SkillsDirs: nil,
-		MaxTurns:   10,
-		Verbose:    false,
-		AllowedDir: wd,
+		SkillsDirs:              nil,
+		SkillsLenient:           true,
+		SkillsDuplicates:        "first",
+		SkillsPromptTokens:      DefaultSkillsPromptTokens,
+		SkillsTopN:              DefaultSkillsTopN,
+		SkillsReloadInterval:    DefaultSkillsReloadInterval,
+		SkillsSignatureMismatch: "refuse",
+		SkillsLock:              filepath.Join(wd, "skills.lock"),
+		SkillsLockMode:          "warn",
+		SkillsScan:              "warn",
+		ProjectInstructions:     []string{"AGENTS.md"},
+		InstructionsMaxBytes:    DefaultInstructionsMaxBytes,
+		ToolResultEnvelope:      "untrusted",
+		MaxTurns:                10,
+		Verbose:                 false,
+		AllowedDir:              wd,
 	}
 }
 
 // Normalize sanitizes configuration values and applies defaults.
 func Normalize(cfg Config) Config {
 	cfg.AllowedDir = strings.TrimSpace(cfg.AllowedDir)
+	cfg.OverlayDir = strings.TrimSpace(cfg.OverlayDir)
+	cfg.AuditLog = strings.TrimSpace(cfg.AuditLog)
+	cfg.SkillsDuplicates = strings.ToLower(strings.TrimSpace(cfg.SkillsDuplicates))
+	cfg.SkillsTrustedKeys = strings.TrimSpace(cfg.SkillsTrustedKeys)
+	// Unknown modes are kept, so that loading fails instead of falling back
+	// to a weaker default.
+	cfg.SkillsSignatureMismatch = strings.ToLower(strings.TrimSpace(cfg.SkillsSignatureMismatch))
+	if cfg.SkillsSignatureMismatch == "" {
+		cfg.SkillsSignatureMismatch = "refuse"
+	}
+	cfg.SkillsLock = strings.TrimSpace(cfg.SkillsLock)
+	cfg.SkillsLockMode = strings.ToLower(strings.TrimSpace(cfg.SkillsLockMode))
+	if cfg.SkillsLockMode == "" {
+		cfg.SkillsLockMode = "warn"
+	}
+	cfg.SkillsScan = strings.TrimSpace(cfg.SkillsScan)
+	cfg.ToolResultEnvelope = strings.ToLower(strings.TrimSpace(cfg.ToolResultEnvelope))
+	if cfg.ToolResultEnvelope == "" {
+		cfg.ToolResultEnvelope = "untrusted"
+	}
+	cfg.PromptTemplate = strings.TrimSpace(cfg.PromptTemplate)
+	cfg.UserInstructions = strings.TrimSpace(cfg.UserInstructions)
+	names := make([]string, 0, len(cfg.ProjectInstructions))
+	for _, name := range cfg.ProjectInstructions {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	cfg.ProjectInstructions = names
 	cfg.APIKey = strings.TrimSpace(cfg.APIKey)
 	cfg.BaseURL = strings.TrimSpace(cfg.BaseURL)
 	cfg.Model = strings.TrimSpace(cfg.Model)
@@ -48,8 +163,48 @@ func Normalize(cfg Config) Config {
 	}
 	cfg.SkillsDirs = normalizedSkills
 
+	normalizedServers := make([]MCPServerConfig, 0, len(cfg.MCPServers))
+	for _, server := range cfg.MCPServers {
+		server = normalizeMCPServer(server)
+		if server.Name == "" {
+			continue
+		}
+		normalizedServers = append(normalizedServers, server)
+	}
+	cfg.MCPServers = normalizedServers
+
 	if cfg.MaxTurns <= 0 {
 		cfg.MaxTurns = 1
 	}
+	if cfg.SkillsPromptTokens < 0 {
+		cfg.SkillsPromptTokens = 0
+	}
+	if cfg.SkillsReloadInterval < 0 {
+		cfg.SkillsReloadInterval = 0
+	}
+	if cfg.InstructionsMaxBytes <= 0 {
+		cfg.InstructionsMaxBytes = DefaultInstructionsMaxBytes
+	}
+	if cfg.SkillsTopN <= 0 {
+		cfg.SkillsTopN = DefaultSkillsTopN
+	}
 	return cfg
 }
+
+// ToolAllowedDirs returns the directories file tools may access: AllowedDir plus
+// every skills directory. An empty AllowedDir disables the restriction entirely.
+func ToolAllowedDirs(cfg Config) []string {
+	allowedDirs := []string{}
+	if cfg.AllowedDir == "" {
+		return allowedDirs
+	}
+	allowedDirs = append(allowedDirs, cfg.AllowedDir)
+	for _, dir := range cfg.SkillsDirs {
+		if abs, err := filepath.Abs(dir); err == nil {
+			allowedDirs = append(allowedDirs, abs)
+		} else {
+			allowedDirs = append(allowedDirs, dir)
+		}
+	}
+	return allowedDirs
+}
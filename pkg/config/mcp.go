@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// MCP transports supported by the client.
+const (
+	MCPTransportStdio          = "stdio"
+	MCPTransportStreamableHTTP = "streamable_http"
+)
+
+// MCPServerConfig describes one MCP server to connect to.
+type MCPServerConfig struct {
+	// Name namespaces the server's tools, e.g. mcp__<name>__<tool>.
+	Name string `json:"name"`
+	// Transport is "stdio" or "streamable_http"; inferred from URL when empty.
+	Transport string `json:"transport,omitempty"`
+
+	// Command, Args, Env and WorkingDir launch a stdio server. The process only
+	// inherits low-risk environment variables plus Env.
+	Command    string            `json:"command,omitempty"`
+	Args       []string          `json:"args,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	WorkingDir string            `json:"cwd,omitempty"`
+
+	// URL and Headers reach a streamable HTTP server.
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// LoadMCPServers reads server definitions from a JSON file shaped like
+// {"mcpServers": {"name": {"command": "...", "args": [...]}}}.
+func LoadMCPServers(path string) ([]MCPServerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		MCPServers map[string]MCPServerConfig `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	names := make([]string, 0, len(file.MCPServers))
+	for name := range file.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	servers := make([]MCPServerConfig, 0, len(names))
+	for _, name := range names {
+		server := file.MCPServers[name]
+		server.Name = name
+		server = normalizeMCPServer(server)
+		switch server.Transport {
+		case MCPTransportStdio:
+			if server.Command == "" {
+				return nil, fmt.Errorf("mcp server %s: command is required for stdio transport", name)
+			}
+		case MCPTransportStreamableHTTP:
+			if server.URL == "" {
+				return nil, fmt.Errorf("mcp server %s: url is required for streamable_http transport", name)
+			}
+		default:
+			return nil, fmt.Errorf("mcp server %s: unsupported transport %q", name, server.Transport)
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+// normalizeMCPServer trims fields and infers the transport.
+func normalizeMCPServer(server MCPServerConfig) MCPServerConfig {
+	server.Name = strings.TrimSpace(server.Name)
+	server.Transport = strings.ToLower(strings.TrimSpace(server.Transport))
+	server.Command = strings.TrimSpace(server.Command)
+	server.URL = strings.TrimSpace(server.URL)
+	server.WorkingDir = strings.TrimSpace(server.WorkingDir)
+	if server.Transport == "" {
+		if server.URL != "" {
+			server.Transport = MCPTransportStreamableHTTP
+		} else {
+			server.Transport = MCPTransportStdio
+		}
+	}
+	return server
+}
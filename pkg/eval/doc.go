@@ -0,0 +1,5 @@
+// Package eval runs suites of prompts against an agent and scores which skills
+// it activated and whether the outcome matched: files created, commands run
+// and the final answer. Suites run against the configured model or replay a
+// scripted conversation per case.
+package eval
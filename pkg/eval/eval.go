@@ -0,0 +1,285 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minhyannv/agent-skills-go/pkg/agent"
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+)
+
+// Options configures Run.
+type Options struct {
+	// Config is the base agent configuration. AllowedDir is replaced by a
+	// fresh workspace per case; overlay mode, the audit log, the skills lock
+	// check and the skill watcher are turned off.
+	Config configpkg.Config
+	// Scripted replays each case's script instead of calling the model in
+	// Config. Cases without a script fail.
+	Scripted bool
+	Logger   loggerpkg.Logger
+	// AgentOptions are passed to agent.New for every case.
+	AgentOptions []agent.AgentOption
+}
+
+// Run runs every case of the suite in order and scores the results. An error
+// is returned only when the suite cannot run at all, including a hand-built
+// suite that ParseSuite would reject; failing cases are reported in the Report.
+func Run(ctx context.Context, suite *Suite, opts Options) (*Report, error) {
+	if suite == nil {
+		return nil, errors.New("suite has no cases")
+	}
+	if err := suite.validate(); err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	model := opts.Config.Model
+	if opts.Scripted {
+		model = "scripted"
+	}
+	report := &Report{Suite: suite.Name, Model: model}
+	for _, c := range suite.Cases {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report.Cases = append(report.Cases, runCase(ctx, suite, c, opts))
+	}
+	report.score()
+	return report, nil
+}
+
+// runCase runs one case in its own workspace and checks its expectations.
+func runCase(ctx context.Context, suite *Suite, c Case, opts Options) CaseResult {
+	start := time.Now()
+	result := CaseResult{Name: c.Name, Expected: c.Expect.Skills, Activated: []string{}}
+	defer func() { result.DurationMs = time.Since(start).Milliseconds() }()
+
+	workspace, err := os.MkdirTemp("", "agent-skills-eval-")
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer func() { _ = os.RemoveAll(workspace) }()
+	fixture := c.Workspace
+	if fixture == "" {
+		fixture = suite.Workspace
+	}
+	if fixture != "" {
+		if err := copyTree(fixture, workspace); err != nil {
+			result.Error = fmt.Sprintf("copy workspace: %v", err)
+			return result
+		}
+	}
+
+	cfg := opts.Config
+	cfg.AllowedDir = workspace
+	cfg.OverlayDir = ""
+	cfg.AuditLog = ""
+	cfg.SkillsLock = ""
+	cfg.ProjectInstructions, cfg.UserInstructions = nil, ""
+	cfg.SkillsReloadInterval = 0
+	if suite.SkillsDirs != nil {
+		cfg.SkillsDirs = suite.SkillsDirs
+	}
+	if suite.MaxTurns > 0 {
+		cfg.MaxTurns = suite.MaxTurns
+	}
+
+	var calls []tools.AuditEvent
+	agentOpts := append(slices.Clone(opts.AgentOptions), agent.WithAuditHook(func(e tools.AuditEvent) {
+		calls = append(calls, e)
+	}))
+	if opts.Logger != nil {
+		agentOpts = append(agentOpts, agent.WithLogger(opts.Logger))
+	}
+	if opts.Scripted {
+		if len(c.Script) == 0 {
+			result.Error = "case has no script for the scripted model"
+			return result
+		}
+		agentOpts = append(agentOpts, agent.WithChatCompleter(NewScriptedModel(expandTurns(c.Script, workspace))))
+	}
+
+	app, err := agent.New(ctx, cfg, agentOpts...)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	answer, runErr := app.Run(expand(c.Prompt, workspace))
+	_ = app.Close()
+
+	result.Answer = answer.Content
+	result.Activated, result.Commands = observe(calls)
+	if runErr != nil {
+		result.Error = runErr.Error()
+	}
+	result.Failures = check(c.Expect, result, workspace)
+	result.Passed = result.Error == "" && len(result.Failures) == 0
+	return result
+}
+
+// WorkspaceVar in prompts and scripts is replaced by the case workspace, since
+// tools resolve relative paths against the process working directory.
+const WorkspaceVar = "${workspace}"
+
+// expand replaces WorkspaceVar in s.
+func expand(s, workspace string) string {
+	return strings.ReplaceAll(s, WorkspaceVar, workspace)
+}
+
+// expandTurns returns a copy of turns with WorkspaceVar replaced in contents
+// and string arguments.
+func expandTurns(turns []Turn, workspace string) []Turn {
+	var expandValue func(v any) any
+	expandValue = func(v any) any {
+		switch v := v.(type) {
+		case string:
+			return expand(v, workspace)
+		case []any:
+			out := make([]any, len(v))
+			for i, item := range v {
+				out[i] = expandValue(item)
+			}
+			return out
+		case map[string]any:
+			out := make(map[string]any, len(v))
+			for k, item := range v {
+				out[k] = expandValue(item)
+			}
+			return out
+		default:
+			return v
+		}
+	}
+	out := make([]Turn, len(turns))
+	for i, turn := range turns {
+		out[i] = Turn{Content: expand(turn.Content, workspace)}
+		for _, call := range turn.ToolCalls {
+			args, _ := expandValue(call.Arguments).(map[string]any)
+			out[i].ToolCalls = append(out[i].ToolCalls, ToolCall{Name: call.Name, Arguments: args})
+		}
+	}
+	return out
+}
+
+// observe extracts the activated skills and the commands run from the tool
+// calls the agent was allowed to make. run_shell calls are recorded as their
+// command line and other tools by name.
+func observe(calls []tools.AuditEvent) (activated, commands []string) {
+	activated = []string{}
+	for _, call := range calls {
+		if !call.Allowed {
+			continue
+		}
+		var args struct {
+			Name    string `json:"name"`
+			Command string `json:"command"`
+		}
+		_ = json.Unmarshal([]byte(call.Arguments), &args)
+		switch call.Tool {
+		case "load_skill":
+			if args.Name != "" && !slices.Contains(activated, args.Name) {
+				activated = append(activated, args.Name)
+			}
+		case "run_shell":
+			commands = append(commands, args.Command)
+		default:
+			commands = append(commands, call.Tool)
+		}
+	}
+	return activated, commands
+}
+
+// check compares a case result with its expectations and returns the failures.
+func check(expect Expectations, result CaseResult, workspace string) []string {
+	var failures []string
+	if expect.Skills != nil && !sameSet(expect.Skills, result.Activated) {
+		failures = append(failures, fmt.Sprintf("skills: expected %v, activated %v", expect.Skills, result.Activated))
+	}
+	for _, file := range expect.Files {
+		if _, err := os.Stat(filepath.Join(workspace, filepath.FromSlash(file))); err != nil {
+			failures = append(failures, fmt.Sprintf("files: %s was not created", file))
+		}
+	}
+	for _, pattern := range expect.Commands {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("commands: %v", err))
+			continue
+		}
+		if !slices.ContainsFunc(result.Commands, re.MatchString) {
+			failures = append(failures, fmt.Sprintf("commands: nothing matched %q", pattern))
+		}
+	}
+	if expect.Answer != "" {
+		re, err := regexp.Compile(expect.Answer)
+		switch {
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("answer: %v", err))
+		case !re.MatchString(result.Answer):
+			failures = append(failures, fmt.Sprintf("answer: does not match %q", expect.Answer))
+		}
+	}
+	return failures
+}
+
+// sameSet reports whether a and b hold the same names.
+func sameSet(a, b []string) bool {
+	x, y := slices.Clone(a), slices.Clone(b)
+	sort.Strings(x)
+	sort.Strings(y)
+	return slices.Equal(slices.Compact(x), slices.Compact(y))
+}
+
+// copyTree copies the regular files and directories under src into dst.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = in.Close() }()
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			_ = out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,136 @@
+// Tests for suite parsing, scripted runs and scoring.
+package eval
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+)
+
+const testSuite = `name: smoke
+skills_dirs: [skills/.system]
+workspace: fixture
+cases:
+  - name: write-notes
+    prompt: Save a note to out.txt
+    expect:
+      skills: [notes]
+      files: [out.txt]
+      commands: [write_file]
+      answer: (?i)saved
+    script:
+      - tool_calls:
+          - name: load_skill
+            arguments: {name: notes}
+      - tool_calls:
+          - name: write_file
+            arguments: {path: "${workspace}/out.txt", content: hello}
+      - content: Saved out.txt.
+  - name: no-skill
+    prompt: What is in input.txt?
+    expect:
+      skills: []
+    script:
+      - tool_calls:
+          - name: load_skill
+            arguments: {name: pdf}
+      - content: It is a PDF question.
+  - name: unscored
+    prompt: Say hi
+    expect:
+      commands: ['^echo hi$']
+    script:
+      - tool_calls:
+          - name: run_shell
+            arguments: {command: echo hi, working_dir: "${workspace}"}
+      - content: hi
+`
+
+// TestRunScripted runs a suite with the scripted model and checks the
+// assertions, selection scores and reports.
+func TestRunScripted(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"skills/.system/notes/SKILL.md": "---\nname: notes\ndescription: Write notes to files\n---\n",
+		"skills/.system/pdf/SKILL.md":   "---\nname: pdf\ndescription: Work with PDF files\n---\n",
+		"fixture/input.txt":             "input\n",
+		"suite.yaml":                    testSuite,
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	suite, err := LoadSuite(filepath.Join(root, "suite.yaml"))
+	if err != nil {
+		t.Fatalf("load suite: %v", err)
+	}
+	cfg := configpkg.DefaultConfig()
+	cfg.SkillsSystemDirs = []string{filepath.Join(root, "skills", ".system")}
+	report, err := Run(context.Background(), suite, Options{Config: cfg, Scripted: true})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if report.Total != 3 || report.Passed != 2 || report.PassRate != 0.667 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	for _, c := range report.Cases {
+		if c.Name == "no-skill" {
+			if c.Passed || len(c.Failures) != 1 || !strings.Contains(c.Failures[0], "activated [pdf]") {
+				t.Fatalf("expected no-skill to fail on selection: %+v", c)
+			}
+		} else if !c.Passed {
+			t.Fatalf("expected %s to pass: %+v", c.Name, c)
+		}
+	}
+	sel := report.Selection
+	if sel.TruePositives != 1 || sel.FalsePositives != 1 || sel.FalseNegatives != 0 || sel.Precision != 0.5 || sel.Recall != 1 {
+		t.Fatalf("unexpected selection score: %+v", sel)
+	}
+	if len(report.Skills) != 2 || report.Skills[1].Skill != "pdf" || report.Skills[1].Precision != 0 {
+		t.Fatalf("unexpected per-skill scores: %+v", report.Skills)
+	}
+	md := report.Markdown()
+	for _, want := range []string{"# Eval: smoke", "Cases passed: 2/3", "precision 0.500, recall 1.000", "| no-skill | FAIL | none | pdf |"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	// Without a script the scripted model cannot run a case.
+	suite.Cases[0].Script = nil
+	report, err = Run(context.Background(), suite, Options{Config: configpkg.DefaultConfig(), Scripted: true})
+	if err != nil || report.Cases[0].Passed || !strings.Contains(report.Cases[0].Error, "no script") {
+		t.Fatalf("expected a missing script error: %+v %v", report.Cases[0], err)
+	}
+}
+
+// TestParseSuiteErrors covers the checks made before a suite runs.
+func TestParseSuiteErrors(t *testing.T) {
+	for suite, want := range map[string]string{
+		"":                      "empty",
+		"name: x\n":             "no cases",
+		"cases:\n  - name: a\n": "prompt is required",
+		"cases:\n  - {name: a, prompt: p, expect: {skill: [x]}}\n":     "field skill not found",
+		"cases:\n  - {name: a, prompt: p, expect: {answer: '('}}\n":    "answer",
+		"cases:\n  - {name: a, prompt: p}\n  - {name: a, prompt: q}\n": "duplicate",
+	} {
+		if _, err := ParseSuite([]byte(suite)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%q: expected error containing %q, got %v", suite, want, err)
+		}
+	}
+
+	// A hand-built suite skips ParseSuite; Run must reject it, not panic.
+	suite := &Suite{Cases: []Case{{Name: "a", Prompt: "p", Expect: Expectations{Commands: []string{"("}}}}}
+	if _, err := Run(context.Background(), suite, Options{Scripted: true}); err == nil || !strings.Contains(err.Error(), "commands") {
+		t.Fatalf("expected Run to reject the bad pattern, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Report is the outcome of a suite run.
+type Report struct {
+	Suite string `json:"suite"`
+	Model string `json:"model"`
+	// Total and Passed count cases; a case passes when it ran without error
+	// and met every expectation.
+	Total    int     `json:"total"`
+	Passed   int     `json:"passed"`
+	PassRate float64 `json:"pass_rate"`
+	// Selection scores skill activation over the cases with expected skills.
+	Selection Score `json:"selection"`
+	// Skills breaks Selection down per skill, sorted by name.
+	Skills []SkillScore `json:"skills"`
+	Cases  []CaseResult `json:"cases"`
+}
+
+// Score counts skill activations against expectations. Precision is the share
+// of activations that were expected and recall the share of expected skills
+// that were activated; both are 1 when there is nothing to count.
+type Score struct {
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+}
+
+// SkillScore is the selection score of one skill.
+type SkillScore struct {
+	Skill string `json:"skill"`
+	Score
+}
+
+// CaseResult is the outcome of one case.
+type CaseResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	// Expected is nil when the case does not score skill selection.
+	Expected  []string `json:"expected_skills"`
+	Activated []string `json:"activated_skills"`
+	// Commands are the run_shell command lines and the names of other tools
+	// called, in order.
+	Commands   []string `json:"commands,omitempty"`
+	Answer     string   `json:"answer"`
+	Failures   []string `json:"failures,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+}
+
+// score fills the totals and selection scores from the cases.
+func (r *Report) score() {
+	r.Total, r.Passed = len(r.Cases), 0
+	perSkill := map[string]*Score{}
+	get := func(name string) *Score {
+		if perSkill[name] == nil {
+			perSkill[name] = &Score{}
+		}
+		return perSkill[name]
+	}
+	r.Selection = Score{}
+	for _, c := range r.Cases {
+		if c.Passed {
+			r.Passed++
+		}
+		if c.Expected == nil {
+			continue
+		}
+		for _, name := range c.Activated {
+			if slices.Contains(c.Expected, name) {
+				r.Selection.TruePositives++
+				get(name).TruePositives++
+			} else {
+				r.Selection.FalsePositives++
+				get(name).FalsePositives++
+			}
+		}
+		for _, name := range c.Expected {
+			if !slices.Contains(c.Activated, name) {
+				r.Selection.FalseNegatives++
+				get(name).FalseNegatives++
+			}
+		}
+	}
+	r.PassRate = ratio(r.Passed, r.Total)
+	r.Selection.finish()
+	r.Skills = make([]SkillScore, 0, len(perSkill))
+	for name, score := range perSkill {
+		score.finish()
+		r.Skills = append(r.Skills, SkillScore{Skill: name, Score: *score})
+	}
+	sort.Slice(r.Skills, func(i, j int) bool { return r.Skills[i].Skill < r.Skills[j].Skill })
+}
+
+// finish computes precision and recall from the counts.
+func (s *Score) finish() {
+	s.Precision = ratio(s.TruePositives, s.TruePositives+s.FalsePositives)
+	s.Recall = ratio(s.TruePositives, s.TruePositives+s.FalseNegatives)
+}
+
+// ratio returns n/d rounded to three decimals, or 1 when d is 0.
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 1
+	}
+	return math.Round(float64(n)/float64(d)*1000) / 1000
+}
+
+// Markdown renders the report as a summary, a per-skill table and a case table.
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Eval: %s\n\n", r.Suite)
+	fmt.Fprintf(&sb, "- Model: %s\n", r.Model)
+	fmt.Fprintf(&sb, "- Cases passed: %d/%d (%.1f%%)\n", r.Passed, r.Total, r.PassRate*100)
+	fmt.Fprintf(&sb, "- Skill selection: precision %.3f, recall %.3f (TP %d, FP %d, FN %d)\n",
+		r.Selection.Precision, r.Selection.Recall, r.Selection.TruePositives, r.Selection.FalsePositives, r.Selection.FalseNegatives)
+
+	if len(r.Skills) > 0 {
+		sb.WriteString("\n## Skills\n\n| Skill | Precision | Recall | TP | FP | FN |\n|---|---|---|---|---|---|\n")
+		for _, s := range r.Skills {
+			fmt.Fprintf(&sb, "| %s | %.3f | %.3f | %d | %d | %d |\n", markdownCell(s.Skill), s.Precision, s.Recall, s.TruePositives, s.FalsePositives, s.FalseNegatives)
+		}
+	}
+
+	sb.WriteString("\n## Cases\n\n| Case | Result | Expected | Activated | Notes |\n|---|---|---|---|---|\n")
+	for _, c := range r.Cases {
+		result := "pass"
+		if !c.Passed {
+			result = "FAIL"
+		}
+		expected := "-"
+		if c.Expected != nil {
+			expected = joinOrNone(c.Expected)
+		}
+		notes := c.Failures
+		if c.Error != "" {
+			notes = append([]string{"error: " + c.Error}, notes...)
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n", markdownCell(c.Name), result, markdownCell(expected),
+			markdownCell(joinOrNone(c.Activated)), markdownCell(strings.Join(notes, "; ")))
+	}
+	return sb.String()
+}
+
+// joinOrNone joins names, or returns "none".
+func joinOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// markdownCell keeps a value on one table row.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,67 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// ScriptedModel is an agent.ChatCompleter that answers each request with the
+// next scripted turn, ignoring the conversation. It lets suites check the
+// harness, tools and assertions without a model.
+type ScriptedModel struct {
+	mu    sync.Mutex
+	turns []Turn
+	next  int
+	calls int
+}
+
+// NewScriptedModel returns a model that replays turns in order.
+func NewScriptedModel(turns []Turn) *ScriptedModel {
+	return &ScriptedModel{turns: turns}
+}
+
+// New returns the next turn as a completion; it fails once the script is used up.
+func (m *ScriptedModel) New(ctx context.Context, body openai.ChatCompletionNewParams, opts ...option.RequestOption) (*openai.ChatCompletion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.next >= len(m.turns) {
+		return nil, fmt.Errorf("scripted model: no turn left after %d", len(m.turns))
+	}
+	turn := m.turns[m.next]
+	m.next++
+
+	message := openai.ChatCompletionMessage{Role: "assistant", Content: turn.Content}
+	for _, call := range turn.ToolCalls {
+		args := call.Arguments
+		if args == nil {
+			args = map[string]any{}
+		}
+		data, err := json.Marshal(args)
+		if err != nil {
+			return nil, fmt.Errorf("scripted model: arguments of %s: %w", call.Name, err)
+		}
+		m.calls++
+		message.ToolCalls = append(message.ToolCalls, openai.ChatCompletionMessageToolCall{
+			ID:       fmt.Sprintf("call_%d", m.calls),
+			Type:     "function",
+			Function: openai.ChatCompletionMessageToolCallFunction{Name: call.Name, Arguments: string(data)},
+		})
+	}
+	finish := "stop"
+	if len(message.ToolCalls) > 0 {
+		finish = "tool_calls"
+	}
+	return &openai.ChatCompletion{
+		Model:   body.Model,
+		Object:  "chat.completion",
+		Choices: []openai.ChatCompletionChoice{{Message: message, FinishReason: finish}},
+	}, nil
+}
@@ -0,0 +1,177 @@
+package eval
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a set of evaluation cases, usually read from YAML:
+//
+//	name: skill-selection
+//	skills_dirs: [../skills]
+//	workspace: fixtures/empty
+//	cases:
+//	  - name: rotate-pdf
+//	    prompt: Rotate every page of report.pdf by 90 degrees
+//	    expect:
+//	      skills: [pdf]
+//	      files: [report-rotated.pdf]
+//	      commands: ['rotate_pdf\.py']
+//	      answer: '(?i)rotated'
+//	    script:
+//	      - tool_calls:
+//	          - name: load_skill
+//	            arguments: {name: pdf}
+//	      - tool_calls:
+//	          - name: run_shell
+//	            arguments:
+//	              command: python3 scripts/rotate_pdf.py report.pdf
+//	              working_dir: ${workspace}
+//	      - content: Rotated report.pdf.
+//
+// Relative paths are resolved against the suite file. ${workspace} in prompts
+// and scripts is replaced by the case workspace.
+type Suite struct {
+	Name string `yaml:"name"`
+	// SkillsDirs replaces the configured skill directories when set.
+	SkillsDirs []string `yaml:"skills_dirs"`
+	// Workspace is copied into a fresh directory for every case, which becomes
+	// the agent's allowed directory. Empty starts each case in an empty one.
+	Workspace string `yaml:"workspace"`
+	// MaxTurns replaces the configured tool-call turn limit when positive.
+	MaxTurns int    `yaml:"max_turns"`
+	Cases    []Case `yaml:"cases"`
+}
+
+// Case is one prompt and what should happen when the agent handles it.
+type Case struct {
+	Name   string `yaml:"name"`
+	Prompt string `yaml:"prompt"`
+	// Workspace replaces the suite workspace for this case.
+	Workspace string       `yaml:"workspace"`
+	Expect    Expectations `yaml:"expect"`
+	// Script is replayed by the scripted model instead of calling the
+	// configured model.
+	Script []Turn `yaml:"script"`
+}
+
+// Expectations are checked after a case runs. Empty fields are not checked.
+type Expectations struct {
+	// Skills are the skills the agent should load with load_skill, in any
+	// order. Nil leaves selection unscored; an empty list expects no skill.
+	Skills []string `yaml:"skills"`
+	// Files must exist in the workspace afterwards, relative to it.
+	Files []string `yaml:"files"`
+	// Commands are regular expressions that must each match a run_shell
+	// command or the name of a called tool.
+	Commands []string `yaml:"commands"`
+	// Answer is a regular expression the final answer must match.
+	Answer string `yaml:"answer"`
+}
+
+// Turn is one scripted assistant message: tool calls, or the final answer.
+type Turn struct {
+	Content   string     `yaml:"content"`
+	ToolCalls []ToolCall `yaml:"tool_calls"`
+}
+
+// ToolCall is a scripted tool call.
+type ToolCall struct {
+	Name      string         `yaml:"name"`
+	Arguments map[string]any `yaml:"arguments"`
+}
+
+// LoadSuite reads a suite file and resolves its relative paths.
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	suite, err := ParseSuite(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	for i, skillsDir := range suite.SkillsDirs {
+		suite.SkillsDirs[i] = resolve(skillsDir)
+	}
+	suite.Workspace = resolve(suite.Workspace)
+	for i := range suite.Cases {
+		suite.Cases[i].Workspace = resolve(suite.Cases[i].Workspace)
+	}
+	if suite.Name == "" {
+		suite.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return suite, nil
+}
+
+// ParseSuite decodes and checks a suite. Unknown keys are errors so typos in
+// expectations do not pass silently.
+func ParseSuite(data []byte) (*Suite, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var suite Suite
+	if err := dec.Decode(&suite); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("suite is empty")
+		}
+		return nil, err
+	}
+	if err := suite.validate(); err != nil {
+		return nil, err
+	}
+	return &suite, nil
+}
+
+// validate checks case names, prompts, regular expressions and scripts.
+func (s *Suite) validate() error {
+	if len(s.Cases) == 0 {
+		return errors.New("suite has no cases")
+	}
+	seen := map[string]bool{}
+	for i, c := range s.Cases {
+		if strings.TrimSpace(c.Name) == "" {
+			return fmt.Errorf("cases[%d]: name is required", i)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("case %s: duplicate name", c.Name)
+		}
+		seen[c.Name] = true
+		if strings.TrimSpace(c.Prompt) == "" {
+			return fmt.Errorf("case %s: prompt is required", c.Name)
+		}
+		for _, pattern := range c.Expect.Commands {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("case %s: commands: %w", c.Name, err)
+			}
+		}
+		if _, err := regexp.Compile(c.Expect.Answer); err != nil {
+			return fmt.Errorf("case %s: answer: %w", c.Name, err)
+		}
+		for j, turn := range c.Script {
+			if turn.Content == "" && len(turn.ToolCalls) == 0 {
+				return fmt.Errorf("case %s: script[%d] needs content or tool_calls", c.Name, j)
+			}
+			for _, call := range turn.ToolCalls {
+				if call.Name == "" {
+					return fmt.Errorf("case %s: script[%d]: tool call name is required", c.Name, j)
+				}
+			}
+		}
+	}
+	return nil
+}
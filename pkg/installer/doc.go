@@ -0,0 +1,4 @@
+// Package installer installs, updates and removes skills in a skills home
+// directory from local git repositories, archives or directories, and records
+// their provenance in a lockfile.
+package installer
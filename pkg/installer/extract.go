@@ -0,0 +1,211 @@
+// Safe extraction and copying of skill trees.
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Limits applied while extracting or copying a source tree.
+const (
+	maxTreeBytes = 256 * 1024 * 1024
+	maxTreeFiles = 10000
+)
+
+// ErrUnsafePath is returned for entries that would land outside the destination.
+var ErrUnsafePath = errors.New("unsafe path")
+
+// ErrLink is returned for symlinks and hard links, which are never installed.
+var ErrLink = errors.New("links are not allowed")
+
+// treeWriter writes entries below root while enforcing the tree limits.
+type treeWriter struct {
+	root  string
+	bytes int64
+	files int
+}
+
+// target resolves an archive entry name to a path below root.
+func (w *treeWriter) target(name string) (string, error) {
+	if strings.Contains(name, "\\") || path.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	clean := path.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	return filepath.Join(w.root, filepath.FromSlash(clean)), nil
+}
+
+func (w *treeWriter) mkdir(name string) error {
+	dir, err := w.target(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(dir, 0o755)
+}
+
+// writeFile copies r into name. Only the executable bit of mode is kept.
+func (w *treeWriter) writeFile(name string, mode fs.FileMode, r io.Reader) error {
+	dst, err := w.target(name)
+	if err != nil {
+		return err
+	}
+	w.files++
+	if w.files > maxTreeFiles {
+		return fmt.Errorf("source has more than %d files", maxTreeFiles)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	perm := fs.FileMode(0o644)
+	if mode&0o111 != 0 {
+		perm = 0o755
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	remaining := maxTreeBytes - w.bytes
+	n, err := io.Copy(f, io.LimitReader(r, remaining+1))
+	closeErr := f.Close()
+	w.bytes += n
+	if err != nil {
+		return err
+	}
+	if w.bytes > maxTreeBytes {
+		return fmt.Errorf("source exceeds %d bytes", maxTreeBytes)
+	}
+	return closeErr
+}
+
+// extractArchive unpacks a .zip, .tar.gz or .tgz file into dst.
+func extractArchive(archive, dst string) error {
+	if strings.HasSuffix(strings.ToLower(archive), ".zip") {
+		return extractZip(archive, dst)
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", archive, err)
+	}
+	defer func() { _ = gz.Close() }()
+	return extractTar(gz, dst)
+}
+
+// extractTar unpacks a tar stream into dst, rejecting links and special files.
+func extractTar(r io.Reader, dst string) error {
+	w := &treeWriter{root: dst}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = w.mkdir(hdr.Name)
+		case tar.TypeReg:
+			err = w.writeFile(hdr.Name, hdr.FileInfo().Mode(), tr)
+		case tar.TypeXGlobalHeader:
+			// git archive stores the commit id here.
+		case tar.TypeSymlink, tar.TypeLink:
+			err = fmt.Errorf("%w: %s", ErrLink, hdr.Name)
+		default:
+			err = fmt.Errorf("unsupported tar entry type %q: %s", hdr.Typeflag, hdr.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extractZip unpacks a zip file into dst, rejecting links and special files.
+func extractZip(archive, dst string) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", archive, err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	w := &treeWriter{root: dst}
+	for _, f := range zr.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			err = w.mkdir(f.Name)
+		case mode&fs.ModeSymlink != 0:
+			err = fmt.Errorf("%w: %s", ErrLink, f.Name)
+		case mode.IsRegular():
+			err = extractZipFile(w, f)
+		default:
+			err = fmt.Errorf("unsupported zip entry: %s", f.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(w *treeWriter, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+	return w.writeFile(f.Name, f.Mode(), rc)
+}
+
+// copyTree copies a directory into dst, skipping .git and rejecting links.
+func copyTree(src, dst string) error {
+	w := &treeWriter{root: dst}
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		switch {
+		case d.IsDir():
+			if d.Name() == ".git" && p != src {
+				return fs.SkipDir
+			}
+			return w.mkdir(name)
+		case d.Type()&fs.ModeSymlink != 0:
+			return fmt.Errorf("%w: %s", ErrLink, p)
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = f.Close() }()
+			return w.writeFile(name, info.Mode(), f)
+		default:
+			return fmt.Errorf("unsupported file type: %s", p)
+		}
+	})
+}
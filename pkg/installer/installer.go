@@ -0,0 +1,313 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+)
+
+// ErrAlreadyInstalled is returned by Install when the skill exists and Force is unset.
+var ErrAlreadyInstalled = errors.New("skill already installed")
+
+// ErrNotInstalled is returned for skills that are not in the skills home.
+var ErrNotInstalled = errors.New("skill not installed")
+
+// DefaultHome returns $CODEX_HOME/skills, or ~/.codex/skills when CODEX_HOME is unset.
+func DefaultHome() string {
+	if codexHome := strings.TrimSpace(os.Getenv("CODEX_HOME")); codexHome != "" {
+		return filepath.Join(codexHome, "skills")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".codex", "skills")
+	}
+	return filepath.Join(home, ".codex", "skills")
+}
+
+// DefaultTrustedKeys returns the trusted skill signing keys file next to the
+// skills home: $CODEX_HOME/trusted_skill_keys or ~/.codex/trusted_skill_keys.
+func DefaultTrustedKeys() string {
+	return filepath.Join(filepath.Dir(DefaultHome()), "trusted_skill_keys")
+}
+
+// DefaultUserInstructions returns the user-level instruction file next to
+// the skills home: $CODEX_HOME/AGENTS.md or ~/.codex/AGENTS.md.
+func DefaultUserInstructions() string {
+	return filepath.Join(filepath.Dir(DefaultHome()), "AGENTS.md")
+}
+
+// ValidationError reports a skill that failed skills.Validate.
+type ValidationError struct {
+	Skill       string
+	Diagnostics []skills.Diagnostic
+}
+
+func (e *ValidationError) Error() string {
+	var msgs []string
+	for _, d := range e.Diagnostics {
+		if d.Severity == skills.SeverityError {
+			msgs = append(msgs, d.String())
+		}
+	}
+	return fmt.Sprintf("skill %s is invalid: %s", e.Skill, strings.Join(msgs, "; "))
+}
+
+// Installer manages the skills in one skills home directory.
+type Installer struct {
+	home string
+	now  func() time.Time
+}
+
+// New returns an installer for home; an empty home uses DefaultHome.
+func New(home string) *Installer {
+	if strings.TrimSpace(home) == "" {
+		home = DefaultHome()
+	}
+	return &Installer{home: home, now: time.Now}
+}
+
+// Home returns the skills home directory.
+func (i *Installer) Home() string {
+	return i.home
+}
+
+// InstallOptions tunes Install.
+type InstallOptions struct {
+	// Force replaces an installed skill with the same name.
+	Force bool
+	// ExpectName fails the install when the source provides a different skill.
+	ExpectName string
+}
+
+// stagingDir creates a temporary directory next to dir: on the same file
+// system, so its content can be renamed into dir, but outside dir, so skill
+// loaders and watchers walking dir never see a half-extracted skill.
+func stagingDir(dir, purpose string) (string, error) {
+	return os.MkdirTemp(filepath.Dir(filepath.Clean(dir)), "."+filepath.Base(filepath.Clean(dir))+"-"+purpose+"-*")
+}
+
+// Install fetches src into a staging directory next to the skills home,
+// validates the skill, moves it to <home>/<name> and records it in the lockfile.
+func (i *Installer) Install(ctx context.Context, src Source, opts InstallOptions) (Entry, error) {
+	if err := os.MkdirAll(i.home, 0o755); err != nil {
+		return Entry{}, err
+	}
+	staging, err := stagingDir(i.home, "install")
+	if err != nil {
+		return Entry{}, err
+	}
+	defer func() { _ = os.RemoveAll(staging) }()
+
+	fetched := filepath.Join(staging, "src")
+	if err := os.Mkdir(fetched, 0o755); err != nil {
+		return Entry{}, err
+	}
+	commit, err := fetch(ctx, src, fetched)
+	if err != nil {
+		return Entry{}, fmt.Errorf("fetch %s: %w", src, err)
+	}
+
+	root, err := skillRoot(filepath.Join(fetched, filepath.FromSlash(src.Subpath)))
+	if err != nil {
+		return Entry{}, fmt.Errorf("%s: %w", src, err)
+	}
+	skill, err := skills.ParseFile(filepath.Join(root, "SKILL.md"))
+	if err != nil {
+		return Entry{}, fmt.Errorf("%s: %w", src, err)
+	}
+	name := skill.Name
+	if opts.ExpectName != "" && name != opts.ExpectName {
+		return Entry{}, fmt.Errorf("%s now provides skill %q, not %q", src, name, opts.ExpectName)
+	}
+	if err := checkName(name); err != nil {
+		return Entry{}, err
+	}
+
+	// Validate under the final directory name so the name check applies.
+	named := filepath.Join(staging, "skill", name)
+	if err := os.MkdirAll(filepath.Dir(named), 0o755); err != nil {
+		return Entry{}, err
+	}
+	if err := os.Rename(root, named); err != nil {
+		return Entry{}, err
+	}
+	if diags := skills.Validate(named); skills.HasErrors(diags) {
+		return Entry{}, &ValidationError{Skill: name, Diagnostics: diags}
+	}
+	digest, err := TreeDigest(named)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	lock, err := readLock(i.home)
+	if err != nil {
+		return Entry{}, err
+	}
+	dest := filepath.Join(i.home, name)
+	if err := replaceDir(named, dest, filepath.Join(staging, "previous"), opts.Force); err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{Name: name, Source: src, Commit: commit, Digest: digest, InstalledAt: i.now().UTC()}
+	lock.Skills[name] = entry
+	if err := writeLock(i.home, lock); err != nil {
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+// Update reinstalls a locked skill from its recorded source. changed reports
+// whether the installed tree differs from before.
+func (i *Installer) Update(ctx context.Context, name string) (entry Entry, changed bool, err error) {
+	lock, err := readLock(i.home)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	prev, ok := lock.Skills[name]
+	if !ok {
+		return Entry{}, false, fmt.Errorf("%w: %s is not in %s", ErrNotInstalled, name, LockFileName)
+	}
+	entry, err = i.Install(ctx, prev.Source, InstallOptions{Force: true, ExpectName: name})
+	if err != nil {
+		return Entry{}, false, err
+	}
+	return entry, entry.Digest != prev.Digest, nil
+}
+
+// Remove deletes an installed skill and its lockfile entry.
+func (i *Installer) Remove(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	lock, err := readLock(i.home)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(i.home, name)
+	_, locked := lock.Skills[name]
+	if _, err := os.Stat(dir); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if !locked {
+			return fmt.Errorf("%w: %s", ErrNotInstalled, name)
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	delete(lock.Skills, name)
+	return writeLock(i.home, lock)
+}
+
+// Installed describes a skill directory in the skills home.
+type Installed struct {
+	Name string `json:"name"`
+	Dir  string `json:"dir"`
+	// Entry is nil for skills that were not installed by the installer.
+	Entry *Entry `json:"entry,omitempty"`
+	// Modified reports local changes since install; false when Entry is nil.
+	Modified bool `json:"modified"`
+	// Missing reports a lockfile entry whose directory is gone.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// List returns every skill directory in the home plus lockfile entries whose
+// directory was deleted, sorted by name.
+func (i *Installer) List() ([]Installed, error) {
+	lock, err := readLock(i.home)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(i.home)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var out []Installed
+	seen := map[string]bool{}
+	for _, dirEntry := range entries {
+		name := dirEntry.Name()
+		if !dirEntry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		dir := filepath.Join(i.home, name)
+		if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err != nil {
+			continue
+		}
+		item := Installed{Name: name, Dir: dir}
+		if entry, ok := lock.Skills[name]; ok {
+			item.Entry = &entry
+			digest, err := TreeDigest(dir)
+			item.Modified = err != nil || digest != entry.Digest
+		}
+		seen[name] = true
+		out = append(out, item)
+	}
+	for name, entry := range lock.Skills {
+		if !seen[name] {
+			entry := entry
+			out = append(out, Installed{Name: name, Dir: filepath.Join(i.home, name), Entry: &entry, Missing: true})
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Name < out[b].Name })
+	return out, nil
+}
+
+// skillRoot returns dir when it holds SKILL.md. Archives often wrap the content
+// in a single top-level directory, so that directory is also accepted.
+func skillRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("no SKILL.md found: %w", err)
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		inner := filepath.Join(dir, entries[0].Name())
+		if _, err := os.Stat(filepath.Join(inner, "SKILL.md")); err == nil {
+			return inner, nil
+		}
+	}
+	return "", errors.New("no SKILL.md found; use a subpath that points at the skill directory")
+}
+
+// checkName rejects names that are not a single path element.
+func checkName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid skill name %q", name)
+	}
+	return nil
+}
+
+// replaceDir moves src to dest. An existing dest is kept unless force is set,
+// in which case it is moved to backup first and restored if the move fails.
+func replaceDir(src, dest, backup string, force bool) error {
+	if _, err := os.Lstat(dest); err == nil {
+		if !force {
+			return fmt.Errorf("%w: %s", ErrAlreadyInstalled, dest)
+		}
+		if err := os.Rename(dest, backup); err != nil {
+			return err
+		}
+		if err := os.Rename(src, dest); err != nil {
+			_ = os.Rename(backup, dest)
+			return err
+		}
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.Rename(src, dest)
+}
@@ -0,0 +1,367 @@
+// Tests for skill installation, archives, the lockfile and project locks.
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+)
+
+const helloSkill = "---\nname: hello\ndescription: Say hello\n---\n# Hello\n"
+
+// TestInstallFromDirAndArchives covers directory, tar.gz and zip sources plus list and remove.
+func TestInstallFromDirAndArchives(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "skills")
+	inst := New(home)
+	ctx := context.Background()
+
+	srcDir := filepath.Join(t.TempDir(), "hello")
+	writeFile(t, filepath.Join(srcDir, "SKILL.md"), helloSkill)
+	writeFile(t, filepath.Join(srcDir, "scripts", "run.sh"), "#!/bin/sh\n")
+	if err := os.Chmod(filepath.Join(srcDir, "scripts", "run.sh"), 0o755); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+
+	src, err := ParseSource(srcDir, "", "")
+	if err != nil || src.Kind != SourceDir {
+		t.Fatalf("ParseSource dir: %+v %v", src, err)
+	}
+	entry, err := inst.Install(ctx, src, InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install dir: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(home, "hello", "scripts", "run.sh"))
+	if err != nil || info.Mode().Perm() != 0o755 {
+		t.Fatalf("expected executable script, got %v %v", info, err)
+	}
+	if _, err := inst.Install(ctx, src, InstallOptions{}); !errors.Is(err, ErrAlreadyInstalled) {
+		t.Fatalf("expected ErrAlreadyInstalled, got %v", err)
+	}
+
+	// A tar.gz with a wrapping directory installs the same tree.
+	tgz := filepath.Join(t.TempDir(), "hello.tar.gz")
+	writeTarGz(t, tgz, map[string]string{"hello-main/SKILL.md": helloSkill, "hello-main/scripts/run.sh": "#!/bin/sh\n"})
+	src, err = ParseSource(tgz, "", "")
+	if err != nil || src.Kind != SourceArchive {
+		t.Fatalf("ParseSource archive: %+v %v", src, err)
+	}
+	if _, err := inst.Install(ctx, src, InstallOptions{Force: true}); err != nil {
+		t.Fatalf("Install tar.gz: %v", err)
+	}
+
+	list, err := inst.List()
+	if err != nil || len(list) != 1 || list[0].Entry == nil || list[0].Entry.Source.Kind != SourceArchive || list[0].Modified {
+		t.Fatalf("unexpected list: %+v %v", list, err)
+	}
+	if list[0].Entry.Digest != entry.Digest {
+		t.Fatal("expected identical trees to have the same digest")
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "hello.zip")
+	writeZip(t, zipPath, map[string]string{"skills/hello/SKILL.md": helloSkill})
+	src, err = ParseSource(zipPath, "", "skills/hello")
+	if err != nil {
+		t.Fatalf("ParseSource zip: %v", err)
+	}
+	if _, err := inst.Install(ctx, src, InstallOptions{Force: true}); err != nil {
+		t.Fatalf("Install zip: %v", err)
+	}
+
+	writeFile(t, filepath.Join(home, "hello", "SKILL.md"), helloSkill+"local edit\n")
+	if list, _ := inst.List(); len(list) != 1 || !list[0].Modified {
+		t.Fatalf("expected modified skill, got %+v", list)
+	}
+
+	if err := inst.Remove("hello"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if list, _ := inst.List(); len(list) != 0 {
+		t.Fatalf("expected empty list, got %+v", list)
+	}
+	if err := inst.Remove("hello"); !errors.Is(err, ErrNotInstalled) {
+		t.Fatalf("expected ErrNotInstalled, got %v", err)
+	}
+}
+
+// TestInstallFromGitAndUpdate installs a subpath at a ref and updates it after a new commit.
+func TestInstallFromGitAndUpdate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	writeFile(t, filepath.Join(repo, "skills", "hello", "SKILL.md"), helloSkill)
+	git("add", "-A")
+	git("commit", "-qm", "init")
+	git("tag", "v1")
+
+	inst := New(filepath.Join(t.TempDir(), "skills"))
+	src, err := ParseSource(repo, "v1", "skills/hello")
+	if err != nil || src.Kind != SourceGit {
+		t.Fatalf("ParseSource git: %+v %v", src, err)
+	}
+	entry, err := inst.Install(context.Background(), src, InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install git: %v", err)
+	}
+	if len(entry.Commit) != 40 {
+		t.Fatalf("expected resolved commit, got %q", entry.Commit)
+	}
+
+	writeFile(t, filepath.Join(repo, "skills", "hello", "SKILL.md"), helloSkill+"More.\n")
+	git("commit", "-qam", "update")
+	git("tag", "-f", "v1")
+	updated, changed, err := inst.Update(context.Background(), "hello")
+	if err != nil || !changed || updated.Commit == entry.Commit {
+		t.Fatalf("expected update to a new commit, got %+v changed=%v err=%v", updated, changed, err)
+	}
+	if _, changed, err := inst.Update(context.Background(), "hello"); err != nil || changed {
+		t.Fatalf("expected no change on second update, got changed=%v err=%v", changed, err)
+	}
+}
+
+// TestInstallRejectsUnsafeSources covers zip-slip, links and invalid skills.
+func TestInstallRejectsUnsafeSources(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "skills")
+	inst := New(home)
+	ctx := context.Background()
+
+	slip := filepath.Join(t.TempDir(), "slip.zip")
+	writeZip(t, slip, map[string]string{"hello/SKILL.md": helloSkill, "../evil.txt": "x"})
+	src, _ := ParseSource(slip, "", "")
+	if _, err := inst.Install(ctx, src, InstallOptions{}); !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("expected ErrUnsafePath, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "hello/link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"})
+	_ = tw.Close()
+	if err := extractTar(&buf, t.TempDir()); !errors.Is(err, ErrLink) {
+		t.Fatalf("expected ErrLink for tar symlink, got %v", err)
+	}
+
+	linked := filepath.Join(t.TempDir(), "hello")
+	writeFile(t, filepath.Join(linked, "SKILL.md"), helloSkill)
+	if err := os.Symlink("/etc/passwd", filepath.Join(linked, "passwd")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	src, _ = ParseSource(linked, "", "")
+	if _, err := inst.Install(ctx, src, InstallOptions{}); !errors.Is(err, ErrLink) {
+		t.Fatalf("expected ErrLink for directory symlink, got %v", err)
+	}
+
+	invalid := filepath.Join(t.TempDir(), "Bad")
+	writeFile(t, filepath.Join(invalid, "SKILL.md"), "---\nname: Bad\ndescription: Bad skill\n---\n")
+	src, _ = ParseSource(invalid, "", "")
+	var verr *ValidationError
+	if _, err := inst.Install(ctx, src, InstallOptions{}); !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	if _, err := ParseSource(invalid, "", "../x"); err == nil {
+		t.Fatal("expected escaping subpath to fail")
+	}
+	if entries, _ := os.ReadDir(home); len(entries) != 0 {
+		t.Fatalf("expected nothing installed, found %d entries", len(entries))
+	}
+}
+
+// TestProjectLockAndSync locks a project skill and an installed one, detects
+// drift, and restores both from the cache and from the recorded source.
+func TestProjectLockAndSync(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	lockPath := filepath.Join(project, ProjectLockFileName)
+	cache := filepath.Join(t.TempDir(), "cache")
+	home := filepath.Join(t.TempDir(), "skills")
+	inst := New(home)
+
+	writeFile(t, filepath.Join(project, "skills", "hello", "SKILL.md"), helloSkill)
+	worldSrc := filepath.Join(t.TempDir(), "world")
+	writeFile(t, filepath.Join(worldSrc, "SKILL.md"), "---\nname: world\ndescription: Greet the world\nversion: 1.2.0\n---\n# World\n")
+	src, err := ParseSource(worldSrc, "", "")
+	if err != nil {
+		t.Fatalf("ParseSource: %v", err)
+	}
+	if _, err := inst.Install(ctx, src, InstallOptions{}); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	load := func() []*skills.Skill {
+		t.Helper()
+		list, _, err := skills.Load([]string{filepath.Join(project, "skills"), home}, skills.LoadOptions{})
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		return list
+	}
+
+	lock, err := Lock(load(), lockPath, cache)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if err := WriteProjectLock(lockPath, lock); err != nil {
+		t.Fatalf("WriteProjectLock: %v", err)
+	}
+	lock, err = ReadProjectLock(lockPath)
+	if err != nil || len(lock.Skills) != 2 {
+		t.Fatalf("ReadProjectLock: %+v %v", lock, err)
+	}
+	hello, world := lock.Skills[0], lock.Skills[1]
+	if hello.Dir != "skills/hello" || hello.Source != nil {
+		t.Fatalf("expected project-relative hello without source, got %+v", hello)
+	}
+	if world.Version != "1.2.0" || world.Source == nil || world.Source.Kind != SourceDir {
+		t.Fatalf("expected world with version and source, got %+v", world)
+	}
+	if diags, err := CheckProjectLock(lockPath, load(), true); err != nil || len(diags) != 0 {
+		t.Fatalf("expected clean lock, got %v %v", diags, err)
+	}
+	if mode, err := ParseLockMode(" Refuse "); err != nil || mode != LockRefuse {
+		t.Fatalf("parse lock mode: %q %v", mode, err)
+	}
+	if _, err := ParseLockMode("strict"); err == nil {
+		t.Fatal("expected an unknown lock mode to fail")
+	}
+	if diags, err := CheckProjectLock(filepath.Join(project, "missing.lock"), load(), true); err != nil || diags != nil {
+		t.Fatalf("missing lock should check nothing, got %v %v", diags, err)
+	}
+
+	// Drift: a changed project skill and an extra installed one.
+	writeFile(t, filepath.Join(project, "skills", "hello", "SKILL.md"), helloSkill+"Changed.\n")
+	writeFile(t, filepath.Join(home, "extra", "SKILL.md"), "---\nname: extra\ndescription: Not locked\n---\n")
+	diags, err := CheckProjectLock(lockPath, load(), false)
+	if err != nil || len(diags) != 2 || diags[0].Kind != KindLock {
+		t.Fatalf("expected two lock warnings, got %v %v", diags, err)
+	}
+	if _, err := CheckProjectLock(lockPath, load(), true); err == nil {
+		t.Fatal("expected refuse mode to fail")
+	}
+	if err := os.RemoveAll(filepath.Join(home, "extra")); err != nil {
+		t.Fatalf("remove extra: %v", err)
+	}
+
+	// Sync restores hello from the cache; world is already in place.
+	results := inst.Sync(ctx, lock, project, cache)
+	if results[0].Action != "restored" || results[0].From != "cache" || results[1].Action != "ok" {
+		t.Fatalf("unexpected sync results: %+v", results)
+	}
+
+	// Without the cache, a deleted installed skill comes back from its source.
+	if err := os.RemoveAll(filepath.Join(home, "world")); err != nil {
+		t.Fatalf("remove world: %v", err)
+	}
+	results = inst.Sync(ctx, lock, project, "")
+	if results[1].Action != "restored" || results[1].From == "cache" {
+		t.Fatalf("expected world restored from source, got %+v", results)
+	}
+	if diags, err := CheckProjectLock(lockPath, load(), true); err != nil || len(diags) != 0 {
+		t.Fatalf("expected clean lock after sync, got %v %v", diags, err)
+	}
+
+	// A source that no longer matches the lock is not installed.
+	writeFile(t, filepath.Join(worldSrc, "SKILL.md"), "---\nname: world\ndescription: Changed upstream\n---\n")
+	writeFile(t, filepath.Join(home, "world", "SKILL.md"), "tampered")
+	results = inst.Sync(ctx, lock, project, "")
+	if results[1].Action != "failed" {
+		t.Fatalf("expected failed sync for a changed source, got %+v", results)
+	}
+	if data, _ := os.ReadFile(filepath.Join(home, "world", "SKILL.md")); string(data) != "tampered" {
+		t.Fatalf("failed sync should leave the skill untouched, got %q", data)
+	}
+
+	// A lockfile cannot restore outside the project and the skills home, nor
+	// replace a directory that is not a skill.
+	outside := filepath.Join(t.TempDir(), "Documents")
+	writeFile(t, filepath.Join(outside, "notes.txt"), "keep me")
+	plain := filepath.Join(project, "docs")
+	writeFile(t, filepath.Join(plain, "notes.txt"), "keep me too")
+	locked := lock.Skills[0]
+	escapes := &ProjectLock{Skills: []LockedSkill{
+		{Name: "hello", Dir: outside, Digest: locked.Digest},
+		{Name: "hello", Dir: "../" + filepath.Base(filepath.Dir(outside)) + "/Documents", Digest: locked.Digest},
+		{Name: "hello", Dir: "docs", Digest: locked.Digest},
+	}}
+	for _, result := range inst.Sync(ctx, escapes, project, cache) {
+		if result.Action != "failed" || !strings.Contains(result.Err, "refusing") {
+			t.Fatalf("expected sync to refuse %s, got %+v", result.Dir, result)
+		}
+	}
+	for _, path := range []string{filepath.Join(outside, "notes.txt"), filepath.Join(plain, "notes.txt")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected %s to be left alone: %v", path, err)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		mode := int64(0o644)
+		if filepath.Ext(name) == ".sh" {
+			mode = 0o755
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: mode, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("tar write: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar close: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	writeFile(t, path, buf.String())
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("zip write: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+	writeFile(t, path, buf.String())
+}
@@ -0,0 +1,138 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// LockFileName is the lockfile kept in the skills home.
+const LockFileName = ".skills-lock.json"
+
+// lockVersion is the current lockfile format.
+const lockVersion = 1
+
+// Entry records where an installed skill came from.
+type Entry struct {
+	Name   string `json:"name"`
+	Source Source `json:"source"`
+	// Commit is the resolved git commit for git sources.
+	Commit string `json:"commit,omitempty"`
+	// Digest is the sha256 of the installed tree (see TreeDigest).
+	Digest      string    `json:"digest"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// lockFile is the on-disk lockfile format.
+type lockFile struct {
+	Version int              `json:"version"`
+	Skills  map[string]Entry `json:"skills"`
+}
+
+// readLock loads the lockfile in home; a missing file yields an empty lock.
+func readLock(home string) (lockFile, error) {
+	lock := lockFile{Version: lockVersion, Skills: map[string]Entry{}}
+	data, err := os.ReadFile(filepath.Join(home, LockFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return lock, err
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("parse %s: %w", LockFileName, err)
+	}
+	if lock.Version > lockVersion {
+		return lock, fmt.Errorf("%s has version %d; this build supports %d", LockFileName, lock.Version, lockVersion)
+	}
+	if lock.Skills == nil {
+		lock.Skills = map[string]Entry{}
+	}
+	return lock, nil
+}
+
+// writeLock replaces the lockfile atomically.
+func writeLock(home string, lock lockFile) error {
+	lock.Version = lockVersion
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(home, LockFileName+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(home, LockFileName))
+}
+
+// TreeDigest hashes every regular file below dir: each file contributes its
+// slash-separated relative path, its executable bit and the sha256 of its content,
+// in path order. The result is stable across machines.
+func TreeDigest(dir string) (string, error) {
+	type file struct {
+		rel  string
+		exec bool
+		sum  [sha256.Size]byte
+	}
+	var files []file
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("%w: %s", ErrLink, p)
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+		entry := file{rel: filepath.ToSlash(rel), exec: info.Mode()&0o111 != 0}
+		copy(entry.sum[:], h.Sum(nil))
+		files = append(files, entry)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+
+	h := sha256.New()
+	for _, f := range files {
+		mode := "644"
+		if f.exec {
+			mode = "755"
+		}
+		_, _ = fmt.Fprintf(h, "%s %s %x\n", mode, f.rel, f.sum)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
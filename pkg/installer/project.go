@@ -0,0 +1,508 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+)
+
+// ProjectLockFileName is the project lockfile that pins the skills a project
+// uses, kept next to the project's sources and committed with them.
+const ProjectLockFileName = "skills.lock"
+
+// KindLock reports skills that do not match the project lockfile.
+const KindLock skills.DiagnosticKind = "lock"
+
+// projectLockVersion is the current project lockfile format.
+const projectLockVersion = 1
+
+// ErrDigestMismatch is returned by Sync when no cached copy or source provides
+// the locked content.
+var ErrDigestMismatch = errors.New("skill content does not match the lock")
+
+// ProjectLock pins a set of skills by content.
+type ProjectLock struct {
+	Version int           `json:"version"`
+	Skills  []LockedSkill `json:"skills"`
+}
+
+// LockedSkill is one pinned skill.
+type LockedSkill struct {
+	Name string `json:"name"`
+	// Version is the front matter version, informational only.
+	Version string `json:"version,omitempty"`
+	// Dir is where the skill is loaded from: relative to the lockfile for
+	// skills inside the project, ~/ for the user's home, absolute otherwise.
+	Dir string `json:"dir"`
+	// Source is where the skill was installed from, if known.
+	Source *Source `json:"source,omitempty"`
+	// Commit is the resolved git commit of git sources.
+	Commit string `json:"commit,omitempty"`
+	// Digest is the TreeDigest of the skill directory.
+	Digest string `json:"digest"`
+}
+
+// DefaultCache returns the skill cache next to the skills home:
+// $CODEX_HOME/skills-cache or ~/.codex/skills-cache.
+func DefaultCache() string {
+	return filepath.Join(filepath.Dir(DefaultHome()), "skills-cache")
+}
+
+// ReadProjectLock loads a project lockfile. A missing file returns an error
+// matching os.ErrNotExist.
+func ReadProjectLock(path string) (*ProjectLock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lock ProjectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if lock.Version > projectLockVersion {
+		return nil, fmt.Errorf("%s has version %d; this build supports %d", path, lock.Version, projectLockVersion)
+	}
+	return &lock, nil
+}
+
+// WriteProjectLock writes lock to path with its skills sorted by name.
+func WriteProjectLock(path string, lock *ProjectLock) error {
+	lock.Version = projectLockVersion
+	sort.Slice(lock.Skills, func(i, j int) bool { return lock.Skills[i].Name < lock.Skills[j].Name })
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Lock pins the skills in list, skipping skills loaded from an fs.FS, which
+// ship with the binary. Provenance comes from the lockfile of the skills home
+// a skill was installed into. When cache is set, each skill's tree is copied
+// there under its digest so Sync can restore it without the source.
+func Lock(list []*skills.Skill, lockPath, cache string) (*ProjectLock, error) {
+	lockDir, err := filepath.Abs(filepath.Dir(lockPath))
+	if err != nil {
+		return nil, err
+	}
+	lock := &ProjectLock{Version: projectLockVersion, Skills: []LockedSkill{}}
+	homeLocks := map[string]lockFile{}
+	for _, skill := range list {
+		if skill.Virtual() {
+			continue
+		}
+		dir, err := filepath.Abs(skill.Dir())
+		if err != nil {
+			return nil, err
+		}
+		digest, err := TreeDigest(dir)
+		if err != nil {
+			return nil, fmt.Errorf("skill %s: %w", skill.Name, err)
+		}
+		locked := LockedSkill{
+			Name:    skill.Name,
+			Version: skill.Version,
+			Dir:     portablePath(lockDir, dir),
+			Digest:  digest,
+		}
+		home := filepath.Dir(dir)
+		if _, ok := homeLocks[home]; !ok {
+			if homeLocks[home], err = readLock(home); err != nil {
+				return nil, err
+			}
+		}
+		if entry, ok := homeLocks[home].Skills[filepath.Base(dir)]; ok {
+			src := entry.Source
+			locked.Source = &src
+			locked.Commit = entry.Commit
+		}
+		if cache != "" {
+			if err := cacheTree(cache, dir, digest); err != nil {
+				return nil, fmt.Errorf("cache skill %s: %w", skill.Name, err)
+			}
+		}
+		lock.Skills = append(lock.Skills, locked)
+	}
+	sort.Slice(lock.Skills, func(i, j int) bool { return lock.Skills[i].Name < lock.Skills[j].Name })
+	return lock, nil
+}
+
+// VerifyProjectLock compares the loaded skills with lock and returns a
+// warning per difference: locked skills that are missing or whose content
+// changed, and loaded skills that are not locked. Skills loaded from an fs.FS
+// are ignored. lockDir resolves relative Dir entries.
+func VerifyProjectLock(lock *ProjectLock, lockDir string, list []*skills.Skill) []skills.Diagnostic {
+	var diags []skills.Diagnostic
+	lockPath := filepath.Join(lockDir, ProjectLockFileName)
+	loaded := map[string]*skills.Skill{}
+	for _, skill := range list {
+		if !skill.Virtual() {
+			loaded[skill.Name] = skill
+		}
+	}
+	add := func(path, format string, args ...any) {
+		diags = append(diags, skills.Diagnostic{Path: path, Kind: KindLock, Severity: skills.SeverityWarning, Message: fmt.Sprintf(format, args...)})
+	}
+	locked := map[string]bool{}
+	for _, entry := range lock.Skills {
+		locked[entry.Name] = true
+		skill, ok := loaded[entry.Name]
+		if !ok {
+			add(lockPath, "locked skill %s is not loaded (expected in %s)", entry.Name, entry.Dir)
+			continue
+		}
+		digest, err := TreeDigest(skill.Dir())
+		if err != nil {
+			add(skill.SkillFilePath, "skill %s: %v", entry.Name, err)
+			continue
+		}
+		if digest != entry.Digest {
+			add(skill.SkillFilePath, "skill %s does not match %s: digest %s, locked %s", entry.Name, ProjectLockFileName, digest, entry.Digest)
+		}
+	}
+	names := make([]string, 0, len(loaded))
+	for name := range loaded {
+		if !locked[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(loaded[name].SkillFilePath, "skill %s is not in %s", name, ProjectLockFileName)
+	}
+	return diags
+}
+
+// SyncResult reports what Sync did for one locked skill.
+type SyncResult struct {
+	Name string `json:"name"`
+	Dir  string `json:"dir"`
+	// Action is "ok" when the skill already matched, "restored" when it was
+	// replaced, or "failed".
+	Action string `json:"action"`
+	// From is "cache" or the source a restored skill came from.
+	From string `json:"from,omitempty"`
+	Err  string `json:"error,omitempty"`
+}
+
+// Sync makes every locked skill match its digest, restoring it from cache or,
+// failing that, from its recorded source at the locked commit. Skills restored
+// into this installer's home are recorded in its lockfile. lockDir resolves
+// relative Dir entries; an empty cache skips the cache. Since the lockfile is
+// committed with the project, a skill is only restored into a directory below
+// lockDir or the skills home, and an existing directory without a SKILL.md is
+// never replaced.
+func (i *Installer) Sync(ctx context.Context, lock *ProjectLock, lockDir, cache string) []SyncResult {
+	results := make([]SyncResult, 0, len(lock.Skills))
+	for _, entry := range lock.Skills {
+		dir := expandPath(lockDir, entry.Dir)
+		result := SyncResult{Name: entry.Name, Dir: dir, Action: "ok"}
+		if err := i.checkSyncDir(lockDir, dir); err != nil {
+			result.Action, result.Err = "failed", err.Error()
+		} else if digest, err := TreeDigest(dir); err != nil || digest != entry.Digest {
+			from, err := i.restore(ctx, entry, dir, cache)
+			result.Action, result.From = "restored", from
+			if err != nil {
+				result.Action, result.Err = "failed", err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkSyncDir refuses lock entries that point outside lockDir and the skills
+// home, after resolving symlinks, so a lockfile cannot replace arbitrary
+// directories.
+func (i *Installer) checkSyncDir(lockDir, dir string) error {
+	resolved, err := resolveExisting(dir)
+	if err != nil {
+		return err
+	}
+	for _, root := range []string{lockDir, i.home} {
+		if strings.TrimSpace(root) == "" {
+			continue
+		}
+		resolvedRoot, err := resolveExisting(root)
+		if err != nil {
+			return err
+		}
+		if below(resolvedRoot, resolved) {
+			return nil
+		}
+	}
+	return fmt.Errorf("refusing to sync %s: not inside %s or the skills home %s", dir, lockDir, i.home)
+}
+
+// resolveExisting makes p absolute and resolves the symlinks of its longest
+// existing prefix.
+func resolveExisting(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	rest := ""
+	for current := abs; ; {
+		if resolvedPath, err := filepath.EvalSymlinks(current); err == nil {
+			return filepath.Join(resolvedPath, rest), nil
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return abs, nil
+		}
+		rest = filepath.Join(filepath.Base(current), rest)
+		current = parent
+	}
+}
+
+// below reports whether path lies strictly inside dir.
+func below(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkReplaceable refuses to replace anything but a skill directory.
+func checkReplaceable(dir string) error {
+	info, err := os.Lstat(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("refusing to replace %s: not a directory", dir)
+	}
+	if skill, err := os.Lstat(filepath.Join(dir, "SKILL.md")); err != nil || !skill.Mode().IsRegular() {
+		return fmt.Errorf("refusing to replace %s: it has no SKILL.md", dir)
+	}
+	return nil
+}
+
+// restore replaces dir with the locked content and returns where it came from.
+// If the swap fails and the previous directory cannot be put back, it is left
+// in the staging directory named in the error.
+func (i *Installer) restore(ctx context.Context, entry LockedSkill, dir, cache string) (string, error) {
+	if err := checkName(filepath.Base(dir)); err != nil {
+		return "", err
+	}
+	if err := checkReplaceable(dir); err != nil {
+		return "", err
+	}
+	parent := filepath.Dir(dir)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return "", err
+	}
+	staging, err := stagingDir(parent, "sync")
+	if err != nil {
+		return "", err
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			_ = os.RemoveAll(staging)
+		}
+	}()
+
+	fetched := filepath.Join(staging, "skill")
+	from := ""
+	if cached := cachePath(cache, entry.Digest); cached != "" {
+		if _, err := os.Stat(cached); err == nil {
+			if err := copyTree(cached, fetched); err != nil {
+				return "", err
+			}
+			from = "cache"
+		}
+	}
+	if from == "" {
+		if entry.Source == nil {
+			return "", fmt.Errorf("%w: no cached copy of %s and no recorded source", ErrDigestMismatch, entry.Digest)
+		}
+		src := *entry.Source
+		if entry.Commit != "" {
+			src.Ref = entry.Commit
+		}
+		raw := filepath.Join(staging, "src")
+		if err := os.Mkdir(raw, 0o755); err != nil {
+			return "", err
+		}
+		if _, err := fetch(ctx, src, raw); err != nil {
+			return "", fmt.Errorf("fetch %s: %w", src, err)
+		}
+		root, err := skillRoot(filepath.Join(raw, filepath.FromSlash(src.Subpath)))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", src, err)
+		}
+		if err := os.Rename(root, fetched); err != nil {
+			return "", err
+		}
+		from = src.String()
+	}
+
+	digest, err := TreeDigest(fetched)
+	if err != nil {
+		return from, err
+	}
+	if digest != entry.Digest {
+		return from, fmt.Errorf("%w: %s provides %s, locked %s", ErrDigestMismatch, from, digest, entry.Digest)
+	}
+	backup := filepath.Join(staging, "previous")
+	if err := replaceDir(fetched, dir, backup, true); err != nil {
+		if _, statErr := os.Lstat(backup); statErr == nil {
+			keep = true
+			return from, fmt.Errorf("%w (previous copy kept in %s)", err, backup)
+		}
+		return from, err
+	}
+	if abs, err := filepath.Abs(i.home); err == nil && abs == parent && entry.Source != nil {
+		if err := i.record(Entry{Name: entry.Name, Source: *entry.Source, Commit: entry.Commit, Digest: digest}); err != nil {
+			return from, err
+		}
+	}
+	return from, nil
+}
+
+// record adds entry to the home lockfile.
+func (i *Installer) record(entry Entry) error {
+	lock, err := readLock(i.home)
+	if err != nil {
+		return err
+	}
+	entry.InstalledAt = i.now().UTC()
+	lock.Skills[entry.Name] = entry
+	return writeLock(i.home, lock)
+}
+
+// cachePath is the cache directory of a digest, or "" without a cache.
+func cachePath(cache, digest string) string {
+	hex := strings.TrimPrefix(digest, "sha256:")
+	if cache == "" || hex == "" || strings.ContainsAny(hex, `/\.`) {
+		return ""
+	}
+	return filepath.Join(cache, hex)
+}
+
+// cacheTree copies dir into the cache under digest unless it is already there.
+func cacheTree(cache, dir, digest string) error {
+	dest := cachePath(cache, digest)
+	if dest == "" {
+		return fmt.Errorf("invalid digest %q", digest)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(cache, 0o755); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(cache, ".cache-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(staging) }()
+	tree := filepath.Join(staging, "tree")
+	if err := copyTree(dir, tree); err != nil {
+		return err
+	}
+	if err := os.Rename(tree, dest); err != nil && !errors.Is(err, os.ErrExist) {
+		if _, statErr := os.Stat(dest); statErr == nil {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// portablePath writes dir relative to lockDir when inside it, as ~/... when
+// inside the user's home, and absolute otherwise.
+func portablePath(lockDir, dir string) string {
+	if rel, err := filepath.Rel(lockDir, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(rel)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "~/" + filepath.ToSlash(rel)
+		}
+	}
+	return dir
+}
+
+// expandPath reverses portablePath.
+func expandPath(lockDir, p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, filepath.FromSlash(rest))
+		}
+	}
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(lockDir, filepath.FromSlash(p))
+}
+
+// LockMode is what happens when the loaded skills differ from the project lock.
+type LockMode string
+
+const (
+	// LockWarn reports the differences as warnings.
+	LockWarn LockMode = "warn"
+	// LockRefuse fails loading.
+	LockRefuse LockMode = "refuse"
+	// LockOff skips the check.
+	LockOff LockMode = "off"
+)
+
+// ParseLockMode validates a lock mode; empty means LockWarn.
+func ParseLockMode(value string) (LockMode, error) {
+	switch mode := LockMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return LockWarn, nil
+	case LockWarn, LockRefuse, LockOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown lock mode %q (want warn, refuse or off)", value)
+	}
+}
+
+// CheckProjectLock verifies list against the project lockfile at path. A
+// missing lockfile checks nothing. With refuse, any difference is an error
+// listing them; otherwise the differences are returned as warnings.
+func CheckProjectLock(path string, list []*skills.Skill, refuse bool) ([]skills.Diagnostic, error) {
+	if path == "" {
+		return nil, nil
+	}
+	lock, err := ReadProjectLock(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	diags := VerifyProjectLock(lock, filepath.Dir(path), list)
+	if refuse && len(diags) > 0 {
+		messages := make([]string, len(diags))
+		for i, d := range diags {
+			messages[i] = d.Message
+		}
+		return nil, fmt.Errorf("skills do not match %s (run `agent-skills-go skills sync`): %s", path, strings.Join(messages, "; "))
+	}
+	return diags, nil
+}
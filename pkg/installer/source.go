@@ -0,0 +1,177 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SourceKind is the type of an install source.
+type SourceKind string
+
+const (
+	// SourceGit is a local git repository, read at Ref.
+	SourceGit SourceKind = "git"
+	// SourceArchive is a .zip, .tar.gz or .tgz file.
+	SourceArchive SourceKind = "archive"
+	// SourceDir is a plain directory.
+	SourceDir SourceKind = "dir"
+)
+
+// Source says where a skill comes from.
+type Source struct {
+	Kind SourceKind `json:"kind"`
+	// Location is an absolute path to the repository, archive or directory.
+	Location string `json:"location"`
+	// Ref is the git revision; empty means HEAD. Only used for SourceGit.
+	Ref string `json:"ref,omitempty"`
+	// Subpath is the skill directory inside the source, slash-separated.
+	Subpath string `json:"subpath,omitempty"`
+}
+
+// ParseSource builds a Source from a path. Archives are recognized by extension
+// and directories containing .git are git repositories, read from committed
+// history rather than the working tree.
+func ParseSource(location, ref, subpath string) (Source, error) {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return Source{}, errors.New("source path is required")
+	}
+	abs, err := filepath.Abs(location)
+	if err != nil {
+		return Source{}, err
+	}
+	sub, err := cleanSubpath(subpath)
+	if err != nil {
+		return Source{}, err
+	}
+	src := Source{Location: abs, Ref: strings.TrimSpace(ref), Subpath: sub}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return Source{}, err
+	}
+	switch {
+	case !info.IsDir():
+		if !isArchive(abs) {
+			return Source{}, fmt.Errorf("%s is not a directory or a .zip/.tar.gz/.tgz archive", location)
+		}
+		src.Kind = SourceArchive
+	case isGitRepo(abs):
+		src.Kind = SourceGit
+	default:
+		src.Kind = SourceDir
+	}
+	if src.Ref != "" && src.Kind != SourceGit {
+		return Source{}, fmt.Errorf("-ref requires a git repository; %s is a %s source", location, src.Kind)
+	}
+	return src, nil
+}
+
+// String formats the source for messages.
+func (s Source) String() string {
+	out := s.Location
+	if s.Ref != "" {
+		out += "@" + s.Ref
+	}
+	if s.Subpath != "" {
+		out += "//" + s.Subpath
+	}
+	return out
+}
+
+func isArchive(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".zip") || strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+func isGitRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// cleanSubpath normalizes a slash-separated subpath and rejects escapes.
+func cleanSubpath(subpath string) (string, error) {
+	subpath = strings.Trim(filepath.ToSlash(strings.TrimSpace(subpath)), "/")
+	if subpath == "" {
+		return "", nil
+	}
+	clean := path.Clean(subpath)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("subpath %q escapes the source", subpath)
+	}
+	if clean == "." {
+		return "", nil
+	}
+	return clean, nil
+}
+
+// fetch copies the source tree into dst and returns the resolved git commit,
+// if any. Only the subpath is fetched for git sources.
+func fetch(ctx context.Context, src Source, dst string) (string, error) {
+	switch src.Kind {
+	case SourceDir:
+		return "", copyTree(src.Location, dst)
+	case SourceArchive:
+		return "", extractArchive(src.Location, dst)
+	case SourceGit:
+		return fetchGit(ctx, src, dst)
+	default:
+		return "", fmt.Errorf("unknown source kind %q", src.Kind)
+	}
+}
+
+// fetchGit exports the tree at src.Ref with git archive and extracts it into dst.
+func fetchGit(ctx context.Context, src Source, dst string) (string, error) {
+	ref := src.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	out, err := exec.CommandContext(ctx, "git", "-C", src.Location, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}").Output()
+	if err != nil {
+		return "", fmt.Errorf("resolve %s in %s: %w", ref, src.Location, gitError(err))
+	}
+	commit := strings.TrimSpace(string(out))
+
+	args := []string{"-C", src.Location, "archive", "--format=tar", commit}
+	if src.Subpath != "" {
+		args = append(args, "--", src.Subpath)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+	extractErr := extractTar(stdout, dst)
+	if extractErr != nil {
+		// git may be blocked writing the rest of the archive.
+		_ = cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+	if extractErr != nil {
+		return "", extractErr
+	}
+	if waitErr != nil {
+		return "", fmt.Errorf("git archive: %v: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return commit, nil
+}
+
+// gitError includes git's stderr in exec errors.
+func gitError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,325 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+)
+
+// DefaultRequestTimeout bounds requests whose context has no deadline.
+const DefaultRequestTimeout = 60 * time.Second
+
+// ErrClosed is returned for requests on a closed client.
+var ErrClosed = errors.New("mcp client closed")
+
+// ClientOptions tunes a client connection.
+type ClientOptions struct {
+	// ClientInfo identifies this client in the initialize handshake.
+	ClientInfo Implementation
+	// Stderr receives the stderr of stdio servers; nil discards it.
+	Stderr io.Writer
+	// HTTPClient is used by the streamable HTTP transport; nil uses http.DefaultClient.
+	HTTPClient *http.Client
+	// RequestTimeout overrides DefaultRequestTimeout.
+	RequestTimeout time.Duration
+}
+
+// Client is a connection to one MCP server.
+type Client struct {
+	name    string
+	t       transport
+	timeout time.Duration
+
+	mu           sync.Mutex
+	nextID       int64
+	pending      map[string]chan *message
+	closed       bool
+	toolsChanged []func()
+
+	serverInfo   Implementation
+	capabilities map[string]json.RawMessage
+	done         chan struct{}
+}
+
+// Connect starts the transport for server and performs the initialize handshake.
+func Connect(ctx context.Context, server configpkg.MCPServerConfig, opts ClientOptions) (*Client, error) {
+	var (
+		t   transport
+		err error
+	)
+	switch server.Transport {
+	case configpkg.MCPTransportStdio, "":
+		t, err = newStdioTransport(server, opts.Stderr)
+	case configpkg.MCPTransportStreamableHTTP:
+		t = newHTTPTransport(server.URL, server.Headers, opts.HTTPClient)
+	default:
+		err = fmt.Errorf("unsupported transport %q", server.Transport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mcp server %s: %w", server.Name, err)
+	}
+
+	c := newClient(server.Name, t, opts.RequestTimeout)
+	if err := c.initialize(ctx, opts.ClientInfo); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("mcp server %s: initialize: %w", server.Name, err)
+	}
+	if ht, ok := t.(*httpTransport); ok {
+		ht.listen()
+	}
+	return c, nil
+}
+
+func newClient(name string, t transport, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	c := &Client{
+		name:    name,
+		t:       t,
+		timeout: timeout,
+		pending: map[string]chan *message{},
+		done:    make(chan struct{}),
+	}
+	go c.dispatch()
+	return c
+}
+
+// Name returns the configured server name.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// ServerInfo returns the implementation reported by the server.
+func (c *Client) ServerInfo() Implementation {
+	return c.serverInfo
+}
+
+// OnToolsChanged registers a callback for notifications/tools/list_changed.
+func (c *Client) OnToolsChanged(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.toolsChanged = append(c.toolsChanged, fn)
+}
+
+func (c *Client) initialize(ctx context.Context, info Implementation) error {
+	if info.Name == "" {
+		info = Implementation{Name: "agent-skills-go", Version: "dev"}
+	}
+	params := map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      info,
+	}
+	var result struct {
+		ProtocolVersion string                     `json:"protocolVersion"`
+		Capabilities    map[string]json.RawMessage `json:"capabilities"`
+		ServerInfo      Implementation             `json:"serverInfo"`
+	}
+	if err := c.call(ctx, "initialize", params, &result); err != nil {
+		return err
+	}
+	c.serverInfo = result.ServerInfo
+	c.capabilities = result.Capabilities
+	if ht, ok := c.t.(*httpTransport); ok {
+		ht.setProtocolVersion(result.ProtocolVersion)
+	}
+	return c.notify(ctx, "notifications/initialized", nil)
+}
+
+// ListTools returns every tool the server offers, following pagination cursors.
+func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
+	var all []ToolInfo
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []ToolInfo `json:"tools"`
+			NextCursor string     `json:"nextCursor"`
+		}
+		if err := c.call(ctx, "tools/list", params, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return all, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool invokes a tool with JSON arguments.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*CallToolResult, error) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage(`{}`)
+	}
+	params := map[string]any{
+		"name":      name,
+		"arguments": arguments,
+	}
+	var result CallToolResult
+	if err := c.call(ctx, "tools/call", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close ends the session and stops the server process for stdio transports.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	err := c.t.close()
+	<-c.done
+	return err
+}
+
+// call sends a request and decodes its result into out.
+func (c *Client) call(ctx context.Context, method string, params any, out any) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	reply := make(chan *message, 1)
+	c.pending[id] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	msg := &message{JSONRPC: "2.0", ID: json.RawMessage(id), Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = raw
+	}
+	if err := c.t.send(ctx, msg); err != nil {
+		return err
+	}
+
+	select {
+	case resp, ok := <-reply:
+		if !ok {
+			return ErrClosed
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if out == nil {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, out); err != nil {
+			return fmt.Errorf("decode %s result: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		// Let the server stop working on a request nobody waits for.
+		_ = c.notify(context.Background(), "notifications/cancelled", map[string]any{
+			"requestId": json.RawMessage(id),
+			"reason":    ctx.Err().Error(),
+		})
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+}
+
+// notify sends a one-way notification.
+func (c *Client) notify(ctx context.Context, method string, params any) error {
+	msg := &message{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = raw
+	}
+	return c.t.send(ctx, msg)
+}
+
+// dispatch routes incoming responses, notifications and server requests.
+func (c *Client) dispatch() {
+	defer close(c.done)
+	for msg := range c.t.incoming() {
+		switch {
+		case msg.isResponse():
+			c.mu.Lock()
+			reply, ok := c.pending[normalizeID(msg.ID)]
+			c.mu.Unlock()
+			if ok {
+				reply <- msg
+			}
+		case msg.isNotification():
+			if msg.Method == "notifications/tools/list_changed" {
+				c.mu.Lock()
+				handlers := append([]func(){}, c.toolsChanged...)
+				c.mu.Unlock()
+				for _, fn := range handlers {
+					go fn()
+				}
+			}
+		case msg.isRequest():
+			c.answerServerRequest(msg)
+		}
+	}
+
+	c.mu.Lock()
+	c.closed = true
+	for id, reply := range c.pending {
+		close(reply)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+}
+
+// answerServerRequest replies to requests initiated by the server.
+// Only ping is supported; sampling, roots and elicitation are not offered.
+func (c *Client) answerServerRequest(msg *message) {
+	var resp *message
+	if msg.Method == "ping" {
+		resp, _ = newResponse(msg.ID, struct{}{}, nil)
+	} else {
+		resp, _ = newResponse(msg.ID, nil, &RPCError{Code: codeMethodNotFound, Message: "method not supported by client: " + msg.Method})
+	}
+	if resp != nil {
+		_ = c.t.send(context.Background(), resp)
+	}
+}
+
+// normalizeID makes numeric and string ids comparable as map keys.
+func normalizeID(id json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(id, &s); err == nil {
+		return s
+	}
+	return string(id)
+}
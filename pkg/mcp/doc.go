@@ -0,0 +1,3 @@
+// Package mcp implements a Model Context Protocol client and server
+// (JSON-RPC 2.0 over stdio or streamable HTTP).
+package mcp
@@ -0,0 +1,305 @@
+// Tests for the MCP client against a local stdio stub server.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go"
+
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+)
+
+// stubServerEnv makes the test binary act as an MCP stdio server.
+const stubServerEnv = "AGENT_SKILLS_MCP_STUB"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(stubServerEnv) == "1" {
+		runStubServer()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runStubServer serves echo and add_tool over stdin/stdout until EOF. It
+// lists echo twice.
+// Calling add_tool announces a list change that exposes a second tool.
+func runStubServer() {
+	out := json.NewEncoder(os.Stdout)
+	extra := false
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || !msg.isRequest() {
+			continue
+		}
+		var result any
+		var rpcErr *RPCError
+		notifyChanged := false
+		switch msg.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": ProtocolVersion,
+				"capabilities":    map[string]any{"tools": map[string]any{"listChanged": true}},
+				"serverInfo":      Implementation{Name: "stub", Version: "1.0"},
+			}
+		case "tools/list":
+			list := []ToolInfo{
+				{Name: "echo", Description: "Echo text", InputSchema: map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"text": map[string]any{"type": "string"}},
+				}},
+				{Name: "add_tool", InputSchema: map[string]any{"type": "object"}},
+				// A repeated name is skipped instead of failing the mount.
+				{Name: "echo", Description: "Duplicate echo"},
+			}
+			if extra {
+				list = append(list, ToolInfo{Name: "extra.tool", InputSchema: map[string]any{"type": "object"}})
+			}
+			result = map[string]any{"tools": list}
+		case "tools/call":
+			var params struct {
+				Name      string         `json:"name"`
+				Arguments map[string]any `json:"arguments"`
+			}
+			_ = json.Unmarshal(msg.Params, &params)
+			switch params.Name {
+			case "echo":
+				result = CallToolResult{Content: []Content{{Type: "text", Text: fmt.Sprint(params.Arguments["text"])}}}
+			case "add_tool":
+				extra = true
+				result = CallToolResult{Content: []Content{{Type: "text", Text: "added"}}}
+				notifyChanged = true
+			default:
+				result = CallToolResult{IsError: true, Content: []Content{{Type: "text", Text: "no such tool"}}}
+			}
+		default:
+			rpcErr = &RPCError{Code: codeMethodNotFound, Message: msg.Method}
+		}
+		resp, _ := newResponse(msg.ID, result, rpcErr)
+		_ = out.Encode(resp)
+		if notifyChanged {
+			_ = out.Encode(message{JSONRPC: "2.0", Method: "notifications/tools/list_changed"})
+		}
+	}
+}
+
+// TestClientStdio covers the handshake, tools/list, tools/call and list_changed handling.
+func TestClientStdio(t *testing.T) {
+	client, err := Connect(context.Background(), configpkg.MCPServerConfig{
+		Name:      "stub",
+		Transport: configpkg.MCPTransportStdio,
+		Command:   os.Args[0],
+		Env:       map[string]string{stubServerEnv: "1"},
+	}, ClientOptions{RequestTimeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if client.ServerInfo().Name != "stub" {
+		t.Fatalf("unexpected server info: %+v", client.ServerInfo())
+	}
+
+	registry := tools.New(tools.Context{Ctx: context.Background()})
+	if err := Mount(context.Background(), registry, client, nil); err != nil {
+		t.Fatalf("Mount: %v", err)
+	}
+	if !hasTool(registry, "mcp__stub__echo") {
+		t.Fatalf("expected namespaced echo tool, got %v", registry.Names())
+	}
+
+	out, err := registry.Execute(toolCall("mcp__stub__echo", `{"text":"hi there"}`))
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(out, `"ok":true`) || !strings.Contains(out, "hi there") {
+		t.Fatalf("unexpected echo output: %s", out)
+	}
+
+	if _, err := registry.Execute(toolCall("mcp__stub__add_tool", `{}`)); err != nil {
+		t.Fatalf("Execute add_tool: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !hasTool(registry, ToolName("stub", "extra.tool")) {
+		if time.Now().After(deadline) {
+			t.Fatalf("tool list was not refreshed after list_changed: %v", registry.Names())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestToolName checks that sanitized and truncated names stay unique.
+func TestToolName(t *testing.T) {
+	if got := ToolName("stub", "echo"); got != "mcp__stub__echo" {
+		t.Fatalf("expected a valid name unchanged, got %q", got)
+	}
+	long := strings.Repeat("x", 70)
+	names := []string{ToolName("s", "a.b"), ToolName("s", "a_b"), ToolName("s", long+"1"), ToolName("s", long+"2")}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] || len(name) > 64 || strings.Trim(name, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") != "" {
+			t.Fatalf("unexpected tool names %v", names)
+		}
+		seen[name] = true
+	}
+}
+
+// TestClientStreamableHTTP covers JSON and SSE responses and session headers.
+func TestClientStreamableHTTP(t *testing.T) {
+	var sawSession bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var msg message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !msg.isRequest() {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		switch msg.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "session-1")
+			w.Header().Set("Content-Type", "application/json")
+			resp, _ := newResponse(msg.ID, map[string]any{
+				"protocolVersion": ProtocolVersion,
+				"serverInfo":      Implementation{Name: "http-stub", Version: "1.0"},
+			}, nil)
+			_ = json.NewEncoder(w).Encode(resp)
+		case "tools/list":
+			sawSession = r.Header.Get("Mcp-Session-Id") == "session-1"
+			w.Header().Set("Content-Type", "text/event-stream")
+			resp, _ := newResponse(msg.ID, map[string]any{"tools": []ToolInfo{{Name: "ping"}}}, nil)
+			payload, _ := json.Marshal(resp)
+			_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", payload)
+		}
+	}))
+	defer server.Close()
+
+	client, err := Connect(context.Background(), configpkg.MCPServerConfig{
+		Name:      "remote",
+		Transport: configpkg.MCPTransportStreamableHTTP,
+		URL:       server.URL,
+	}, ClientOptions{RequestTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	list, err := client.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "ping" {
+		t.Fatalf("unexpected tools: %+v", list)
+	}
+	if !sawSession {
+		t.Fatal("expected Mcp-Session-Id header on follow-up requests")
+	}
+}
+
+// TestServer exercises tools, resources and prompts through an in-process client.
+func TestServer(t *testing.T) {
+	allowed := t.TempDir()
+	skillDir := filepath.Join(t.TempDir(), "demo")
+	if err := os.MkdirAll(filepath.Join(skillDir, "agents"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("---\nname: demo\ndescription: Demo skill\n---\n# Demo\n"), 0o644); err != nil {
+		t.Fatalf("write SKILL.md: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(skillDir, "agents", "openai.yaml"), []byte("interface:\n  default_prompt: \"Use $demo to say hi.\"\n"), 0o644); err != nil {
+		t.Fatalf("write openai.yaml: %v", err)
+	}
+	skillList, err := skills.LoadFromDirs([]string{skillDir})
+	if err != nil {
+		t.Fatalf("LoadFromDirs: %v", err)
+	}
+	registry := tools.New(tools.Context{AllowedDirs: []string{allowed}, Ctx: context.Background()})
+	server := NewServer(Implementation{Name: "test"}, registry, skillList, nil)
+
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.ServeStdio(ctx, serverIn, serverOut) }()
+
+	client := newClient("local", newStreamTransport(clientIn, clientOut, func() error {
+		_ = clientOut.Close()
+		return serverOut.Close()
+	}), 5*time.Second)
+	defer func() { _ = client.Close() }()
+	if err := client.initialize(context.Background(), Implementation{}); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	list, err := client.ListTools(context.Background())
+	if err != nil || len(list) != 3 {
+		t.Fatalf("expected 3 built-in tools, got %+v (%v)", list, err)
+	}
+
+	result, err := client.CallTool(context.Background(), "read_file", json.RawMessage(`{"path":"/etc/hostname"}`))
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "outside allowed directories") {
+		t.Fatalf("expected allowed-dir policy to apply, got %+v", result)
+	}
+
+	var read struct {
+		Contents []Resource `json:"contents"`
+	}
+	if err := client.call(context.Background(), "resources/read", map[string]any{"uri": "skill://demo/SKILL.md"}, &read); err != nil {
+		t.Fatalf("resources/read: %v", err)
+	}
+	if len(read.Contents) != 1 || !strings.Contains(read.Contents[0].Text, "# Demo") {
+		t.Fatalf("unexpected resource contents: %+v", read)
+	}
+
+	var prompt struct {
+		Messages []struct {
+			Content Content `json:"content"`
+		} `json:"messages"`
+	}
+	if err := client.call(context.Background(), "prompts/get", map[string]any{"name": "demo"}, &prompt); err != nil {
+		t.Fatalf("prompts/get: %v", err)
+	}
+	if len(prompt.Messages) != 1 || prompt.Messages[0].Content.Text != "Use $demo to say hi." {
+		t.Fatalf("unexpected prompt: %+v", prompt)
+	}
+}
+
+// hasTool reports whether registry has a tool with name.
+func hasTool(registry *tools.Registry, name string) bool {
+	for _, n := range registry.Names() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// toolCall builds a tool call for Registry.Execute.
+func toolCall(name, args string) openai.ChatCompletionMessageToolCall {
+	return openai.ChatCompletionMessageToolCall{
+		ID:       "call_1",
+		Function: openai.ChatCompletionMessageToolCallFunction{Name: name, Arguments: args},
+	}
+}
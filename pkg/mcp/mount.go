@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+)
+
+// maxToolName is the longest tool name the chat completions API accepts.
+const maxToolName = 64
+
+// ToolName returns the registry name for a server tool: mcp__<server>__<tool>.
+// Names that need sanitizing to characters accepted by the chat completions
+// API, or cutting to 64 bytes, get a short hash of the original server and tool
+// names appended, so distinct tools such as "a.b" and "a_b" stay distinct.
+func ToolName(server, tool string) string {
+	raw := "mcp__" + server + "__" + tool
+	name := "mcp__" + sanitizeName(server) + "__" + sanitizeName(tool)
+	if name == raw && len(name) <= maxToolName {
+		return name
+	}
+	sum := sha256.Sum256([]byte(server + "\x00" + tool))
+	suffix := "_" + hex.EncodeToString(sum[:4])
+	if len(name) > maxToolName-len(suffix) {
+		name = name[:maxToolName-len(suffix)]
+	}
+	return name + suffix
+}
+
+// sanitizeName replaces characters outside [a-zA-Z0-9_-] with underscores.
+func sanitizeName(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r == '_' || r == '-' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// Mount registers the client's tools into registry under namespaced names and
+// keeps them in sync when the server sends notifications/tools/list_changed.
+func Mount(ctx context.Context, registry *tools.Registry, client *Client, logger loggerpkg.Logger) error {
+	m := &mount{registry: registry, client: client, logger: logger}
+	if err := m.refresh(ctx); err != nil {
+		return err
+	}
+	client.OnToolsChanged(func() {
+		if err := m.refresh(context.Background()); err != nil {
+			loggerpkg.Warn(logger, "mcp tool refresh failed", map[string]any{
+				"server": client.Name(),
+				"error":  err.Error(),
+			})
+		}
+	})
+	return nil
+}
+
+// mount tracks which registry entries belong to one client.
+type mount struct {
+	registry *tools.Registry
+	client   *Client
+	logger   loggerpkg.Logger
+
+	mu      sync.Mutex
+	mounted []string
+}
+
+// refresh replaces the mounted tools with the server's current list.
+func (m *mount) refresh(ctx context.Context) error {
+	list, err := m.client.ListTools(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range m.mounted {
+		m.registry.Unregister(name)
+	}
+	m.mounted = m.mounted[:0]
+
+	for _, info := range list {
+		name := ToolName(m.client.Name(), info.Name)
+		if err := m.registry.Register(m.toolFor(name, info)); err != nil {
+			// A name taken by another tool skips this one, not the whole server.
+			loggerpkg.Warn(m.logger, "mcp tool skipped", map[string]any{
+				"server": m.client.Name(),
+				"tool":   info.Name,
+				"error":  err.Error(),
+			})
+			continue
+		}
+		m.mounted = append(m.mounted, name)
+	}
+	loggerpkg.Info(m.logger, "mcp tools mounted", map[string]any{
+		"server": m.client.Name(),
+		"count":  len(m.mounted),
+	})
+	return nil
+}
+
+// toolFor adapts a server tool to a registry tool.
+func (m *mount) toolFor(name string, info ToolInfo) tools.Tool {
+	schema := info.InputSchema
+	if schema == nil {
+		schema = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	description := info.Description
+	if description == "" {
+		description = "Tool " + info.Name + " from MCP server " + m.client.Name()
+	}
+	client := m.client
+	remoteName := info.Name
+	return tools.Tool{
+		Name:        name,
+		Description: description,
+		Parameters:  schema,
+		Execute: func(ctx context.Context, argText string) (any, error) {
+			args := json.RawMessage(argText)
+			if strings.TrimSpace(argText) == "" {
+				args = nil
+			}
+			result, err := client.CallTool(ctx, remoteName, args)
+			if err != nil {
+				return nil, err
+			}
+			return toolResultData(result)
+		},
+	}
+}
+
+// toolResultData flattens a tools/call result for the model.
+func toolResultData(result *CallToolResult) (any, error) {
+	var text []string
+	var other []Content
+	for _, item := range result.Content {
+		if item.Type == "text" {
+			text = append(text, item.Text)
+		} else {
+			other = append(other, item)
+		}
+	}
+	if result.IsError {
+		msg := strings.Join(text, "\n")
+		if msg == "" {
+			msg = "tool reported an error"
+		}
+		return nil, errors.New(msg)
+	}
+	return struct {
+		Text       string          `json:"text,omitempty"`
+		Structured json.RawMessage `json:"structured,omitempty"`
+		Content    []Content       `json:"content,omitempty"`
+	}{
+		Text:       strings.Join(text, "\n"),
+		Structured: result.StructuredContent,
+		Content:    other,
+	}, nil
+}
@@ -0,0 +1,276 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+	"github.com/openai/openai-go"
+)
+
+// Server exposes a tool registry, skill files and skill prompts over MCP.
+// Tool calls go through Registry.Execute, so the registry's allowed directories
+// and command policy apply unchanged.
+type Server struct {
+	info     Implementation
+	registry *tools.Registry
+	skills   []*skills.Skill
+	logger   loggerpkg.Logger
+}
+
+// NewServer builds a server. registry and skillList may be empty.
+func NewServer(info Implementation, registry *tools.Registry, skillList []*skills.Skill, logger loggerpkg.Logger) *Server {
+	if info.Name == "" {
+		info = Implementation{Name: "agent-skills-go", Version: "dev"}
+	}
+	return &Server{info: info, registry: registry, skills: skillList, logger: logger}
+}
+
+// ServeStdio answers newline-delimited JSON-RPC requests from in on out until
+// in is exhausted or ctx is cancelled.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	t := newStreamTransport(in, out, nil)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-t.incoming():
+			if !ok {
+				return nil
+			}
+			if !msg.isRequest() {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp := s.handle(ctx, msg)
+				if err := t.send(ctx, resp); err != nil {
+					loggerpkg.Warn(s.logger, "mcp send failed", map[string]any{"error": err.Error()})
+				}
+			}()
+		}
+	}
+}
+
+// handle dispatches one request and always returns a response.
+func (s *Server) handle(ctx context.Context, msg *message) *message {
+	result, rpcErr := s.dispatch(ctx, msg)
+	resp, err := newResponse(msg.ID, result, rpcErr)
+	if err != nil {
+		resp, _ = newResponse(msg.ID, nil, &RPCError{Code: codeInternalError, Message: err.Error()})
+	}
+	return resp
+}
+
+func (s *Server) dispatch(_ context.Context, msg *message) (any, *RPCError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities": map[string]any{
+				"tools":     map[string]any{},
+				"resources": map[string]any{},
+				"prompts":   map[string]any{},
+			},
+			"serverInfo": s.info,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.listTools()}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil || params.Name == "" {
+			return nil, &RPCError{Code: codeInvalidParams, Message: "tools/call requires a tool name"}
+		}
+		return s.callTool(params.Name, params.Arguments), nil
+	case "resources/list":
+		return map[string]any{"resources": s.listResources()}, nil
+	case "resources/read":
+		var params struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &RPCError{Code: codeInvalidParams, Message: "resources/read requires a uri"}
+		}
+		resource, err := s.readResource(params.URI)
+		if err != nil {
+			return nil, &RPCError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return map[string]any{"contents": []Resource{resource}}, nil
+	case "prompts/list":
+		return map[string]any{"prompts": s.listPrompts()}, nil
+	case "prompts/get":
+		var params struct {
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &RPCError{Code: codeInvalidParams, Message: "prompts/get requires a name"}
+		}
+		prompt, err := s.getPrompt(params.Name, params.Arguments)
+		if err != nil {
+			return nil, &RPCError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return prompt, nil
+	default:
+		return nil, &RPCError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+}
+
+// listTools converts the registry definitions to MCP tool descriptors.
+func (s *Server) listTools() []ToolInfo {
+	if s.registry == nil {
+		return []ToolInfo{}
+	}
+	defs := s.registry.Definitions()
+	out := make([]ToolInfo, 0, len(defs))
+	for _, def := range defs {
+		schema := map[string]any(def.Function.Parameters)
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		out = append(out, ToolInfo{
+			Name:        def.Function.Name,
+			Description: def.Function.Description.Value,
+			InputSchema: schema,
+		})
+	}
+	return out
+}
+
+// callTool runs a registry tool and maps its {"ok":...} envelope to a tool result.
+func (s *Server) callTool(name string, arguments json.RawMessage) CallToolResult {
+	if s.registry == nil {
+		return CallToolResult{IsError: true, Content: []Content{{Type: "text", Text: "no tools available"}}}
+	}
+	if len(arguments) == 0 || string(arguments) == "null" {
+		arguments = json.RawMessage(`{}`)
+	}
+	output, err := s.registry.Execute(openai.ChatCompletionMessageToolCall{
+		ID: "mcp",
+		Function: openai.ChatCompletionMessageToolCallFunction{
+			Name:      name,
+			Arguments: string(arguments),
+		},
+	})
+	if err != nil {
+		return CallToolResult{IsError: true, Content: []Content{{Type: "text", Text: err.Error()}}}
+	}
+
+	var envelope struct {
+		OK    bool            `json:"ok"`
+		Data  json.RawMessage `json:"data"`
+		Error string          `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(output), &envelope); err != nil {
+		return CallToolResult{Content: []Content{{Type: "text", Text: output}}}
+	}
+	if !envelope.OK {
+		return CallToolResult{IsError: true, Content: []Content{{Type: "text", Text: envelope.Error}}}
+	}
+	result := CallToolResult{Content: []Content{{Type: "text", Text: string(envelope.Data)}}}
+	if len(envelope.Data) > 0 && envelope.Data[0] == '{' {
+		result.StructuredContent = envelope.Data
+	}
+	return result
+}
+
+// skillURI returns the resource URI of a skill's SKILL.md.
+func skillURI(skill *skills.Skill) string {
+	return skills.URIScheme + skill.Name + "/SKILL.md"
+}
+
+func (s *Server) listResources() []Resource {
+	out := make([]Resource, 0, len(s.skills))
+	for _, skill := range s.skills {
+		out = append(out, Resource{
+			URI:         skillURI(skill),
+			Name:        skill.Name,
+			Description: skill.Description,
+			MimeType:    "text/markdown",
+		})
+	}
+	return out
+}
+
+func (s *Server) readResource(uri string) (Resource, error) {
+	for _, skill := range s.skills {
+		if skillURI(skill) != uri {
+			continue
+		}
+		content, err := fs.ReadFile(skill.FS(), filepath.Base(skill.SkillFilePath))
+		if err != nil {
+			return Resource{}, fmt.Errorf("read %s: %w", uri, err)
+		}
+		return Resource{URI: uri, MimeType: "text/markdown", Text: string(content)}, nil
+	}
+	return Resource{}, fmt.Errorf("unknown resource: %s", uri)
+}
+
+// promptInfo is a prompt advertised by prompts/list.
+type promptInfo struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []promptArgument `json:"arguments,omitempty"`
+}
+
+type promptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// listPrompts offers one prompt per skill with a default_prompt.
+func (s *Server) listPrompts() []promptInfo {
+	out := []promptInfo{}
+	for _, skill := range s.skills {
+		if skill.Interface.DefaultPrompt == "" {
+			continue
+		}
+		out = append(out, promptInfo{
+			Name:        skill.Name,
+			Description: skill.Description,
+			Arguments: []promptArgument{{
+				Name:        "request",
+				Description: "Optional task details appended to the default prompt.",
+			}},
+		})
+	}
+	return out
+}
+
+func (s *Server) getPrompt(name string, args map[string]string) (any, error) {
+	for _, skill := range s.skills {
+		if skill.Name != name || skill.Interface.DefaultPrompt == "" {
+			continue
+		}
+		text := skill.Interface.DefaultPrompt
+		if request := strings.TrimSpace(args["request"]); request != "" {
+			text += "\n\n" + request
+		}
+		return map[string]any{
+			"description": skill.Description,
+			"messages": []map[string]any{{
+				"role":    "user",
+				"content": Content{Type: "text", Text: text},
+			}},
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown prompt: %s", name)
+}
@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+)
+
+// maxMessageBytes bounds a single newline-delimited JSON-RPC message.
+const maxMessageBytes = 16 * 1024 * 1024
+
+// transport moves JSON-RPC messages between peers.
+type transport interface {
+	// send delivers one message to the peer.
+	send(ctx context.Context, msg *message) error
+	// incoming yields messages from the peer and is closed when the connection ends.
+	incoming() <-chan *message
+	close() error
+}
+
+// streamTransport speaks newline-delimited JSON over a reader/writer pair,
+// as used by the stdio transport on both the client and server side.
+type streamTransport struct {
+	w       io.Writer
+	writeMu sync.Mutex
+	in      chan *message
+	onClose func() error
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newStreamTransport(r io.Reader, w io.Writer, onClose func() error) *streamTransport {
+	t := &streamTransport{
+		w:       w,
+		in:      make(chan *message, 16),
+		onClose: onClose,
+	}
+	go t.readLoop(r)
+	return t
+}
+
+func (t *streamTransport) readLoop(r io.Reader) {
+	defer close(t.in)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var msg message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			// Peers may print non-protocol noise; skip lines that are not JSON-RPC.
+			continue
+		}
+		t.in <- &msg
+	}
+}
+
+func (t *streamTransport) send(_ context.Context, msg *message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.w.Write(append(payload, '\n')); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+	return nil
+}
+
+func (t *streamTransport) incoming() <-chan *message {
+	return t.in
+}
+
+func (t *streamTransport) close() error {
+	t.closeOnce.Do(func() {
+		if t.onClose != nil {
+			t.closeErr = t.onClose()
+		}
+	})
+	return t.closeErr
+}
+
+// newStdioTransport launches the server process and talks to it over stdin/stdout.
+func newStdioTransport(server configpkg.MCPServerConfig, stderr io.Writer) (*streamTransport, error) {
+	cmd := exec.Command(server.Command, server.Args...)
+	cmd.Env = tools.SanitizedEnv()
+	keys := make([]string, 0, len(server.Env))
+	for key := range server.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cmd.Env = append(cmd.Env, key+"="+server.Env[key])
+	}
+	cmd.Dir = server.WorkingDir
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", server.Command, err)
+	}
+
+	onClose := func() error {
+		_ = stdin.Close()
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		select {
+		case <-done:
+			return nil
+		case <-time.After(2 * time.Second):
+			// The server ignored EOF on stdin; stop it.
+			if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				return err
+			}
+			<-done
+			return nil
+		}
+	}
+	return newStreamTransport(stdout, stdin, onClose), nil
+}
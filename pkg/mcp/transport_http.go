@@ -0,0 +1,226 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// httpTransport implements the MCP streamable HTTP transport: every message is
+// POSTed to one endpoint, and responses arrive as JSON or as an SSE stream.
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+
+	in        chan *message
+	wg        sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+func newHTTPTransport(url string, headers map[string]string, client *http.Client) *httpTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &httpTransport{
+		url:     url,
+		headers: headers,
+		client:  client,
+		in:      make(chan *message, 16),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+func (t *httpTransport) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set("MCP-Protocol-Version", t.protocolVersion)
+	}
+	t.mu.Unlock()
+	return req, nil
+}
+
+func (t *httpTransport) send(ctx context.Context, msg *message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := t.newRequest(ctx, http.MethodPost, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post %s: %w", t.url, err)
+	}
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusNoContent {
+		_ = resp.Body.Close()
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		return fmt.Errorf("post %s: %s: %s", t.url, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		// The stream may stay open for server notifications; drain it in the background.
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			defer func() { _ = resp.Body.Close() }()
+			t.readEventStream(resp.Body)
+		}()
+		return nil
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageBytes))
+	if err != nil {
+		return err
+	}
+	return t.deliver(body)
+}
+
+// deliver decodes a single message or a batch and queues it for the client.
+func (t *httpTransport) deliver(body []byte) error {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	var batch []*message
+	if body[0] == '[' {
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	} else {
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		batch = append(batch, &msg)
+	}
+	for _, msg := range batch {
+		select {
+		case t.in <- msg:
+		case <-t.ctx.Done():
+			return t.ctx.Err()
+		}
+	}
+	return nil
+}
+
+// readEventStream parses Server-Sent Events and delivers each data payload.
+func (t *httpTransport) readEventStream(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageBytes)
+	var data strings.Builder
+	flush := func() {
+		if data.Len() > 0 {
+			_ = t.deliver([]byte(data.String()))
+			data.Reset()
+		}
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	flush()
+}
+
+// listen opens the optional GET stream for server-initiated messages.
+// Servers that do not offer one answer 405, which is not an error.
+func (t *httpTransport) listen() {
+	req, err := t.newRequest(t.ctx, http.MethodGet, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer func() { _ = resp.Body.Close() }()
+		t.readEventStream(resp.Body)
+	}()
+}
+
+// setProtocolVersion records the negotiated version for subsequent requests.
+func (t *httpTransport) setProtocolVersion(version string) {
+	t.mu.Lock()
+	t.protocolVersion = version
+	t.mu.Unlock()
+}
+
+func (t *httpTransport) incoming() <-chan *message {
+	return t.in
+}
+
+func (t *httpTransport) close() error {
+	t.closeOnce.Do(func() {
+		t.mu.Lock()
+		hasSession := t.sessionID != ""
+		t.mu.Unlock()
+		if hasSession {
+			// Best effort: tell the server the session is over.
+			if req, err := t.newRequest(context.Background(), http.MethodDelete, nil); err == nil {
+				if resp, err := t.client.Do(req); err == nil {
+					_ = resp.Body.Close()
+				}
+			}
+		}
+		t.cancel()
+		t.wg.Wait()
+		close(t.in)
+	})
+	return nil
+}
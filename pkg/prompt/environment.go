@@ -0,0 +1,176 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+)
+
+// gitTimeout bounds the git status call made for each environment snapshot.
+const gitTimeout = 2 * time.Second
+
+// Environment describes where the agent runs.
+type Environment struct {
+	OS   string
+	Arch string
+	// Date is the local date, as YYYY-MM-DD.
+	Date string
+	// WorkingDir is the process working directory.
+	WorkingDir string
+	// AllowedDirs are the directories the file tools may access; empty means
+	// unrestricted.
+	AllowedDirs []string
+	// Roots describe AllowedDirs with what the agent may do in each.
+	Roots []Root
+	// Git summarizes the repository holding WorkingDir; nil outside one or
+	// without git.
+	Git *GitStatus
+	// Shell lists the restrictions of run_shell.
+	Shell tools.ShellPolicy
+}
+
+// Root is an allowed directory.
+type Root struct {
+	Path string
+	// Access is "read-write", or in overlay mode "read-write; writes are
+	// staged until the user applies them".
+	Access string
+	// Skills reports whether Path is a skills directory.
+	Skills bool
+}
+
+// GitStatus summarizes a git working tree.
+type GitStatus struct {
+	// Branch is the current branch, or "HEAD (detached)".
+	Branch string
+	// Upstream is the tracking branch with how far ahead or behind it is,
+	// e.g. "origin/main, ahead 1"; empty without one.
+	Upstream string
+	// Staged, Modified and Untracked count changed paths.
+	Staged    int
+	Modified  int
+	Untracked int
+}
+
+// Clean reports whether the working tree has no changes.
+func (g GitStatus) Clean() bool {
+	return g.Staged == 0 && g.Modified == 0 && g.Untracked == 0
+}
+
+// Summary describes the changes, e.g. "2 modified, 1 untracked", or "clean".
+func (g GitStatus) Summary() string {
+	var parts []string
+	for _, count := range []struct {
+		n     int
+		label string
+	}{{g.Staged, "staged"}, {g.Modified, "modified"}, {g.Untracked, "untracked"}} {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count.n, count.label))
+		}
+	}
+	if len(parts) == 0 {
+		return "clean"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CurrentEnvironment describes the running process under cfg.
+func CurrentEnvironment(cfg configpkg.Config) Environment {
+	wd, err := os.Getwd()
+	if err != nil {
+		wd = ""
+	}
+	env := Environment{
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Date:       time.Now().Format("2006-01-02"),
+		WorkingDir: wd,
+		Shell:      tools.RunShellPolicy(),
+	}
+	if cfg.AllowedDir != "" {
+		env.AllowedDirs = configpkg.ToolAllowedDirs(cfg)
+		access := "read-write"
+		if cfg.OverlayDir != "" {
+			access = "read-write; writes are staged until the user applies them"
+		}
+		for i, dir := range env.AllowedDirs {
+			if abs, err := filepath.Abs(dir); err == nil {
+				dir = abs
+			}
+			env.Roots = append(env.Roots, Root{Path: dir, Access: access, Skills: i > 0})
+		}
+	}
+	if wd != "" {
+		env.Git = gitStatus(wd)
+	}
+	return env
+}
+
+// gitStatus runs git status in dir, or returns nil when dir is not in a
+// repository or git is unavailable.
+func gitStatus(dir string) *GitStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain=v1", "--branch", "--untracked-files=normal")
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return parseGitStatus(string(out))
+}
+
+// parseGitStatus reads the output of git status --porcelain=v1 --branch.
+func parseGitStatus(out string) *GitStatus {
+	status := &GitStatus{}
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		if header, ok := strings.CutPrefix(line, "## "); ok {
+			status.Branch, status.Upstream = parseGitBranch(header)
+			continue
+		}
+		x, y := line[0], line[1]
+		switch {
+		case x == '?' && y == '?':
+			status.Untracked++
+		default:
+			if x != ' ' && x != '!' {
+				status.Staged++
+			}
+			if y != ' ' && y != '!' {
+				status.Modified++
+			}
+		}
+	}
+	return status
+}
+
+// parseGitBranch splits a porcelain branch header such as
+// "main...origin/main [ahead 1]" into the branch and upstream summary.
+func parseGitBranch(header string) (string, string) {
+	if rest, ok := strings.CutPrefix(header, "No commits yet on "); ok {
+		return rest, ""
+	}
+	if strings.HasPrefix(header, "HEAD (no branch)") {
+		return "HEAD (detached)", ""
+	}
+	branch, tracking, ok := strings.Cut(header, "...")
+	if !ok {
+		return branch, ""
+	}
+	upstream, counts, _ := strings.Cut(tracking, " [")
+	if counts = strings.TrimSuffix(counts, "]"); counts != "" {
+		upstream += ", " + counts
+	}
+	return branch, upstream
+}
@@ -0,0 +1,221 @@
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+)
+
+// DefaultInstructionsMaxBytes caps the instruction files included in the
+// system prompt.
+const DefaultInstructionsMaxBytes = configpkg.DefaultInstructionsMaxBytes
+
+// Instruction scopes.
+const (
+	ScopeUser    = "user"
+	ScopeProject = "project"
+)
+
+// Instruction is an instruction file, such as AGENTS.md, included in the
+// system prompt. Unlike skill descriptions, its content is followed as
+// instructions.
+type Instruction struct {
+	// Path is the absolute path of the file.
+	Path string
+	// Scope is ScopeUser for the user-level file and ScopeProject otherwise.
+	Scope string
+	// Content is the text included in the prompt, cut to fit the size cap.
+	Content string
+	// Size is the size of the file in bytes.
+	Size int
+	// Truncated reports whether Content is shorter than the file. Content is
+	// empty when nothing was left of the budget.
+	Truncated bool
+}
+
+// InstructionOptions controls LoadInstructions.
+type InstructionOptions struct {
+	// Names are the file names looked up in each directory, in order of
+	// preference; the first one found in a directory is used.
+	Names []string
+	// WorkingDir is where the walk starts.
+	WorkingDir string
+	// Root is the highest directory searched, normally the allowed dir. When
+	// it is empty or does not contain WorkingDir, the walk stops at the
+	// nearest git repository root, or at WorkingDir outside a repository.
+	Root string
+	// UserFile is the user-level instruction file; empty or missing is skipped.
+	UserFile string
+	// MaxBytes caps the content of all files together; <= 0 uses
+	// DefaultInstructionsMaxBytes.
+	MaxBytes int
+}
+
+// LoadInstructions returns the instruction files in precedence order: the user
+// file first, then project files from Root down to WorkingDir, so later files
+// take precedence. When the files exceed MaxBytes, the budget goes to the most
+// specific files first and the rest are truncated; no file is read past the
+// budget left for it.
+func LoadInstructions(opts InstructionOptions) ([]Instruction, error) {
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultInstructionsMaxBytes
+	}
+	var list []Instruction
+
+	if opts.UserFile != "" {
+		path, err := filepath.Abs(opts.UserFile)
+		if err != nil {
+			return nil, err
+		}
+		if isFile(path) {
+			list = append(list, Instruction{Path: path, Scope: ScopeUser})
+		}
+	}
+	if len(opts.Names) > 0 && opts.WorkingDir != "" {
+		dirs, err := instructionDirs(opts.WorkingDir, opts.Root)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			for _, name := range opts.Names {
+				path := filepath.Join(dir, name)
+				if !isFile(path) || (len(list) > 0 && list[0].Path == path) {
+					continue
+				}
+				list = append(list, Instruction{Path: path, Scope: ScopeProject})
+				break
+			}
+		}
+	}
+
+	budget := maxBytes
+	for i := len(list) - 1; i >= 0; i-- {
+		text, size, truncated, err := readInstructions(list[i].Path, budget)
+		if err != nil {
+			return nil, fmt.Errorf("read instructions: %w", err)
+		}
+		content := truncateUTF8(text, budget)
+		budget -= len(content)
+		list[i].Size = size
+		list[i].Content = escapeInstructions(strings.TrimSpace(content))
+		list[i].Truncated = truncated || len(content) < len(text)
+	}
+	return list, nil
+}
+
+// readInstructions reads at most budget bytes of path, plus enough to finish a
+// rune, so a huge file or a FIFO is never read past the cap. It returns the
+// trimmed text, the file size and whether the file was cut short.
+func readInstructions(path string, budget int) (text string, size int, truncated bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, false, err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return "", 0, false, err
+	}
+	limit := int64(max(budget, 0)) + utf8.UTFMax
+	data, err := io.ReadAll(io.LimitReader(f, limit))
+	if err != nil {
+		return "", 0, false, err
+	}
+	if int64(len(data)) == limit {
+		truncated = true
+		data = trimPartialRune(data)
+	}
+	text = strings.TrimSpace(strings.ToValidUTF8(string(data), "\uFFFD"))
+	return text, int(info.Size()), truncated, nil
+}
+
+// trimPartialRune drops an incomplete rune cut off at the end of data.
+func trimPartialRune(data []byte) []byte {
+	i := len(data) - 1
+	for i > 0 && !utf8.RuneStart(data[i]) {
+		i--
+	}
+	if i >= 0 && !utf8.FullRune(data[i:]) {
+		return data[:i]
+	}
+	return data
+}
+
+// instructionDirs lists the directories searched, from the highest down to
+// workingDir.
+func instructionDirs(workingDir, root string) ([]string, error) {
+	dir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return nil, err
+	}
+	top := ""
+	if root != "" {
+		if root, err = filepath.Abs(root); err != nil {
+			return nil, err
+		}
+		if rel, err := filepath.Rel(root, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			top = root
+		}
+	}
+	if top == "" {
+		top = gitRoot(dir)
+	}
+	dirs := []string{dir}
+	for dir != top {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+		dirs = append(dirs, dir)
+	}
+	for i, j := 0, len(dirs)-1; i < j; i, j = i+1, j-1 {
+		dirs[i], dirs[j] = dirs[j], dirs[i]
+	}
+	return dirs, nil
+}
+
+// gitRoot returns the nearest directory at or above dir holding .git, or dir
+// when there is none.
+func gitRoot(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// escapeInstructions keeps file content from closing its <instructions> block.
+func escapeInstructions(s string) string {
+	return strings.ReplaceAll(s, "</instructions", "&lt;/instructions")
+}
@@ -2,7 +2,11 @@
 package prompt
 
 import (
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
 	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/minhyannv/agent-skills-go/pkg/tools"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -25,12 +29,44 @@ func TestToPromptMarkdown(t *testing.T) {
 		"pdf",
 		"<description>",
 		"PDF tools",
-		"<location>",
-		"/tmp/pdf/SKILL.md",
-		"/tmp/docx/SKILL.md",
+		"load_skill",
 	}) {
 		t.Fatalf("markdown missing expected content:\n%s", md)
 	}
+	if strings.Contains(md, "<location>") || strings.Contains(md, "/tmp/") {
+		t.Fatalf("expected skills to be listed by name only:\n%s", md)
+	}
+}
+
+// TestToPromptMarkdownWithBudget verifies short descriptions are used when space is tight.
+func TestToPromptMarkdownWithBudget(t *testing.T) {
+	long := strings.TrimSpace(strings.Repeat("Detailed guidance for spreadsheets. ", 20))
+	list := []*skills.Skill{
+		{Name: "xlsx", Description: long, SkillFilePath: "/tmp/xlsx/SKILL.md", Metadata: map[string]any{"short-description": "Excel tools"}},
+		{Name: "docx", Description: long, SkillFilePath: "/tmp/docx/SKILL.md"},
+	}
+
+	full := ToPromptMarkdown(list)
+	if ToPromptMarkdownWithBudget(list, len(full)) != full {
+		t.Fatal("expected full listing when it fits the budget")
+	}
+
+	budget := len(full) - len(long) + len("Excel tools")
+	md := ToPromptMarkdownWithBudget(list, budget)
+	if !strings.Contains(md, "<description>\nExcel tools\n</description>") {
+		t.Fatalf("expected short description:\n%s", md)
+	}
+	if !strings.Contains(md, long) {
+		t.Fatalf("expected docx description to stay intact:\n%s", md)
+	}
+
+	md = ToPromptMarkdownWithBudget(list, budget-len(long)/2)
+	if len(md) > budget-len(long)/2 {
+		t.Fatalf("expected listing within budget, got %d bytes", len(md))
+	}
+	if !strings.Contains(md, "…") || !strings.Contains(md, "<name>\ndocx\n</name>") {
+		t.Fatalf("expected truncated description with name kept:\n%s", md)
+	}
 }
 
 // TestBuildSystemPrompt verifies system prompt composition.
@@ -47,9 +83,262 @@ func TestBuildSystemPrompt(t *testing.T) {
 		"Available Skills",
 		"Skill Selection Rules",
 		"xlsx",
+		"load_skill",
+		"read_skill_resource",
 	}) {
 		t.Fatalf("prompt missing expected content:\n%s", prompt)
 	}
+	if strings.Contains(prompt, "/tmp/xlsx") {
+		t.Fatalf("expected prompt not to reveal skill paths:\n%s", prompt)
+	}
+	if !strings.Contains(BuildSystemPrompt(nil), "Tools available: read_file, write_file, run_shell.") {
+		t.Fatal("expected skill tools to be omitted without skills")
+	}
+	if strings.Contains(prompt, "<trust>") {
+		t.Fatal("expected no trust tier when trust was not evaluated")
+	}
+
+	skills[0].Trust = "untrusted"
+	prompt = BuildSystemPrompt(skills)
+	if !containsAll(prompt, []string{"<trust>untrusted</trust>", "`run_shell` and `write_file` are blocked"}) {
+		t.Fatalf("expected untrusted tier and note:\n%s", prompt)
+	}
+}
+
+// TestBuildSystemPromptScripts verifies the script tool note appears only when
+// a listed skill declares scripts.
+func TestBuildSystemPromptScripts(t *testing.T) {
+	list := []*skills.Skill{{Name: "pdf", Description: "PDF tools"}}
+	if strings.Contains(BuildSystemPrompt(list), "`<skill>__<script>`") {
+		t.Fatal("expected no script tool note without scripts")
+	}
+	list[0].Scripts = []skills.Script{{Name: "convert", Path: "scripts/convert.py"}}
+	if !strings.Contains(BuildSystemPrompt(list), "`<skill>__<script>`") {
+		t.Fatal("expected a script tool note")
+	}
+}
+
+// TestBuildSystemPromptWithListing verifies shortlists point at search_skills and fit the budget.
+func TestBuildSystemPromptWithListing(t *testing.T) {
+	var list []*skills.Skill
+	for _, name := range []string{"pdf", "docx", "xlsx", "pptx"} {
+		list = append(list, &skills.Skill{Name: name, Description: "Create and edit " + name + " files"})
+	}
+	maxTokens := EstimateTokens(ToPromptMarkdown(list[:2]))
+
+	fitted := FitSkills(list, maxTokens)
+	if len(fitted) != 2 {
+		t.Fatalf("expected two skills to fit, got %d", len(fitted))
+	}
+	if len(ToPromptMarkdownWithBudget(fitted, maxTokens*4)) > maxTokens*4 {
+		t.Fatal("expected fitted listing within budget")
+	}
+	if len(FitSkills(list, 0)) != len(list) {
+		t.Fatal("expected no limit with a zero budget")
+	}
+
+	prompt := BuildSystemPromptWithListing(fitted, SkillListing{Total: len(list), MaxTokens: maxTokens})
+	if !containsAll(prompt, []string{"search_skills", "Showing 2 of 4 installed skills", "<available_skills>"}) {
+		t.Fatalf("shortlist prompt missing expected content:\n%s", prompt)
+	}
+	empty := BuildSystemPromptWithListing(nil, SkillListing{Total: len(list)})
+	if !strings.Contains(empty, "Showing 0 of 4 installed skills") || strings.Contains(empty, "<available_skills>") {
+		t.Fatalf("unexpected empty shortlist prompt:\n%s", empty)
+	}
+	if strings.Contains(BuildSystemPrompt(list), "Showing") {
+		t.Fatal("expected no shortlist note when every skill is listed")
+	}
+}
+
+// TestTemplateOverrides verifies section and whole-template overrides and the
+// template data.
+func TestTemplateOverrides(t *testing.T) {
+	list := []*skills.Skill{{Name: "pdf", Description: "PDF tools"}}
+	data := NewData(list, SkillListing{Total: len(list)})
+	if out, err := DefaultTemplate().Render(data); err != nil || out != BuildSystemPrompt(list) {
+		t.Fatalf("expected default render to match BuildSystemPrompt, err=%v", err)
+	}
+	if strings.Contains(BuildSystemPrompt(list), "<tool_result") {
+		t.Fatal("expected no tool result rule without an envelope")
+	}
+	if out, _ := DefaultTemplate().RenderSection("safety", Data{ToolEnvelope: true}); !strings.Contains(out, `<tool_result trust="untrusted">`) {
+		t.Fatalf("expected the tool result rule with an envelope:\n%s", out)
+	}
+
+	tmpl, err := ParseTemplate("", map[string]string{
+		"identity":    "You are the release bot for {{.Config.Model}}.",
+		"environment": "## Environment\n- OS: {{.Environment.OS}}\n- Key: [{{.Config.APIKey}}]",
+		"safety":      "",
+	})
+	if err != nil {
+		t.Fatalf("parse sections: %v", err)
+	}
+	data = data.WithConfig(configpkg.Config{Model: "gpt-test", APIKey: "secret"})
+	data.Environment = Environment{OS: "plan9"}
+	out, err := tmpl.Render(data)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !containsAll(out, []string{"You are the release bot for gpt-test.", "- OS: plan9", "- Key: []", "## Skill Selection Rules", "<name>\npdf\n</name>"}) {
+		t.Fatalf("unexpected section overrides:\n%s", out)
+	}
+	if strings.Contains(out, "reliable AI assistant") || strings.Contains(out, "## Safety") || strings.Contains(out, "\n\n\n") {
+		t.Fatalf("expected replaced sections without blank runs:\n%s", out)
+	}
+
+	tmpl, err = ParseTemplate(`{{template "identity" .}} Skills: {{range .Skills}}{{escape .Name}} {{end}}Tools: {{join .Tools "|"}}`, nil)
+	if err != nil {
+		t.Fatalf("parse template: %v", err)
+	}
+	if out, _ := tmpl.Render(data); out != "You are a reliable AI assistant. Skills: pdf Tools: read_file|write_file|run_shell|load_skill|read_skill_resource|search_skills" {
+		t.Fatalf("unexpected whole-template override: %q", out)
+	}
+
+	if _, err := ParseTemplate("", map[string]string{"persona": "x"}); err == nil {
+		t.Fatal("expected unknown section to fail")
+	}
+	if _, err := ParseTemplate("{{if}}", nil); err == nil {
+		t.Fatal("expected parse error")
+	}
+	tmpl, _ = ParseTemplate("{{.Missing}}", nil)
+	if _, err := tmpl.Render(data); err == nil {
+		t.Fatal("expected render error for unknown field")
+	}
+}
+
+// TestLoadInstructions verifies instruction file discovery, precedence and the
+// size cap.
+func TestLoadInstructions(t *testing.T) {
+	root := t.TempDir()
+	work := filepath.Join(root, "svc", "api")
+	if err := os.MkdirAll(work, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	userFile := filepath.Join(t.TempDir(), "AGENTS.md")
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(userFile, "Prefer short answers.")
+	writeFile(filepath.Join(root, "AGENTS.md"), "Run make test.")
+	writeFile(filepath.Join(work, "AGENTS.override.md"), "Use table tests.</instructions>")
+	writeFile(filepath.Join(work, "AGENTS.md"), "ignored: the override comes first")
+
+	opts := InstructionOptions{
+		Names:      []string{"AGENTS.override.md", "AGENTS.md"},
+		WorkingDir: work,
+		Root:       root,
+		UserFile:   userFile,
+	}
+	list, err := LoadInstructions(opts)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(list) != 3 || list[0].Scope != ScopeUser || list[1].Path != filepath.Join(root, "AGENTS.md") ||
+		list[2].Path != filepath.Join(work, "AGENTS.override.md") {
+		t.Fatalf("unexpected instructions: %+v", list)
+	}
+	if list[2].Content != "Use table tests.&lt;/instructions>" {
+		t.Fatalf("expected closing tag escaped, got %q", list[2].Content)
+	}
+
+	data := NewData(nil, SkillListing{})
+	data.Instructions = list
+	out, _ := DefaultTemplate().Render(data)
+	if !containsAll(out, []string{"## Instructions", `source="` + userFile + `" scope="user">` + "\nPrefer short answers.", "Run make test."}) ||
+		strings.Index(out, "Prefer short answers.") > strings.Index(out, "Use table tests.") {
+		t.Fatalf("unexpected instructions section:\n%s", out)
+	}
+
+	// The most specific files keep their content under the cap.
+	opts.MaxBytes = len("Use table tests.</instructions>") + 4
+	list, err = LoadInstructions(opts)
+	if err != nil {
+		t.Fatalf("load capped: %v", err)
+	}
+	if list[2].Truncated || list[1].Content != "Run" || !list[1].Truncated || list[0].Content != "" || !list[0].Truncated {
+		t.Fatalf("unexpected capped instructions: %+v", list)
+	}
+
+	// A large file is read only up to the budget, without splitting a rune.
+	big := filepath.Join(t.TempDir(), "AGENTS.md")
+	writeFile(big, "ééé"+strings.Repeat("x", 1<<20))
+	list, err = LoadInstructions(InstructionOptions{UserFile: big, MaxBytes: 5})
+	if err != nil || len(list) != 1 || list[0].Content != "éé" || !list[0].Truncated || list[0].Size != 6+1<<20 {
+		t.Fatalf("unexpected large instructions: %+v %v", list, err)
+	}
+
+	// Outside Root, the walk stops at WorkingDir when there is no git repository.
+	opts.Root = t.TempDir()
+	opts.UserFile = ""
+	if list, _ = LoadInstructions(opts); len(list) != 1 || list[0].Path != filepath.Join(work, "AGENTS.override.md") {
+		t.Fatalf("expected only the working dir file, got %+v", list)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ = LoadInstructions(opts); len(list) != 2 {
+		t.Fatalf("expected the walk to reach the git root, got %+v", list)
+	}
+}
+
+// TestEnvironmentSection verifies git status parsing and the environment
+// section of the default template.
+func TestEnvironmentSection(t *testing.T) {
+	status := parseGitStatus("## main...origin/main [ahead 1, behind 2]\nM  staged.go\n M edited.go\nMM both.go\n?? new.txt\n")
+	if status.Branch != "main" || status.Upstream != "origin/main, ahead 1, behind 2" ||
+		status.Staged != 2 || status.Modified != 2 || status.Untracked != 1 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if status.Summary() != "2 staged, 2 modified, 1 untracked" || (GitStatus{}).Summary() != "clean" {
+		t.Fatalf("unexpected summary: %q", status.Summary())
+	}
+	if branch, _ := parseGitBranch("No commits yet on dev"); branch != "dev" {
+		t.Fatalf("unexpected branch for an empty repository: %q", branch)
+	}
+
+	if out, _ := DefaultTemplate().RenderSection("environment", NewData(nil, SkillListing{})); out != "" {
+		t.Fatalf("expected no environment section without environment data, got:\n%s", out)
+	}
+	data := NewData(nil, SkillListing{})
+	data.Environment = Environment{
+		OS:         "linux",
+		Arch:       "arm64",
+		Date:       "2026-01-02",
+		WorkingDir: "/work",
+		Roots:      []Root{{Path: "/work", Access: "read-write"}, {Path: "/skills", Access: "read-write", Skills: true}},
+		Git:        status,
+		Shell:      tools.RunShellPolicy(),
+	}
+	section, err := DefaultTemplate().RenderSection("environment", data)
+	if err != nil {
+		t.Fatalf("render section: %v", err)
+	}
+	if !containsAll(section, []string{
+		"- Date: 2026-01-02",
+		"- Platform: linux/arm64",
+		"- Working directory: /work",
+		"  - /work (read-write)",
+		"  - /skills (read-write; skills directory)",
+		"- Git: branch main (tracking origin/main, ahead 1, behind 2); 2 staged, 2 modified, 1 untracked",
+		`"|", ">"`,
+		"refuses shells (bash, dash, fish, sh, zsh)",
+		" rm, ",
+	}) {
+		t.Fatalf("unexpected environment section:\n%s", section)
+	}
+	out, _ := DefaultTemplate().Render(data)
+	if !strings.Contains(out, section) {
+		t.Fatalf("expected the section in the prompt:\n%s", out)
+	}
+
+	data.Environment.Roots, data.Environment.Git = nil, nil
+	section, _ = DefaultTemplate().RenderSection("environment", data)
+	if !containsAll(section, []string{"- Allowed roots: unrestricted", "- Git: not a repository"}) {
+		t.Fatalf("unexpected unrestricted environment section:\n%s", section)
+	}
 }
 
 // containsAll reports whether all substrings exist in text.
@@ -0,0 +1,193 @@
+package prompt
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	configpkg "github.com/minhyannv/agent-skills-go/pkg/config"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+)
+
+// defaultTemplateText is the built-in system prompt template.
+//
+//go:embed default.tmpl
+var defaultTemplateText string
+
+// Sections lists the named templates of the default prompt, in prompt order.
+// Each can be replaced on its own with LoadTemplate.
+var Sections = []string{"identity", "tools", "skill_rules", "safety", "instructions", "environment", "skills"}
+
+// templateFuncs are available to prompt templates in addition to the
+// text/template builtins.
+var templateFuncs = template.FuncMap{
+	// join joins strings with a separator.
+	"join": strings.Join,
+	// escape makes a value safe to place inside the XML-ish skill listing.
+	"escape": sanitizeForPrompt,
+}
+
+// defaultTemplate is parsed once; Template values only ever execute clones.
+var defaultTemplate = template.Must(template.New("system").Funcs(templateFuncs).Parse(defaultTemplateText))
+
+// blankLines matches the runs of blank lines left by empty sections.
+var blankLines = regexp.MustCompile(`\n[ \t]*(?:\n[ \t]*){2,}`)
+
+// Template renders the system prompt from Data.
+type Template struct {
+	tmpl *template.Template
+}
+
+// DefaultTemplate returns the built-in prompt template.
+func DefaultTemplate() *Template {
+	return &Template{tmpl: defaultTemplate}
+}
+
+// Data is what prompt templates render.
+type Data struct {
+	// Skills are the skills listed in the prompt, which may be a shortlist.
+	Skills []*skills.Skill
+	// TotalSkills is the number of loaded skills.
+	TotalSkills int
+	// SkillListing is the rendered skills section, empty without skills.
+	SkillListing string
+	// HasScripts reports whether a listed skill exposes script tools.
+	HasScripts bool
+	// HasUntrusted reports whether a listed skill is untrusted.
+	HasUntrusted bool
+	// ToolEnvelope reports whether tool results from outside sources arrive
+	// wrapped in <tool_result trust="untrusted"> envelopes.
+	ToolEnvelope bool
+	// Instructions are the user and project instruction files, in
+	// precedence order.
+	Instructions []Instruction
+	// Tools are the names of the tools offered to the model.
+	Tools       []string
+	Environment Environment
+	// Config is the agent configuration without secrets: the API key and
+	// MCP server environments and headers are removed.
+	Config configpkg.Config
+}
+
+// NewData returns the data for a prompt listing list. Tools defaults to the
+// built-in tools; callers with a tool registry replace it.
+func NewData(list []*skills.Skill, listing SkillListing) Data {
+	total := max(listing.Total, len(list))
+	tools := []string{"read_file", "write_file", "run_shell"}
+	if total > 0 {
+		tools = append(tools, "load_skill", "read_skill_resource", "search_skills")
+	}
+	return Data{
+		Skills:       list,
+		TotalSkills:  total,
+		SkillListing: skillListing(list, total, listing.MaxTokens),
+		HasScripts:   hasScripts(list),
+		HasUntrusted: hasUntrusted(list),
+		Tools:        tools,
+	}
+}
+
+// WithConfig sets Config to a copy of cfg without secrets and returns d.
+func (d Data) WithConfig(cfg configpkg.Config) Data {
+	cfg.APIKey = ""
+	servers := make([]configpkg.MCPServerConfig, len(cfg.MCPServers))
+	for i, server := range cfg.MCPServers {
+		server.Env, server.Headers = nil, nil
+		servers[i] = server
+	}
+	cfg.MCPServers = servers
+	d.Config = cfg
+	return d
+}
+
+// LoadTemplate returns the default template with overrides read from files.
+// file, when set, replaces the whole template; it can place the default
+// sections with {{template "identity" .}} and the like, and redefine them
+// with {{define}}. A file holding only definitions keeps the default layout.
+// sections maps section names from Sections to files whose content replaces
+// that section, applied after file.
+func LoadTemplate(file string, sections map[string]string) (*Template, error) {
+	text := ""
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read prompt template: %w", err)
+		}
+		text = string(data)
+	}
+	texts := make(map[string]string, len(sections))
+	for name, path := range sections {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read prompt section %s: %w", name, err)
+		}
+		texts[name] = string(data)
+	}
+	return ParseTemplate(text, texts)
+}
+
+// ParseTemplate is LoadTemplate with the template and section texts given
+// directly. Empty text keeps the default layout.
+func ParseTemplate(text string, sections map[string]string) (*Template, error) {
+	tmpl, err := defaultTemplate.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if text != "" {
+		if tmpl, err = tmpl.Parse(text); err != nil {
+			return nil, fmt.Errorf("parse prompt template: %w", err)
+		}
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !knownSection(name) {
+			return nil, fmt.Errorf("unknown prompt section %q (use %s)", name, strings.Join(Sections, ", "))
+		}
+		body := strings.TrimSpace(sections[name])
+		if body == "" {
+			// text/template keeps a definition when the new one is empty.
+			body = `{{""}}`
+		}
+		if _, err := tmpl.New(name).Parse(body); err != nil {
+			return nil, fmt.Errorf("parse prompt section %s: %w", name, err)
+		}
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+func knownSection(name string) bool {
+	for _, known := range Sections {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderSection executes one named section, such as "environment", with the
+// same cleanup as Render.
+func (t *Template) RenderSection(name string, data Data) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.ExecuteTemplate(&sb, name, data); err != nil {
+		return "", fmt.Errorf("render prompt section %s: %w", name, err)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(sb.String(), "\n\n")), nil
+}
+
+// Render executes the template. Runs of blank lines are collapsed and the
+// result is trimmed.
+func (t *Template) Render(data Data) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.ExecuteTemplate(&sb, "system", data); err != nil {
+		return "", fmt.Errorf("render system prompt: %w", err)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(sb.String(), "\n\n")), nil
+}
@@ -0,0 +1,111 @@
+package skills
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// Severity ranks a diagnostic.
+type Severity string
+
+const (
+	// SeverityError means the skill was not loaded.
+	SeverityError Severity = "error"
+	// SeverityWarning means the skill was loaded, renamed or skipped in favour of another.
+	SeverityWarning Severity = "warning"
+)
+
+// DiagnosticKind classifies what went wrong.
+type DiagnosticKind string
+
+const (
+	// KindRead covers unreadable files and directories.
+	KindRead DiagnosticKind = "read"
+	// KindFrontMatter covers malformed or mistyped SKILL.md front matter.
+	KindFrontMatter DiagnosticKind = "front_matter"
+	// KindAgentMetadata covers problems in agents/openai.yaml.
+	KindAgentMetadata DiagnosticKind = "agent_metadata"
+	// KindDuplicate reports two skills with the same name.
+	KindDuplicate DiagnosticKind = "duplicate"
+)
+
+// Diagnostic describes one problem found while loading skills.
+type Diagnostic struct {
+	Path string `json:"path"`
+	// Line is 1-based; 0 when unknown.
+	Line     int            `json:"line,omitempty"`
+	Kind     DiagnosticKind `json:"kind"`
+	Severity Severity       `json:"severity"`
+	Message  string         `json:"message"`
+}
+
+// String formats the diagnostic as path:line: severity: message.
+func (d Diagnostic) String() string {
+	location := d.Path
+	if d.Line > 0 {
+		location += ":" + strconv.Itoa(d.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, d.Severity, d.Message)
+}
+
+// HasErrors reports whether any diagnostic has error severity.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// fileError is a parse failure with its kind and, when known, its line.
+type fileError struct {
+	Kind DiagnosticKind
+	Line int
+	Err  error
+}
+
+func (e *fileError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *fileError) Unwrap() error {
+	return e.Err
+}
+
+// yamlLinePattern extracts the line number from yaml.v3 error messages.
+var yamlLinePattern = regexp.MustCompile(`line (\d+): `)
+
+// yamlError wraps a yaml.v3 error, shifting its line by offset lines.
+func yamlError(kind DiagnosticKind, err error, offset int) error {
+	line := 0
+	msg := err.Error()
+	if m := yamlLinePattern.FindStringSubmatchIndex(msg); m != nil {
+		line, _ = strconv.Atoi(msg[m[2]:m[3]])
+		line += offset
+		msg = msg[:m[0]] + msg[m[1]:]
+	}
+	return &fileError{Kind: kind, Line: line, Err: errors.New(msg)}
+}
+
+// diagnosticFor converts a parse error of the SKILL.md at path into an error
+// diagnostic. Problems in agents/openai.yaml point at that file instead.
+func diagnosticFor(path string, err error) Diagnostic {
+	d := Diagnostic{Path: path, Kind: KindRead, Severity: SeverityError, Message: err.Error()}
+	var fe *fileError
+	if errors.As(err, &fe) {
+		d.Kind = fe.Kind
+		d.Line = fe.Line
+		d.Message = fe.Err.Error()
+		if fe.Kind == KindAgentMetadata {
+			d.Path = filepath.Join(filepath.Dir(path), agentMetadataFile)
+		}
+	}
+	return d
+}
@@ -0,0 +1,240 @@
+package skills
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Known SKILL.md front matter keys.
+const (
+	fieldName          = "name"
+	fieldDescription   = "description"
+	fieldLicense       = "license"
+	fieldVersion       = "version"
+	fieldAllowedTools  = "allowed-tools"
+	fieldCompatibility = "compatibility"
+	fieldMetadata      = "metadata"
+)
+
+// shortDescriptionKey is the metadata key holding a one-line summary.
+const shortDescriptionKey = "short-description"
+
+// skillFrontMatter is the typed view of the YAML front matter in SKILL.md.
+type skillFrontMatter struct {
+	Name          string
+	Description   string
+	License       string
+	Version       string
+	AllowedTools  []string
+	Compatibility string
+	Metadata      map[string]any
+	Scripts       []Script
+	// Extra holds keys without a dedicated field.
+	Extra map[string]any
+	// Lines maps each top-level key to its SKILL.md line.
+	Lines map[string]int
+	// BodyLine is the SKILL.md line where the body starts.
+	BodyLine int
+}
+
+// frontMatterLine is the file line of the first front matter line (after "---").
+const frontMatterLine = 2
+
+// parseFrontMatter splits SKILL.md content into typed front matter and the markdown body.
+func parseFrontMatter(content []byte) (skillFrontMatter, string, error) {
+	text := strings.TrimPrefix(string(content), "\ufeff")
+	lines := strings.Split(text, "\n")
+	if len(lines) < 3 || strings.TrimSpace(lines[0]) != "---" {
+		return skillFrontMatter{}, "", &fileError{Kind: KindFrontMatter, Line: 1, Err: errors.New("missing YAML front matter")}
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return skillFrontMatter{}, "", &fileError{Kind: KindFrontMatter, Line: 1, Err: errors.New("unterminated YAML front matter")}
+	}
+
+	fmText := strings.Join(lines[1:end], "\n")
+	rest := strings.Join(lines[end+1:], "\n")
+	body := strings.TrimLeft(rest, "\r\n")
+	bodyLine := end + 2 + strings.Count(rest[:len(rest)-len(body)], "\n")
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(fmText), &doc); err != nil {
+		return skillFrontMatter{}, "", yamlError(KindFrontMatter, err, frontMatterLine-1)
+	}
+	fm, err := decodeFrontMatter(&doc)
+	if err != nil {
+		return skillFrontMatter{}, "", err
+	}
+	fm.BodyLine = bodyLine
+	return fm, body, nil
+}
+
+// decodeFrontMatter checks the types of known keys and collects the rest into Extra.
+func decodeFrontMatter(doc *yaml.Node) (skillFrontMatter, error) {
+	fm := skillFrontMatter{Lines: map[string]int{}}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return fm, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fm, fieldError(root, "front matter must be a mapping")
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		fm.Lines[key.Value] = key.Line + frontMatterLine - 1
+		var err error
+		switch key.Value {
+		case fieldName:
+			fm.Name, err = stringField(key.Value, value)
+		case fieldDescription:
+			fm.Description, err = stringField(key.Value, value)
+		case fieldLicense:
+			fm.License, err = stringField(key.Value, value)
+		case fieldCompatibility:
+			fm.Compatibility, err = stringField(key.Value, value)
+		case fieldVersion:
+			fm.Version, err = versionField(value)
+		case fieldAllowedTools:
+			fm.AllowedTools, err = allowedToolsField(value)
+		case fieldMetadata:
+			fm.Metadata, err = metadataField(value)
+		case fieldScripts:
+			fm.Scripts, err = scriptsField(value)
+		default:
+			var raw any
+			if err = value.Decode(&raw); err == nil {
+				if fm.Extra == nil {
+					fm.Extra = map[string]any{}
+				}
+				fm.Extra[key.Value] = raw
+			}
+		}
+		if err != nil {
+			return skillFrontMatter{}, err
+		}
+	}
+	return fm, nil
+}
+
+// fieldError reports a front matter problem at the SKILL.md line of node.
+func fieldError(node *yaml.Node, format string, args ...any) error {
+	return &fileError{Kind: KindFrontMatter, Line: node.Line + frontMatterLine - 1, Err: fmt.Errorf(format, args...)}
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+func stringField(name string, node *yaml.Node) (string, error) {
+	if isNull(node) {
+		return "", nil
+	}
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+		return "", fieldError(node, "%s must be a string", name)
+	}
+	return strings.TrimSpace(node.Value), nil
+}
+
+// versionField accepts strings and bare numbers such as 1.0.
+func versionField(node *yaml.Node) (string, error) {
+	if isNull(node) {
+		return "", nil
+	}
+	if node.Kind != yaml.ScalarNode {
+		return "", fieldError(node, "version must be a string")
+	}
+	switch node.Tag {
+	case "!!str", "!!int", "!!float":
+		return strings.TrimSpace(node.Value), nil
+	}
+	return "", fieldError(node, "version must be a string")
+}
+
+// allowedToolsField accepts a YAML list or a space- or comma-separated string.
+// Separators inside parentheses are kept, so "run_shell(git *)" is one entry.
+func allowedToolsField(node *yaml.Node) ([]string, error) {
+	if isNull(node) {
+		return nil, nil
+	}
+	var out []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" {
+			return nil, fieldError(node, "allowed-tools must be a list or string")
+		}
+		out = splitAllowedTools(node.Value)
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode || item.Tag != "!!str" {
+				return nil, fieldError(item, "allowed-tools entries must be strings")
+			}
+			if tool := strings.TrimSpace(item.Value); tool != "" {
+				out = append(out, tool)
+			}
+		}
+	default:
+		return nil, fieldError(node, "allowed-tools must be a list or string")
+	}
+	return out, nil
+}
+
+// splitAllowedTools splits on commas and whitespace outside parentheses.
+func splitAllowedTools(value string) []string {
+	var out []string
+	var current strings.Builder
+	depth := 0
+	flush := func() {
+		if tool := strings.TrimSpace(current.String()); tool != "" {
+			out = append(out, tool)
+		}
+		current.Reset()
+	}
+	for _, r := range value {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			depth = max(depth-1, 0)
+		case depth == 0 && (r == ',' || unicode.IsSpace(r)):
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return out
+}
+
+// metadataField decodes the metadata mapping; short-description must be a string.
+func metadataField(node *yaml.Node) (map[string]any, error) {
+	if isNull(node) {
+		return nil, nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fieldError(node, "metadata must be a mapping")
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == shortDescriptionKey {
+			if _, err := stringField("metadata."+shortDescriptionKey, node.Content[i+1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var out map[string]any
+	if err := node.Decode(&out); err != nil {
+		return nil, fieldError(node, "metadata: %v", err)
+	}
+	return out, nil
+}
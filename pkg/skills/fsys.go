@@ -0,0 +1,204 @@
+package skills
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// URIScheme prefixes the virtual paths of skill files: skill://<name>/<path>.
+// Skills loaded with LoadFromFS have no OS path and use it for SkillFilePath.
+const URIScheme = "skill://"
+
+// FSRoot is a directory in an fs.FS to load skills from with Load.
+type FSRoot struct {
+	FS   fs.FS
+	Root string
+	// System marks the root as bundled with the application; ApplyTrust
+	// gives its skills system trust.
+	System bool
+}
+
+// label names the root in diagnostics and DuplicateNamespace names.
+func (r FSRoot) label() string {
+	if base := path.Base(path.Clean(r.Root)); base != "." && base != "/" {
+		return base
+	}
+	return "fs"
+}
+
+// LoadFromFS loads and parses all SKILL.md files under root in fsys, such as an
+// embed.FS, a *zip.Reader or a fstest.MapFS. The skills' SkillFilePath and Dir
+// are skill:// URIs, and their files are read through fsys. Two skills with the
+// same name are an error because their URIs would collide. As in LoadFromDirs,
+// a skill with injection findings is returned quarantined.
+func LoadFromFS(fsys fs.FS, root string) ([]*Skill, error) {
+	skills, _, err := scanFS(fsys, root, false, quarantinePolicy)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]string{}
+	for _, skill := range skills {
+		if prev, ok := seen[skill.Name]; ok {
+			return nil, fmt.Errorf("duplicate skill %q in %s and %s", skill.Name, prev, skill.fsDir)
+		}
+		seen[skill.Name] = skill.fsDir
+	}
+	sort.Slice(skills, func(i, j int) bool {
+		return strings.ToLower(skills[i].Name) < strings.ToLower(skills[j].Name)
+	})
+	return skills, nil
+}
+
+// scanFS is scanDir for an fs.FS.
+func scanFS(fsys fs.FS, root string, lenient bool, scan ScanPolicy) ([]*Skill, []Diagnostic, error) {
+	if root == "" {
+		root = "."
+	}
+	var skills []*Skill
+	var diags []Diagnostic
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if !lenient {
+				return err
+			}
+			diags = append(diags, Diagnostic{Path: p, Kind: KindRead, Severity: SeverityError, Message: err.Error()})
+			if d != nil && d.IsDir() && p != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(d.Name(), "SKILL.md") {
+			return nil
+		}
+		skill, err := parseSkillFS(fsys, p)
+		if err != nil {
+			if !lenient {
+				return fmt.Errorf("parse %s: %w", p, err)
+			}
+			diags = append(diags, diagnosticFor(p, err))
+			return nil
+		}
+		keep, scanDiags := applyScan(skill, scan)
+		diags = append(diags, scanDiags...)
+		if keep {
+			skills = append(skills, skill)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return skills, diags, nil
+}
+
+// parseSkillFS parses the SKILL.md at p in fsys.
+func parseSkillFS(fsys fs.FS, p string) (*Skill, error) {
+	content, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return nil, &fileError{Kind: KindRead, Err: err}
+	}
+	dir := path.Dir(p)
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return nil, &fileError{Kind: KindRead, Err: err}
+	}
+	skill, err := parseSkill(content, func() (Interface, []Dependency, error) {
+		return loadAgentMetadataFS(sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	uri := URIScheme + skill.Name
+	skill.SkillFilePath = uri + "/" + path.Base(p)
+	skill.fsys = sub
+	skill.fsDir = dir
+	if skill.Interface.IconSmall != "" {
+		skill.Interface.IconSmall = uri + "/" + skill.Interface.IconSmall
+	}
+	if skill.Interface.IconLarge != "" {
+		skill.Interface.IconLarge = uri + "/" + skill.Interface.IconLarge
+	}
+	return skill, nil
+}
+
+// Virtual reports whether the skill was loaded with LoadFromFS and has no
+// directory on disk.
+func (s *Skill) Virtual() bool {
+	return s.fsys != nil
+}
+
+// FS returns the skill's files, rooted at the skill directory.
+func (s *Skill) FS() fs.FS {
+	if s.fsys != nil {
+		return s.fsys
+	}
+	return os.DirFS(s.Dir())
+}
+
+// OpenResource opens a file inside the skill directory. rel must be relative;
+// paths that leave the directory are rejected like in ResolveResource.
+func (s *Skill) OpenResource(rel string) (fs.File, error) {
+	if s.fsys == nil {
+		resolved, err := s.ResolveResource(rel)
+		if err != nil {
+			return nil, err
+		}
+		return os.Open(resolved)
+	}
+	clean, err := cleanResourcePath(rel)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.fsys.Open(clean)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, rel)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		_ = f.Close()
+		return nil, fmt.Errorf("resource path %q is not a regular file", rel)
+	}
+	return f, nil
+}
+
+// ParseURI splits a skill://<name>/<path> URI into the skill name and the
+// slash-separated path inside the skill directory.
+func ParseURI(uri string) (name, rel string, ok bool) {
+	rest, ok := strings.CutPrefix(uri, URIScheme)
+	if !ok {
+		return "", "", false
+	}
+	name, rel, _ = strings.Cut(rest, "/")
+	if name == "" {
+		return "", "", false
+	}
+	return name, rel, true
+}
+
+// cleanResourcePath validates rel for fs.FS access and returns it cleaned.
+func cleanResourcePath(rel string) (string, error) {
+	rel = strings.TrimSpace(rel)
+	if rel == "" {
+		return "", errors.New("resource path is required")
+	}
+	if path.IsAbs(rel) || strings.Contains(rel, "\\") {
+		return "", fmt.Errorf("resource path %q must be relative to the skill directory", rel)
+	}
+	clean := path.Clean(rel)
+	if !fs.ValidPath(clean) || clean == "." {
+		return "", fmt.Errorf("resource path %q is outside the skill directory", rel)
+	}
+	return clean, nil
+}
@@ -0,0 +1,186 @@
+package skills
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// agentMetadataFile is the per-skill harness config, relative to the skill dir.
+var agentMetadataFile = filepath.Join("agents", "openai.yaml")
+
+// agentMetadataPath is agentMetadataFile as an fs.FS path.
+const agentMetadataPath = "agents/openai.yaml"
+
+// DependencyTypeMCP marks a dependency on an MCP server.
+const DependencyTypeMCP = "mcp"
+
+// iconExtensions lists the accepted icon file types.
+var iconExtensions = map[string]bool{".png": true, ".svg": true, ".jpg": true, ".jpeg": true, ".webp": true}
+
+// brandColorPattern matches #RGB and #RRGGBB hex colors.
+var brandColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Interface holds the user-facing presentation from agents/openai.yaml.
+type Interface struct {
+	DisplayName      string
+	ShortDescription string
+	// IconSmall and IconLarge are absolute paths inside the skill dir.
+	IconSmall     string
+	IconLarge     string
+	BrandColor    string
+	DefaultPrompt string
+}
+
+// Dependency is one entry of dependencies.tools in agents/openai.yaml.
+type Dependency struct {
+	Type        string
+	Value       string
+	Description string
+	Transport   string
+	URL         string
+}
+
+// agentMetadata mirrors agents/openai.yaml.
+type agentMetadata struct {
+	Interface struct {
+		DisplayName      string `yaml:"display_name"`
+		ShortDescription string `yaml:"short_description"`
+		IconSmall        string `yaml:"icon_small"`
+		IconLarge        string `yaml:"icon_large"`
+		BrandColor       string `yaml:"brand_color"`
+		DefaultPrompt    string `yaml:"default_prompt"`
+	} `yaml:"interface"`
+	Dependencies struct {
+		Tools []struct {
+			Type        string `yaml:"type"`
+			Value       string `yaml:"value"`
+			Description string `yaml:"description"`
+			Transport   string `yaml:"transport"`
+			URL         string `yaml:"url"`
+		} `yaml:"tools"`
+	} `yaml:"dependencies"`
+}
+
+// loadAgentMetadata parses agents/openai.yaml in skillDir. A missing file yields
+// zero values. Icons are absolute paths.
+func loadAgentMetadata(skillDir string) (Interface, []Dependency, error) {
+	iface, deps, err := loadAgentMetadataFS(os.DirFS(skillDir))
+	if err != nil {
+		return Interface{}, nil, err
+	}
+	absDir, err := filepath.Abs(skillDir)
+	if err != nil {
+		return Interface{}, nil, err
+	}
+	for _, icon := range []*string{&iface.IconSmall, &iface.IconLarge} {
+		if *icon != "" {
+			*icon = filepath.Join(absDir, filepath.FromSlash(*icon))
+		}
+	}
+	return iface, deps, nil
+}
+
+// loadAgentMetadataFS parses agents/openai.yaml in files, which is rooted at
+// the skill directory. Icons are slash paths relative to it.
+func loadAgentMetadataFS(files fs.FS) (Interface, []Dependency, error) {
+	content, err := fs.ReadFile(files, agentMetadataPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Interface{}, nil, nil
+		}
+		return Interface{}, nil, err
+	}
+	var meta agentMetadata
+	if err := yaml.Unmarshal(content, &meta); err != nil {
+		return Interface{}, nil, yamlError(KindAgentMetadata, fmt.Errorf("parse %s: %w", agentMetadataFile, err), 0)
+	}
+
+	in := meta.Interface
+	iface := Interface{
+		DisplayName:      strings.TrimSpace(in.DisplayName),
+		ShortDescription: strings.TrimSpace(in.ShortDescription),
+		BrandColor:       strings.TrimSpace(in.BrandColor),
+		DefaultPrompt:    strings.TrimSpace(in.DefaultPrompt),
+	}
+	if iface.BrandColor != "" && !brandColorPattern.MatchString(iface.BrandColor) {
+		return Interface{}, nil, fmt.Errorf("%s: brand_color %q is not a hex color", agentMetadataFile, iface.BrandColor)
+	}
+	if iface.IconSmall, err = resolveIcon(files, "icon_small", in.IconSmall); err != nil {
+		return Interface{}, nil, err
+	}
+	if iface.IconLarge, err = resolveIcon(files, "icon_large", in.IconLarge); err != nil {
+		return Interface{}, nil, err
+	}
+
+	var deps []Dependency
+	for i, tool := range meta.Dependencies.Tools {
+		dep := Dependency{
+			Type:        strings.TrimSpace(tool.Type),
+			Value:       strings.TrimSpace(tool.Value),
+			Description: strings.TrimSpace(tool.Description),
+			Transport:   strings.TrimSpace(tool.Transport),
+			URL:         strings.TrimSpace(tool.URL),
+		}
+		if dep.Type == "" || dep.Value == "" {
+			return Interface{}, nil, fmt.Errorf("%s: dependencies.tools[%d] requires type and value", agentMetadataFile, i)
+		}
+		deps = append(deps, dep)
+	}
+	return iface, deps, nil
+}
+
+// agentMetadataError tags errors from loadAgentMetadata that carry no kind yet.
+func agentMetadataError(err error) error {
+	var fe *fileError
+	if errors.As(err, &fe) {
+		return err
+	}
+	return &fileError{Kind: KindAgentMetadata, Err: err}
+}
+
+// resolveIcon returns the cleaned slash path of an icon given relative to the
+// skill dir. The icon must stay inside the skill dir, exist, and be an image file.
+func resolveIcon(files fs.FS, field, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if filepath.IsAbs(value) || path.IsAbs(value) {
+		return "", fmt.Errorf("%s: %s must be relative to the skill dir", agentMetadataFile, field)
+	}
+	clean := path.Clean(filepath.ToSlash(value))
+	if !fs.ValidPath(clean) {
+		return "", fmt.Errorf("%s: %s escapes the skill dir", agentMetadataFile, field)
+	}
+	if !iconExtensions[strings.ToLower(path.Ext(clean))] {
+		return "", fmt.Errorf("%s: %s must be a .png, .svg, .jpg, .jpeg or .webp file", agentMetadataFile, field)
+	}
+	info, err := fs.Stat(files, clean)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s: %w", agentMetadataFile, field, err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s: %s is not a regular file", agentMetadataFile, field)
+	}
+	return clean, nil
+}
+
+// MissingDependencies returns the dependencies that available reports as unmet.
+// Dependencies of types the caller cannot check should be reported as available.
+func (s *Skill) MissingDependencies(available func(Dependency) bool) []Dependency {
+	var missing []Dependency
+	for _, dep := range s.Dependencies {
+		if !available(dep) {
+			missing = append(missing, dep)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,164 @@
+package skills
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DuplicatePolicy decides what happens when two skills share a name.
+type DuplicatePolicy string
+
+const (
+	// DuplicateFirst keeps the skill from the earliest directory and skips the rest.
+	DuplicateFirst DuplicatePolicy = "first"
+	// DuplicateError fails loading.
+	DuplicateError DuplicatePolicy = "error"
+	// DuplicateNamespace keeps every copy, renamed to <dir>:<name> where dir is the
+	// base name of the skills directory it was found in.
+	DuplicateNamespace DuplicatePolicy = "namespace"
+)
+
+// ParseDuplicatePolicy validates a policy name; empty means DuplicateFirst.
+func ParseDuplicatePolicy(value string) (DuplicatePolicy, error) {
+	switch policy := DuplicatePolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return DuplicateFirst, nil
+	case DuplicateFirst, DuplicateError, DuplicateNamespace:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown duplicate policy %q (want first, error or namespace)", value)
+	}
+}
+
+// LoadOptions tunes Load.
+type LoadOptions struct {
+	// Lenient skips skills that fail to parse and reports them as diagnostics
+	// instead of failing the whole load.
+	Lenient bool
+	// Duplicates is the policy for repeated skill names; empty means DuplicateFirst.
+	Duplicates DuplicatePolicy
+	// FS lists further roots, searched after dirs, whose skills are loaded as
+	// with LoadFromFS.
+	FS []FSRoot
+	// Scan is applied to every SKILL.md as it is parsed, before duplicates are
+	// resolved, so a blocked skill does not shadow another. The zero value warns.
+	Scan ScanPolicy
+}
+
+// Load parses all SKILL.md files under dirs and opts.FS and resolves duplicate
+// names. Directories are searched in order, so earlier directories take precedence.
+func Load(dirs []string, opts LoadOptions) ([]*Skill, []Diagnostic, error) {
+	policy, err := ParseDuplicatePolicy(string(opts.Duplicates))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var found []sourcedSkill
+	var diags []Diagnostic
+	for _, dir := range dirs {
+		if strings.TrimSpace(dir) == "" {
+			continue
+		}
+		skills, dirDiags, err := scanDir(dir, opts.Lenient, opts.Scan)
+		if err != nil {
+			return nil, nil, err
+		}
+		diags = append(diags, dirDiags...)
+		for _, skill := range skills {
+			found = append(found, sourcedSkill{skill: skill, dir: dir})
+		}
+	}
+	for _, root := range opts.FS {
+		skills, rootDiags, err := scanFS(root.FS, root.Root, opts.Lenient, opts.Scan)
+		if err != nil {
+			return nil, nil, err
+		}
+		diags = append(diags, rootDiags...)
+		for _, skill := range skills {
+			skill.system = root.System
+			found = append(found, sourcedSkill{skill: skill, dir: root.label()})
+		}
+	}
+
+	skills, dupDiags, err := resolveDuplicates(found, policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	diags = append(diags, dupDiags...)
+
+	sort.Slice(skills, func(i, j int) bool {
+		left := strings.ToLower(skills[i].Name)
+		right := strings.ToLower(skills[j].Name)
+		if left == right {
+			return strings.ToLower(skills[i].SkillFilePath) < strings.ToLower(skills[j].SkillFilePath)
+		}
+		return left < right
+	})
+	return skills, diags, nil
+}
+
+// sourcedSkill remembers which skills directory a skill came from.
+type sourcedSkill struct {
+	skill *Skill
+	dir   string
+}
+
+// resolveDuplicates applies policy to skills in discovery order.
+func resolveDuplicates(found []sourcedSkill, policy DuplicatePolicy) ([]*Skill, []Diagnostic, error) {
+	byName := map[string][]sourcedSkill{}
+	for _, entry := range found {
+		key := strings.ToLower(entry.skill.Name)
+		byName[key] = append(byName[key], entry)
+	}
+
+	var diags []Diagnostic
+	taken := map[string]bool{}
+	out := make([]*Skill, 0, len(found))
+	for _, entry := range found {
+		key := strings.ToLower(entry.skill.Name)
+		group := byName[key]
+		if len(group) == 1 {
+			out = append(out, entry.skill)
+			taken[key] = true
+			continue
+		}
+		first := group[0].skill
+
+		switch policy {
+		case DuplicateError:
+			return nil, nil, fmt.Errorf("duplicate skill %q in %s and %s", first.Name, first.SkillFilePath, group[1].skill.SkillFilePath)
+		case DuplicateNamespace:
+			name := filepath.Base(filepath.Clean(entry.dir)) + ":" + entry.skill.Name
+			if taken[strings.ToLower(name)] {
+				diags = append(diags, duplicateDiagnostic(entry.skill, fmt.Sprintf("skill %q already exists; skipped", name)))
+				continue
+			}
+			diags = append(diags, duplicateDiagnostic(entry.skill, fmt.Sprintf("duplicate skill %q renamed to %q", entry.skill.Name, name)))
+			entry.skill.rename(name)
+			taken[strings.ToLower(name)] = true
+			out = append(out, entry.skill)
+		default:
+			if entry.skill == first {
+				out = append(out, entry.skill)
+				taken[key] = true
+				continue
+			}
+			diags = append(diags, duplicateDiagnostic(entry.skill, fmt.Sprintf("duplicate skill %q skipped; using %s", entry.skill.Name, first.SkillFilePath)))
+		}
+	}
+	return out, diags, nil
+}
+
+// rename changes the skill name, keeping the skill:// path of a virtual skill in step.
+func (s *Skill) rename(name string) {
+	if s.fsys != nil {
+		s.SkillFilePath = URIScheme + name + strings.TrimPrefix(s.SkillFilePath, s.Dir())
+	}
+	s.Name = name
+}
+
+func duplicateDiagnostic(skill *Skill, message string) Diagnostic {
+	return Diagnostic{Path: skill.SkillFilePath, Kind: KindDuplicate, Severity: SeverityWarning, Message: message}
+}
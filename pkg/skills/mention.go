@@ -0,0 +1,37 @@
+package skills
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mentionPattern matches $name at the start of text or after a character that
+// cannot be part of a word, so prices such as US$5 are not mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w$])\$([A-Za-z0-9][A-Za-z0-9_.:-]*)`)
+
+// Mentions returns the skills of list mentioned as $name in text, in order of
+// first mention. Trailing punctuation is not part of a name, so "$pdf." and
+// "$pdf," both mention pdf. Unknown names are ignored.
+func Mentions(text string, list []*Skill) []*Skill {
+	byName := make(map[string]*Skill, len(list))
+	for _, skill := range list {
+		if _, exists := byName[skill.Name]; !exists {
+			byName[skill.Name] = skill
+		}
+	}
+	var mentioned []*Skill
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		name := match[1]
+		skill := byName[name]
+		for skill == nil && strings.ContainsAny(name[len(name)-1:], ".:-") {
+			name = name[:len(name)-1]
+			skill = byName[name]
+		}
+		if skill != nil && !seen[skill.Name] {
+			seen[skill.Name] = true
+			mentioned = append(mentioned, skill)
+		}
+	}
+	return mentioned
+}
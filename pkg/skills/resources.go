@@ -0,0 +1,209 @@
+package skills
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrResourceNotFound is returned by ResolveResource for missing files.
+var ErrResourceNotFound = errors.New("skill resource not found")
+
+// Resource is a file bundled with a skill.
+type Resource struct {
+	// Path is slash-separated and relative to the skill directory.
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// Link is a relative markdown link found in the skill body.
+type Link struct {
+	// Target is the link target as written.
+	Target string `json:"target"`
+	// Path is the target resolved against the skill directory, slash-separated.
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+// Dir returns the skill directory, or its skill:// URI for a virtual skill.
+func (s *Skill) Dir() string {
+	if s.fsys != nil {
+		return URIScheme + s.Name
+	}
+	return filepath.Dir(s.SkillFilePath)
+}
+
+// resourcePath joins a slash path to the skill directory.
+func (s *Skill) resourcePath(rel string) string {
+	if s.fsys != nil {
+		return s.Dir() + "/" + rel
+	}
+	return filepath.Join(s.Dir(), filepath.FromSlash(rel))
+}
+
+// Resources lists the files below the skill directory other than SKILL.md,
+// sorted by path. Hidden files and directories are skipped. At most limit files
+// are returned (0 means no limit); truncated reports whether more exist.
+func (s *Skill) Resources(limit int) (resources []Resource, truncated bool, err error) {
+	err = fs.WalkDir(s.FS(), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == "." {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || p == "SKILL.md" {
+			return nil
+		}
+		if limit > 0 && len(resources) == limit {
+			truncated = true
+			return fs.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		resources = append(resources, Resource{Path: p, Size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Path < resources[j].Path })
+	return resources, truncated, nil
+}
+
+// Fingerprint identifies the current content of the skill: a hash of the
+// SKILL.md bytes, the path, size and modification time of every other file
+// in the skill directory, and the trust state. Two loads of an unchanged
+// skill have the same fingerprint regardless of load order or source.
+func (s *Skill) Fingerprint() (string, error) {
+	files := s.FS()
+	h := sha256.New()
+	data, err := fs.ReadFile(files, "SKILL.md")
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "SKILL.md %d\n", len(data))
+	h.Write(data)
+	err = fs.WalkDir(files, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || p == "SKILL.md" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s %d %d %s\n", p, info.Size(), info.ModTime().UnixNano(), info.Mode().Type())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "trust %s %s %t\n", s.Trust, s.SignedBy, s.Quarantined)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Links returns the relative markdown links in the body, in order of first
+// appearance. Links inside fenced code blocks and links that leave the skill
+// directory are skipped.
+func (s *Skill) Links() []Link {
+	files := s.FS()
+	var links []Link
+	seen := map[string]bool{}
+	forEachBodyLine(s.Body, func(_ int, line string) {
+		for _, m := range markdownLinkPattern.FindAllStringSubmatch(line, -1) {
+			target := m[1]
+			if !isLocalLink(target) {
+				continue
+			}
+			rel := path.Clean(strings.SplitN(target, "#", 2)[0])
+			if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || seen[rel] {
+				continue
+			}
+			seen[rel] = true
+			_, err := fs.Stat(files, rel)
+			links = append(links, Link{Target: target, Path: rel, Exists: err == nil})
+		}
+	})
+	return links
+}
+
+// ResolveResource returns the absolute path of a file inside the skill directory,
+// or its skill:// URI for a virtual skill. rel must be relative; paths that
+// leave the directory, including through symlinks, are rejected.
+func (s *Skill) ResolveResource(rel string) (string, error) {
+	if s.fsys != nil {
+		f, err := s.OpenResource(rel)
+		if err != nil {
+			return "", err
+		}
+		_ = f.Close()
+		clean, _ := cleanResourcePath(rel)
+		return s.resourcePath(clean), nil
+	}
+	rel = strings.TrimSpace(rel)
+	if rel == "" {
+		return "", errors.New("resource path is required")
+	}
+	if filepath.IsAbs(rel) || path.IsAbs(filepath.ToSlash(rel)) {
+		return "", fmt.Errorf("resource path %q must be relative to the skill directory", rel)
+	}
+	dir, err := filepath.EvalSymlinks(s.Dir())
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(dir, filepath.FromSlash(rel))
+	if !isWithin(dir, target) {
+		return "", fmt.Errorf("resource path %q is outside the skill directory", rel)
+	}
+	resolved, err := filepath.EvalSymlinks(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrResourceNotFound, rel)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !isWithin(dir, resolved) {
+		return "", fmt.Errorf("resource path %q is outside the skill directory", rel)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("resource path %q is not a regular file", rel)
+	}
+	return resolved, nil
+}
+
+// forEachBodyLine calls fn for each body line outside fenced code blocks.
+func forEachBodyLine(body string, fn func(i int, line string)) {
+	inFence := false
+	for i, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence {
+			fn(i, line)
+		}
+	}
+}
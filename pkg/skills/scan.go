@@ -0,0 +1,280 @@
+package skills
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// KindInjection reports skill content that looks like a prompt injection.
+const KindInjection DiagnosticKind = "injection"
+
+// ScanRule names a class of suspicious content.
+type ScanRule string
+
+const (
+	// RuleIgnoreInstructions matches text telling the model to drop its rules.
+	RuleIgnoreInstructions ScanRule = "ignore_instructions"
+	// RuleHiddenUnicode matches zero-width, bidirectional and tag characters.
+	RuleHiddenUnicode ScanRule = "hidden_unicode"
+	// RuleBase64Blob matches long base64 runs that could hide instructions.
+	RuleBase64Blob ScanRule = "base64_blob"
+	// RuleOutsidePath matches references to credential and system files, and
+	// to paths two or more levels above the skill directory.
+	RuleOutsidePath ScanRule = "outside_path"
+	// RulePipeToShell matches downloads piped into a shell.
+	RulePipeToShell ScanRule = "pipe_to_shell"
+)
+
+// ScanRules lists every rule in report order.
+var ScanRules = []ScanRule{RuleIgnoreInstructions, RuleHiddenUnicode, RuleBase64Blob, RuleOutsidePath, RulePipeToShell}
+
+// ScanAction is what happens to content with findings.
+type ScanAction string
+
+const (
+	// ScanOff ignores findings.
+	ScanOff ScanAction = "off"
+	// ScanWarn reports findings and keeps the content.
+	ScanWarn ScanAction = "warn"
+	// ScanQuarantine loads a skill as untrusted, so it cannot run commands or
+	// write files, and withholds a resource's content from the model.
+	ScanQuarantine ScanAction = "quarantine"
+	// ScanBlock skips the skill or fails the resource read.
+	ScanBlock ScanAction = "block"
+)
+
+// scanActionRank orders actions from mildest to strictest.
+var scanActionRank = map[ScanAction]int{ScanOff: 0, ScanWarn: 1, ScanQuarantine: 2, ScanBlock: 3}
+
+// ScanPolicy maps rules to actions. The zero value warns on every rule.
+type ScanPolicy struct {
+	// Default applies to rules without an override; empty means ScanWarn.
+	Default ScanAction
+	// Rules overrides Default per rule.
+	Rules map[ScanRule]ScanAction
+}
+
+// ParseScanPolicy parses a comma-separated policy such as
+// "warn,pipe_to_shell=block,hidden_unicode=quarantine". A bare action sets the
+// default; rule=action overrides one rule.
+func ParseScanPolicy(s string) (ScanPolicy, error) {
+	var policy ScanPolicy
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		rule, action, isRule := strings.Cut(part, "=")
+		if !isRule {
+			action = rule
+		}
+		if _, ok := scanActionRank[ScanAction(action)]; !ok {
+			return ScanPolicy{}, fmt.Errorf("invalid scan action %q (use off, warn, quarantine or block)", action)
+		}
+		if !isRule {
+			policy.Default = ScanAction(action)
+			continue
+		}
+		if !knownRule(ScanRule(rule)) {
+			return ScanPolicy{}, fmt.Errorf("unknown scan rule %q", rule)
+		}
+		if policy.Rules == nil {
+			policy.Rules = map[ScanRule]ScanAction{}
+		}
+		policy.Rules[ScanRule(rule)] = ScanAction(action)
+	}
+	return policy, nil
+}
+
+func knownRule(rule ScanRule) bool {
+	for _, known := range ScanRules {
+		if rule == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Action returns the action for rule.
+func (p ScanPolicy) Action(rule ScanRule) ScanAction {
+	if action, ok := p.Rules[rule]; ok {
+		return action
+	}
+	if p.Default == "" {
+		return ScanWarn
+	}
+	return p.Default
+}
+
+// Apply returns the strictest action for findings and the findings it does
+// not ignore.
+func (p ScanPolicy) Apply(findings []Finding) (ScanAction, []Finding) {
+	worst := ScanOff
+	var kept []Finding
+	for _, f := range findings {
+		action := p.Action(f.Rule)
+		if action == ScanOff {
+			continue
+		}
+		kept = append(kept, f)
+		if scanActionRank[action] > scanActionRank[worst] {
+			worst = action
+		}
+	}
+	return worst, kept
+}
+
+// Finding is one match of a scan rule.
+type Finding struct {
+	Rule ScanRule `json:"rule"`
+	// Line is 1-based.
+	Line int `json:"line"`
+	// Excerpt is the matched text, shortened and with hidden characters shown
+	// as code points.
+	Excerpt string `json:"excerpt"`
+}
+
+// String formats the finding as "line N: rule: excerpt".
+func (f Finding) String() string {
+	return fmt.Sprintf("line %d: %s: %q", f.Line, f.Rule, f.Excerpt)
+}
+
+// maxExcerpt caps the length of Finding.Excerpt in runes.
+const maxExcerpt = 80
+
+// minBase64Run is the shortest base64 run reported by RuleBase64Blob.
+const minBase64Run = 200
+
+var scanPatterns = []struct {
+	rule ScanRule
+	re   *regexp.Regexp
+}{
+	{RuleIgnoreInstructions, regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\s+(?:all\s+|any\s+)?(?:of\s+)?(?:the\s+|your\s+)?(?:previous|prior|above|earlier|preceding|system|original)\s+(?:instructions|rules|prompts?|directions|guidelines|messages)`)},
+	{RuleIgnoreInstructions, regexp.MustCompile(`(?i)\b(?:do\s+not|don't|never)\s+(?:tell|inform|mention\s+(?:this\s+)?to|reveal\s+(?:this\s+)?to)\s+the\s+user\b`)},
+	{RuleIgnoreInstructions, regexp.MustCompile(`(?i)\byou\s+are\s+no\s+longer\s+bound\b`)},
+	{RuleBase64Blob, regexp.MustCompile(`[A-Za-z0-9+/]{` + fmt.Sprint(minBase64Run) + `,}={0,2}`)},
+	{RuleOutsidePath, regexp.MustCompile(`(?:~|\$HOME|\$\{HOME\}|%USERPROFILE%)[/\\]\.(?:ssh|aws|gnupg|kube|docker|netrc|git-credentials|config/gcloud)\b`)},
+	{RuleOutsidePath, regexp.MustCompile(`(?:^|[\s"'(=])/(?:etc/(?:passwd|shadow|sudoers|ssh)|root/|proc/self/|var/run/secrets)`)},
+	{RuleOutsidePath, regexp.MustCompile(`\bid_(?:rsa|ed25519|ecdsa)\b|(?:^|[\s"'/=(])\.env\b|\.git-credentials\b`)},
+	{RuleOutsidePath, regexp.MustCompile(`(?:\.\.[/\\]){2,}`)},
+	{RulePipeToShell, regexp.MustCompile(`(?i)\b(?:curl|wget|fetch)\b[^\n|;]*\|\s*(?:sudo\s+)?(?:ba|z|k|da)?sh\b`)},
+	{RulePipeToShell, regexp.MustCompile(`(?i)\b(?:ba|z)?sh\s+(?:-c\s+)?["']?\$?<?\(\s*(?:curl|wget)\b`)},
+	{RulePipeToShell, regexp.MustCompile(`(?i)\b(?:iex|invoke-expression)\b[^\n]*\b(?:iwr|irm|invoke-webrequest|invoke-restmethod|downloadstring)\b`)},
+}
+
+// ScanText reports suspicious content in text, ordered by line and rule.
+func ScanText(text string) []Finding {
+	var findings []Finding
+	for i, line := range strings.Split(text, "\n") {
+		n := i + 1
+		if hidden := hiddenRunes(line, i == 0); hidden != "" {
+			findings = append(findings, Finding{Rule: RuleHiddenUnicode, Line: n, Excerpt: hidden})
+		}
+		seen := map[ScanRule]bool{}
+		for _, p := range scanPatterns {
+			if seen[p.rule] {
+				continue
+			}
+			if match := p.re.FindString(line); match != "" {
+				seen[p.rule] = true
+				findings = append(findings, Finding{Rule: p.rule, Line: n, Excerpt: excerpt(strings.TrimLeft(strings.TrimSpace(match), `"'(=`))})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+	return findings
+}
+
+// hiddenRunes lists the invisible formatting characters in line as code
+// points, or "" when there are none. A byte order mark at the start of the
+// first line is not reported.
+func hiddenRunes(line string, first bool) string {
+	var found []string
+	for i, r := range line {
+		if r == '\uFEFF' && i == 0 && first {
+			continue
+		}
+		if isHiddenRune(r) {
+			code := fmt.Sprintf("U+%04X", r)
+			if len(found) == 0 || found[len(found)-1] != code {
+				found = append(found, code)
+			}
+		}
+	}
+	return strings.Join(found, " ")
+}
+
+func isHiddenRune(r rune) bool {
+	switch {
+	case r >= 0x200B && r <= 0x200F, // zero-width space, joiners, direction marks
+		r >= 0x202A && r <= 0x202E, // bidirectional embeddings and overrides
+		r >= 0x2060 && r <= 0x2064, // word joiner and invisible operators
+		r >= 0x2066 && r <= 0x2069, // bidirectional isolates
+		r == 0xFEFF,
+		r >= 0xE0000 && r <= 0xE007F: // tag characters
+		return true
+	}
+	return false
+}
+
+// excerpt shortens s to maxExcerpt runes.
+func excerpt(s string) string {
+	if utf8.RuneCountInString(s) <= maxExcerpt {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:maxExcerpt-3]) + "..."
+}
+
+// ScanSkill scans the skill's SKILL.md, front matter included.
+func ScanSkill(skill *Skill) ([]Finding, error) {
+	data, err := fs.ReadFile(skill.FS(), skillFileName(skill))
+	if err != nil {
+		return nil, err
+	}
+	return ScanText(string(data)), nil
+}
+
+// skillFileName is the base name of the skill file, which may differ in case
+// from SKILL.md.
+func skillFileName(skill *Skill) string {
+	name := strings.ReplaceAll(skill.SkillFilePath, "\\", "/")
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// quarantinePolicy is used by loaders that return no diagnostics: a finding
+// cannot be reported there, so it quarantines the skill instead.
+var quarantinePolicy = ScanPolicy{Default: ScanQuarantine}
+
+// applyScan scans a freshly parsed skill and applies policy: a warned skill is
+// kept, a quarantined skill is kept as untrusted and a blocked skill is dropped
+// (keep is false). Every finding becomes a diagnostic.
+func applyScan(skill *Skill, policy ScanPolicy) (keep bool, diags []Diagnostic) {
+	if policy.Default == ScanOff && len(policy.Rules) == 0 {
+		return true, nil
+	}
+	findings, err := ScanSkill(skill)
+	if err != nil {
+		return true, []Diagnostic{{Path: skill.SkillFilePath, Kind: KindRead, Severity: SeverityWarning,
+			Message: fmt.Sprintf("scan skill %s: %v", skill.Name, err)}}
+	}
+	action, findings := policy.Apply(findings)
+	severity, outcome := SeverityWarning, ""
+	switch action {
+	case ScanQuarantine:
+		skill.Quarantined = true
+		skill.Trust = TrustUntrusted
+		outcome = "; skill quarantined as untrusted"
+	case ScanBlock:
+		severity, outcome = SeverityError, "; skill skipped"
+	}
+	for _, f := range findings {
+		diags = append(diags, Diagnostic{Path: skill.SkillFilePath, Line: f.Line, Kind: KindInjection, Severity: severity,
+			Message: fmt.Sprintf("possible prompt injection (%s): %q%s", f.Rule, f.Excerpt, outcome)})
+	}
+	return action != ScanBlock, diags
+}
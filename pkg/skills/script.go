@@ -0,0 +1,168 @@
+package skills
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fieldScripts declares the skill's scripts in front matter.
+const fieldScripts = "scripts"
+
+// Script working directories.
+const (
+	// ScriptDirSkill runs the script in the skill directory.
+	ScriptDirSkill = "skill"
+	// ScriptDirWorkspace runs the script in the workspace, the first allowed directory.
+	ScriptDirWorkspace = "workspace"
+)
+
+// DefaultScriptTimeout is used for scripts that declare no timeout.
+const DefaultScriptTimeout = 60 * time.Second
+
+// scriptNamePattern restricts script names so they fit in tool names.
+var scriptNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// Script is a bundled script declared under scripts in the front matter, which
+// agents expose as a tool with typed arguments:
+//
+//	scripts:
+//	  - name: init_skill
+//	    description: Create a skill directory from the template
+//	    path: scripts/init_skill.py
+//	    interpreter: python3
+//	    working_dir: workspace
+//	    timeout: 30s
+//	    positional: [skill_name]
+//	    arguments:
+//	      type: object
+//	      properties:
+//	        skill_name: {type: string}
+//	        path: {type: string}
+//	      required: [skill_name, path]
+//
+// Arguments named in positional are passed in that order; the others become
+// --name=value, or --name for a true boolean.
+type Script struct {
+	Name        string
+	Description string
+	// Path is slash-separated and relative to the skill directory.
+	Path string
+	// Interpreter runs the script, e.g. python3; empty executes it directly.
+	Interpreter string
+	// Arguments is the JSON schema of the arguments object.
+	Arguments  map[string]any
+	Positional []string
+	Timeout    time.Duration
+	// WorkingDir is ScriptDirSkill or ScriptDirWorkspace.
+	WorkingDir string
+}
+
+// scriptSpec mirrors one scripts entry.
+type scriptSpec struct {
+	Name        string         `yaml:"name"`
+	Description string         `yaml:"description"`
+	Path        string         `yaml:"path"`
+	Interpreter string         `yaml:"interpreter"`
+	Arguments   map[string]any `yaml:"arguments"`
+	Positional  []string       `yaml:"positional"`
+	Timeout     string         `yaml:"timeout"`
+	WorkingDir  string         `yaml:"working_dir"`
+}
+
+// scriptKeys are the accepted keys of a scripts entry.
+var scriptKeys = map[string]bool{
+	"name": true, "description": true, "path": true, "interpreter": true,
+	"arguments": true, "positional": true, "timeout": true, "working_dir": true,
+}
+
+// scriptsField decodes and checks the scripts list.
+func scriptsField(node *yaml.Node) ([]Script, error) {
+	if isNull(node) {
+		return nil, nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		return nil, fieldError(node, "scripts must be a list")
+	}
+	var out []Script
+	seen := map[string]bool{}
+	for i, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			return nil, fieldError(item, "scripts[%d] must be a mapping", i)
+		}
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			if key := item.Content[j]; !scriptKeys[key.Value] {
+				return nil, fieldError(key, "scripts[%d]: unknown key %q", i, key.Value)
+			}
+		}
+		var spec scriptSpec
+		if err := item.Decode(&spec); err != nil {
+			return nil, fieldError(item, "scripts[%d]: %v", i, err)
+		}
+		script, err := spec.script()
+		if err != nil {
+			return nil, fieldError(item, "scripts[%d]: %v", i, err)
+		}
+		if seen[script.Name] {
+			return nil, fieldError(item, "scripts[%d]: duplicate script %q", i, script.Name)
+		}
+		seen[script.Name] = true
+		out = append(out, script)
+	}
+	return out, nil
+}
+
+// script validates the entry and applies defaults.
+func (s scriptSpec) script() (Script, error) {
+	script := Script{
+		Name:        strings.TrimSpace(s.Name),
+		Description: strings.TrimSpace(s.Description),
+		Interpreter: strings.TrimSpace(s.Interpreter),
+		Arguments:   s.Arguments,
+		Positional:  s.Positional,
+		Timeout:     DefaultScriptTimeout,
+		WorkingDir:  strings.TrimSpace(s.WorkingDir),
+	}
+	if !scriptNamePattern.MatchString(script.Name) {
+		return Script{}, fmt.Errorf("name %q must be 1-32 letters, digits, '_' or '-'", script.Name)
+	}
+	p := strings.TrimSpace(s.Path)
+	if p == "" {
+		return Script{}, fmt.Errorf("path is required")
+	}
+	script.Path = path.Clean(p)
+	if path.IsAbs(p) || strings.Contains(p, "\\") || script.Path == ".." || strings.HasPrefix(script.Path, "../") {
+		return Script{}, fmt.Errorf("path %q must stay inside the skill directory", p)
+	}
+	switch script.WorkingDir {
+	case "":
+		script.WorkingDir = ScriptDirSkill
+	case ScriptDirSkill, ScriptDirWorkspace:
+	default:
+		return Script{}, fmt.Errorf("working_dir must be %q or %q", ScriptDirSkill, ScriptDirWorkspace)
+	}
+	if t := strings.TrimSpace(s.Timeout); t != "" {
+		timeout, err := time.ParseDuration(t)
+		if err != nil || timeout <= 0 {
+			return Script{}, fmt.Errorf("timeout %q must be a positive duration such as 30s", t)
+		}
+		script.Timeout = timeout
+	}
+	if script.Arguments == nil {
+		script.Arguments = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	if typ, _ := script.Arguments["type"].(string); typ != "object" {
+		return Script{}, fmt.Errorf("arguments must be a JSON schema with type: object")
+	}
+	properties, _ := script.Arguments["properties"].(map[string]any)
+	for _, name := range script.Positional {
+		if _, ok := properties[name]; !ok {
+			return Script{}, fmt.Errorf("positional argument %q is not in arguments.properties", name)
+		}
+	}
+	return script, nil
+}
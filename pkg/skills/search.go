@@ -0,0 +1,150 @@
+package skills
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters and per-field term weights. A match in the name counts more
+// than one in the description, which counts more than one in the body.
+const (
+	bm25K1            = 1.2
+	bm25B             = 0.75
+	nameWeight        = 3
+	descriptionWeight = 2
+	bodyWeight        = 1
+)
+
+// stopWords are dropped from documents and queries.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "can": true, "do": true, "for": true, "from": true, "how": true, "i": true,
+	"in": true, "is": true, "it": true, "me": true, "my": true, "of": true, "on": true,
+	"or": true, "please": true, "that": true, "the": true, "this": true, "to": true,
+	"use": true, "when": true, "with": true, "you": true, "your": true,
+}
+
+// Index ranks skills against free-text queries with BM25 over their names,
+// descriptions and bodies. It is built once, needs no network access and is
+// safe for concurrent searches.
+type Index struct {
+	skills  []*Skill
+	terms   []map[string]float64
+	lengths []float64
+	avgLen  float64
+	df      map[string]int
+}
+
+// SearchResult is a skill matched by Index.Search.
+type SearchResult struct {
+	Skill *Skill
+	Score float64
+}
+
+// NewIndex builds an index over list.
+func NewIndex(list []*Skill) *Index {
+	ix := &Index{
+		skills:  list,
+		terms:   make([]map[string]float64, len(list)),
+		lengths: make([]float64, len(list)),
+		df:      map[string]int{},
+	}
+	var total float64
+	for i, skill := range list {
+		tf := map[string]float64{}
+		addTerms(tf, skill.Name, nameWeight)
+		addTerms(tf, skill.Description, descriptionWeight)
+		addTerms(tf, skill.ShortDescription(), descriptionWeight)
+		addTerms(tf, skill.Body, bodyWeight)
+		for term, weight := range tf {
+			ix.df[term]++
+			ix.lengths[i] += weight
+		}
+		ix.terms[i] = tf
+		total += ix.lengths[i]
+	}
+	if len(list) > 0 {
+		ix.avgLen = total / float64(len(list))
+	}
+	return ix
+}
+
+// Len returns the number of indexed skills.
+func (ix *Index) Len() int {
+	return len(ix.skills)
+}
+
+// Search returns up to limit skills matching query, best first. Skills that
+// share no term with the query are omitted. limit <= 0 returns every match.
+func (ix *Index) Search(query string, limit int) []SearchResult {
+	queryTerms := map[string]bool{}
+	for _, term := range tokenize(query) {
+		queryTerms[term] = true
+	}
+	if len(queryTerms) == 0 || ix.avgLen == 0 {
+		return nil
+	}
+
+	n := float64(len(ix.skills))
+	var results []SearchResult
+	for i, tf := range ix.terms {
+		var score float64
+		for term := range queryTerms {
+			freq := tf[term]
+			if freq == 0 {
+				continue
+			}
+			df := float64(ix.df[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := bm25K1 * (1 - bm25B + bm25B*ix.lengths[i]/ix.avgLen)
+			score += idf * freq * (bm25K1 + 1) / (freq + norm)
+		}
+		if score > 0 {
+			results = append(results, SearchResult{Skill: ix.skills[i], Score: score})
+		}
+	}
+	sort.SliceStable(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		return results[a].Skill.Name < results[b].Skill.Name
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// addTerms adds the tokens of text to tf with the given weight.
+func addTerms(tf map[string]float64, text string, weight float64) {
+	for _, term := range tokenize(text) {
+		tf[term] += weight
+	}
+}
+
+// tokenize lowercases text and splits it into letter/digit runs, dropping
+// stop words and single characters. Hyphenated names split into their parts
+// and plurals are reduced so "charts" matches "chart".
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fields[:0]
+	for _, field := range fields {
+		if len(field) < 2 || stopWords[field] {
+			continue
+		}
+		out = append(out, stem(field))
+	}
+	return out
+}
+
+// stem strips a plural "s" from words longer than three letters.
+func stem(word string) string {
+	if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+		return word[:len(word)-1]
+	}
+	return word
+}
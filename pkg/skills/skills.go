@@ -1,33 +1,94 @@
 package skills
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
-
-	"gopkg.in/yaml.v3"
 )
 
+// Skill is a parsed SKILL.md.
 type Skill struct {
 	Name          string
 	Description   string
 	SkillFilePath string
+
+	License       string
+	Version       string
+	AllowedTools  []string
+	Compatibility string
+	// Metadata is the front matter metadata mapping, e.g. short-description.
+	Metadata map[string]any
+	// Extra holds front matter keys without a dedicated field.
+	Extra map[string]any
+	// Body is the markdown after the front matter.
+	Body string
+	// Scripts are the bundled scripts declared in front matter.
+	Scripts []Script
+
+	// Interface and Dependencies come from agents/openai.yaml, if present.
+	Interface    Interface
+	Dependencies []Dependency
+
+	// Trust is set by ApplyTrust; SignedBy is the KeyID of a verified signature.
+	Trust    TrustLevel
+	SignedBy string
+	// Quarantined is set when a prompt-injection scan quarantined the skill;
+	// ApplyTrust keeps it untrusted.
+	Quarantined bool
+
+	// fsys holds the files of a skill loaded with LoadFromFS, rooted at the
+	// skill directory; fsDir is that directory's path in the original FS.
+	// system is set for skills loaded from an FSRoot marked System.
+	fsys   fs.FS
+	fsDir  string
+	system bool
 }
 
-// skillFrontMatter mirrors the YAML front matter in SKILL.md.
-type skillFrontMatter struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description"`
+// ShortDescription returns metadata.short-description, falling back to
+// interface.short_description from agents/openai.yaml, or "" when neither is set.
+func (s *Skill) ShortDescription() string {
+	if s == nil {
+		return ""
+	}
+	if value, _ := s.Metadata[shortDescriptionKey].(string); strings.TrimSpace(value) != "" {
+		return strings.TrimSpace(value)
+	}
+	return s.Interface.ShortDescription
 }
 
 func loadSkillsFromDir(dir string) ([]*Skill, error) {
+	skills, _, err := scanDir(dir, false, quarantinePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(skills, func(i, j int) bool {
+		return strings.ToLower(skills[i].Name) < strings.ToLower(skills[j].Name)
+	})
+
+	return skills, nil
+}
+
+// scanDir parses every SKILL.md under dir in lexical path order and applies the
+// injection scan to each. In lenient mode, files that fail to parse and unreadable
+// directories become diagnostics; otherwise the first failure is returned.
+func scanDir(dir string, lenient bool, scan ScanPolicy) ([]*Skill, []Diagnostic, error) {
 	var skills []*Skill
+	var diags []Diagnostic
 	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return err
+			if !lenient {
+				return err
+			}
+			diags = append(diags, Diagnostic{Path: path, Kind: KindRead, Severity: SeverityError, Message: err.Error()})
+			if d != nil && d.IsDir() && path != dir {
+				return fs.SkipDir
+			}
+			return nil
 		}
 		if d.IsDir() {
 			return nil
@@ -35,24 +96,30 @@ func loadSkillsFromDir(dir string) ([]*Skill, error) {
 		if strings.EqualFold(d.Name(), "SKILL.md") {
 			skill, err := parseSkillFile(path)
 			if err != nil {
-				return fmt.Errorf("parse %s: %w", path, err)
+				if !lenient {
+					return fmt.Errorf("parse %s: %w", path, err)
+				}
+				diags = append(diags, diagnosticFor(path, err))
+				return nil
+			}
+			keep, scanDiags := applyScan(skill, scan)
+			diags = append(diags, scanDiags...)
+			if keep {
+				skills = append(skills, skill)
 			}
-			skills = append(skills, skill)
 		}
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	sort.Slice(skills, func(i, j int) bool {
-		return strings.ToLower(skills[i].Name) < strings.ToLower(skills[j].Name)
-	})
-
-	return skills, nil
+	return skills, diags, nil
 }
 
 // LoadFromDirs loads and parses all SKILL.md files under the provided directories.
+// Skills are scanned for prompt injection; since findings cannot be reported
+// here, a skill with findings is returned quarantined. Use Load to pick the
+// scan policy and see the findings.
 func LoadFromDirs(dirs []string) ([]*Skill, error) {
 	var skills []*Skill
 	for _, dir := range dirs {
@@ -78,49 +145,54 @@ func LoadFromDirs(dirs []string) ([]*Skill, error) {
 	return skills, nil
 }
 
+// ParseFile parses one SKILL.md together with the agents/openai.yaml next to it.
+func ParseFile(path string) (*Skill, error) {
+	return parseSkillFile(path)
+}
+
 func parseSkillFile(path string) (*Skill, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, &fileError{Kind: KindRead, Err: err}
 	}
-
-	fm, err := parseFrontMatter(content)
+	skill, err := parseSkill(content, func() (Interface, []Dependency, error) {
+		return loadAgentMetadata(filepath.Dir(path))
+	})
 	if err != nil {
 		return nil, err
 	}
-	if strings.TrimSpace(fm.Name) == "" {
-		return nil, fmt.Errorf("missing front matter name")
-	}
-
-	return &Skill{
-		Name:          strings.TrimSpace(fm.Name),
-		Description:   strings.TrimSpace(fm.Description),
-		SkillFilePath: path,
-	}, nil
+	skill.SkillFilePath = path
+	return skill, nil
 }
 
-// parseFrontMatter extracts YAML front matter from the file content.
-func parseFrontMatter(content []byte) (skillFrontMatter, error) {
-	lines := strings.Split(string(content), "\n")
-	if len(lines) < 3 || strings.TrimSpace(lines[0]) != "---" {
-		return skillFrontMatter{}, fmt.Errorf("missing YAML front matter")
-	}
-
-	end := -1
-	for i := 1; i < len(lines); i++ {
-		if strings.TrimSpace(lines[i]) == "---" {
-			end = i
-			break
-		}
+// parseSkill parses SKILL.md content; loadMeta reads the agents/openai.yaml
+// next to it.
+func parseSkill(content []byte, loadMeta func() (Interface, []Dependency, error)) (*Skill, error) {
+	fm, body, err := parseFrontMatter(content)
+	if err != nil {
+		return nil, err
 	}
-	if end == -1 {
-		return skillFrontMatter{}, fmt.Errorf("unterminated YAML front matter")
+	if fm.Name == "" {
+		return nil, &fileError{Kind: KindFrontMatter, Line: 1, Err: errors.New("missing front matter name")}
 	}
 
-	fmText := strings.Join(lines[1:end], "\n")
-	var fm skillFrontMatter
-	if err := yaml.Unmarshal([]byte(fmText), &fm); err != nil {
-		return skillFrontMatter{}, err
+	iface, deps, err := loadMeta()
+	if err != nil {
+		return nil, agentMetadataError(err)
 	}
-	return fm, nil
+
+	return &Skill{
+		Name:          fm.Name,
+		Description:   fm.Description,
+		License:       fm.License,
+		Version:       fm.Version,
+		AllowedTools:  fm.AllowedTools,
+		Compatibility: fm.Compatibility,
+		Metadata:      fm.Metadata,
+		Extra:         fm.Extra,
+		Body:          body,
+		Scripts:       fm.Scripts,
+		Interface:     iface,
+		Dependencies:  deps,
+	}, nil
 }
@@ -0,0 +1,374 @@
+package skills
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TrustLevel is how much a skill is trusted. The zero value means trust was
+// not evaluated and no restrictions apply.
+type TrustLevel string
+
+const (
+	// TrustSystem is a skill bundled with the application: one below
+	// TrustOptions.SystemDirs or loaded from an FSRoot marked System.
+	TrustSystem TrustLevel = "system"
+	// TrustVerified is a skill signed by a trusted key.
+	TrustVerified TrustLevel = "verified"
+	// TrustUntrusted is any other skill. Untrusted skills cannot run commands
+	// or write files while active.
+	TrustUntrusted TrustLevel = "untrusted"
+)
+
+// KindSignature reports unknown signing keys and signature mismatches.
+const KindSignature DiagnosticKind = "signature"
+
+// SignatureFile is the signed manifest stored in the skill directory.
+const SignatureFile = ".skill-signature.json"
+
+// signatureVersion is the current signature format; manifestHeader starts the
+// signed bytes so signatures cannot be replayed for other formats.
+const (
+	signatureVersion = 1
+	manifestHeader   = "agent-skills-go skill manifest v1\n"
+)
+
+var (
+	// ErrUnsigned is returned by VerifyDir when the skill has no signature file.
+	ErrUnsigned = errors.New("skill is not signed")
+	// ErrUnknownKey is returned by VerifyDir when the signing key is not trusted.
+	ErrUnknownKey = errors.New("skill is signed by an untrusted key")
+	// ErrSignatureMismatch is returned by VerifyDir when the files or the
+	// signature do not match.
+	ErrSignatureMismatch = errors.New("skill signature mismatch")
+)
+
+// ManifestEntry is one file in a signed manifest.
+type ManifestEntry struct {
+	Path       string `json:"path"`
+	SHA256     string `json:"sha256"`
+	Executable bool   `json:"executable,omitempty"`
+}
+
+// signatureDoc is the SignatureFile format.
+type signatureDoc struct {
+	Version   int             `json:"version"`
+	KeyID     string          `json:"key_id"`
+	Files     []ManifestEntry `json:"files"`
+	Signature string          `json:"signature"`
+}
+
+// KeyID returns the identifier of a public key: the first 16 hex digits of its sha256.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Manifest hashes every regular file in dir except SignatureFile and .git,
+// sorted by path. Links are rejected because their targets cannot be pinned.
+func Manifest(dir string) ([]ManifestEntry, error) {
+	return manifestFS(os.DirFS(dir))
+}
+
+// manifestFS is Manifest for files rooted at the skill directory.
+func manifestFS(files fs.FS) ([]ManifestEntry, error) {
+	var entries []ManifestEntry
+	err := fs.WalkDir(files, ".", func(rel string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" && rel != "." {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if rel == SignatureFile {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("%s: only regular files can be signed", rel)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f, err := files.Open(rel)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, ManifestEntry{
+			Path:       rel,
+			SHA256:     hex.EncodeToString(h.Sum(nil)),
+			Executable: info.Mode()&0o111 != 0,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// manifestBytes is the canonical form that is signed.
+func manifestBytes(entries []ManifestEntry) []byte {
+	var sb strings.Builder
+	sb.WriteString(manifestHeader)
+	for _, e := range entries {
+		mode := "644"
+		if e.Executable {
+			mode = "755"
+		}
+		fmt.Fprintf(&sb, "%s %s %s\n", e.SHA256, mode, e.Path)
+	}
+	return []byte(sb.String())
+}
+
+// SignDir writes SignatureFile for the skill in dir, signed with key.
+func SignDir(dir string, key ed25519.PrivateKey) error {
+	entries, err := Manifest(dir)
+	if err != nil {
+		return err
+	}
+	doc := signatureDoc{
+		Version:   signatureVersion,
+		KeyID:     KeyID(key.Public().(ed25519.PublicKey)),
+		Files:     entries,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifestBytes(entries))),
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, SignatureFile), append(data, '\n'), 0o644)
+}
+
+// VerifyDir checks the skill in dir against its SignatureFile and returns the
+// signing key ID. keys maps key IDs to trusted public keys.
+func VerifyDir(dir string, keys map[string]ed25519.PublicKey) (string, error) {
+	return verifyFS(os.DirFS(dir), keys)
+}
+
+// verifyFS is VerifyDir for files rooted at the skill directory.
+func verifyFS(files fs.FS, keys map[string]ed25519.PublicKey) (string, error) {
+	data, err := fs.ReadFile(files, SignatureFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrUnsigned
+	}
+	if err != nil {
+		return "", err
+	}
+	var doc signatureDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("%w: parse %s: %v", ErrSignatureMismatch, SignatureFile, err)
+	}
+	if doc.Version != signatureVersion {
+		return doc.KeyID, fmt.Errorf("%w: unsupported signature version %d", ErrSignatureMismatch, doc.Version)
+	}
+	pub, ok := keys[doc.KeyID]
+	if !ok {
+		return doc.KeyID, fmt.Errorf("%w: %s", ErrUnknownKey, doc.KeyID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(doc.Signature)
+	if err != nil {
+		return doc.KeyID, fmt.Errorf("%w: decode signature: %v", ErrSignatureMismatch, err)
+	}
+	entries, err := manifestFS(files)
+	if err != nil {
+		return doc.KeyID, fmt.Errorf("%w: %v", ErrSignatureMismatch, err)
+	}
+	if !ed25519.Verify(pub, manifestBytes(entries), sig) {
+		if changes := manifestChanges(doc.Files, entries); changes != "" {
+			return doc.KeyID, fmt.Errorf("%w: %s", ErrSignatureMismatch, changes)
+		}
+		return doc.KeyID, fmt.Errorf("%w: invalid signature", ErrSignatureMismatch)
+	}
+	return doc.KeyID, nil
+}
+
+// manifestChanges describes how the current files differ from the signed list.
+func manifestChanges(signed, current []ManifestEntry) string {
+	before := make(map[string]ManifestEntry, len(signed))
+	for _, e := range signed {
+		before[e.Path] = e
+	}
+	var changes []string
+	for _, e := range current {
+		prev, ok := before[e.Path]
+		switch {
+		case !ok:
+			changes = append(changes, "added "+e.Path)
+		case prev != e:
+			changes = append(changes, "modified "+e.Path)
+		}
+		delete(before, e.Path)
+	}
+	removed := make([]string, 0, len(before))
+	for path := range before {
+		removed = append(removed, "removed "+path)
+	}
+	sort.Strings(removed)
+	return strings.Join(append(changes, removed...), ", ")
+}
+
+// ParseTrustedKeys reads public keys, one base64 ed25519 key per line followed
+// by an optional comment. Blank lines and lines starting with # are ignored.
+func ParseTrustedKeys(r io.Reader) (map[string]ed25519.PublicKey, error) {
+	keys := map[string]ed25519.PublicKey{}
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("line %d: not a base64 ed25519 public key", lineNo)
+		}
+		pub := ed25519.PublicKey(raw)
+		keys[KeyID(pub)] = pub
+	}
+	return keys, scanner.Err()
+}
+
+// LoadTrustedKeys reads a trusted keys file; an empty path or a missing file
+// yields no keys.
+func LoadTrustedKeys(path string) (map[string]ed25519.PublicKey, error) {
+	if path == "" {
+		return map[string]ed25519.PublicKey{}, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]ed25519.PublicKey{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	keys, err := ParseTrustedKeys(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// MismatchPolicy is what happens to a skill whose signature does not match its
+// files.
+type MismatchPolicy string
+
+const (
+	// MismatchRefuse skips the skill.
+	MismatchRefuse MismatchPolicy = "refuse"
+	// MismatchWarn loads the skill as untrusted.
+	MismatchWarn MismatchPolicy = "warn"
+)
+
+// ParseMismatchPolicy validates a policy name; empty means MismatchRefuse.
+func ParseMismatchPolicy(value string) (MismatchPolicy, error) {
+	switch policy := MismatchPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return MismatchRefuse, nil
+	case MismatchRefuse, MismatchWarn:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown signature mismatch policy %q (want refuse or warn)", value)
+	}
+}
+
+// TrustOptions configures ApplyTrust.
+type TrustOptions struct {
+	// Keys are the trusted public keys by KeyID.
+	Keys map[string]ed25519.PublicKey
+	// RefuseMismatch drops skills whose signature does not match instead of
+	// loading them as untrusted.
+	RefuseMismatch bool
+	// SystemDirs are the directories of bundled skills. Skills at or below one
+	// of them are trusted without a signature; a directory merely named
+	// .system elsewhere gets no special treatment.
+	SystemDirs []string
+}
+
+// ApplyTrust sets Trust on each skill: system for bundled skills, verified for
+// skills signed by a trusted key and untrusted otherwise. Quarantined skills
+// stay untrusted. Signatures from unknown keys are reported as warnings and
+// mismatches as errors.
+func ApplyTrust(list []*Skill, opts TrustOptions) ([]*Skill, []Diagnostic) {
+	out := list[:0:0]
+	var diags []Diagnostic
+	for _, skill := range list {
+		if skill.Quarantined {
+			skill.Trust = TrustUntrusted
+			out = append(out, skill)
+			continue
+		}
+		if isSystemSkill(skill, opts.SystemDirs) {
+			skill.Trust = TrustSystem
+			out = append(out, skill)
+			continue
+		}
+		sigPath := skill.resourcePath(SignatureFile)
+		keyID, err := verifyFS(skill.FS(), opts.Keys)
+		switch {
+		case err == nil:
+			skill.Trust = TrustVerified
+			skill.SignedBy = keyID
+		case errors.Is(err, ErrUnsigned):
+			skill.Trust = TrustUntrusted
+		case errors.Is(err, ErrUnknownKey):
+			skill.Trust = TrustUntrusted
+			diags = append(diags, Diagnostic{Path: sigPath, Kind: KindSignature, Severity: SeverityWarning,
+				Message: fmt.Sprintf("skill %s is signed by unknown key %s; loaded as untrusted", skill.Name, keyID)})
+		default:
+			if opts.RefuseMismatch {
+				diags = append(diags, Diagnostic{Path: sigPath, Kind: KindSignature, Severity: SeverityError,
+					Message: fmt.Sprintf("skill %s skipped: %v", skill.Name, err)})
+				continue
+			}
+			skill.Trust = TrustUntrusted
+			diags = append(diags, Diagnostic{Path: sigPath, Kind: KindSignature, Severity: SeverityError,
+				Message: fmt.Sprintf("skill %s loaded as untrusted: %v", skill.Name, err)})
+		}
+		out = append(out, skill)
+	}
+	return out, diags
+}
+
+// isSystemSkill reports whether the skill was loaded from an FSRoot marked
+// System or its directory is at or below one of dirs.
+func isSystemSkill(skill *Skill, dirs []string) bool {
+	if skill.fsys != nil {
+		return skill.system
+	}
+	dir, err := filepath.Abs(skill.Dir())
+	if err != nil {
+		return false
+	}
+	for _, root := range dirs {
+		if strings.TrimSpace(root) == "" {
+			continue
+		}
+		if root, err := filepath.Abs(root); err == nil && isWithin(root, dir) {
+			return true
+		}
+	}
+	return false
+}
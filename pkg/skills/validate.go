@@ -0,0 +1,304 @@
+package skills
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validation limits, matching skill-creator's quick_validate.py and generate_openai_yaml.py.
+const (
+	MaxNameLength             = 64
+	MaxDescriptionLength      = 1024
+	MinInterfaceShortDescChar = 25
+	MaxInterfaceShortDescChar = 64
+)
+
+// Additional diagnostic kinds reported by Validate.
+const (
+	// KindName covers the name format and directory match.
+	KindName DiagnosticKind = "name"
+	// KindDescription covers the description length and content.
+	KindDescription DiagnosticKind = "description"
+	// KindResource reports files referenced from the body that do not exist.
+	KindResource DiagnosticKind = "resource"
+)
+
+// namePattern is hyphen-case: lowercase letters and digits separated by single hyphens.
+var namePattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// allowedFrontMatterKeys lists the keys Validate accepts in SKILL.md.
+var allowedFrontMatterKeys = map[string]bool{
+	fieldName:          true,
+	fieldDescription:   true,
+	fieldLicense:       true,
+	fieldVersion:       true,
+	fieldAllowedTools:  true,
+	fieldCompatibility: true,
+	fieldMetadata:      true,
+	fieldScripts:       true,
+}
+
+// resourceDirs are the skill subdirectories whose files the body may reference.
+var resourceDirs = []string{"scripts", "references", "assets", "agents"}
+
+var (
+	// markdownLinkPattern matches [text](target) and ![alt](target).
+	markdownLinkPattern = regexp.MustCompile(`\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	// codeSpanPattern matches `inline code`.
+	codeSpanPattern = regexp.MustCompile("`([^`\n]+)`")
+)
+
+// Validate checks the skill in skillDir against the authoring rules: the
+// front matter keys, name and description constraints, a directory named
+// after the skill, referenced resources that exist, and agents/openai.yaml.
+// The skill is valid when the result has no error diagnostics.
+func Validate(skillDir string) []Diagnostic {
+	skillPath := filepath.Join(skillDir, "SKILL.md")
+	content, err := os.ReadFile(skillPath)
+	if err != nil {
+		return []Diagnostic{{Path: skillPath, Kind: KindRead, Severity: SeverityError, Message: err.Error()}}
+	}
+	fm, body, err := parseFrontMatter(content)
+	if err != nil {
+		return []Diagnostic{diagnosticFor(skillPath, err)}
+	}
+
+	v := &validator{path: skillPath, fm: fm}
+	v.checkKeys()
+	v.checkName(skillDir)
+	v.checkDescription()
+	v.checkResources(skillDir, body)
+	v.checkAgentMetadata(skillDir)
+	v.checkScripts(skillDir)
+	v.checkInjection(content)
+
+	sort.SliceStable(v.diags, func(i, j int) bool {
+		if v.diags[i].Path != v.diags[j].Path {
+			return v.diags[i].Path < v.diags[j].Path
+		}
+		return v.diags[i].Line < v.diags[j].Line
+	})
+	return v.diags
+}
+
+// FindSkillDirs returns root when it holds a SKILL.md, otherwise every directory
+// below root that does, in lexical order.
+func FindSkillDirs(root string) ([]string, error) {
+	if _, err := os.Stat(filepath.Join(root, "SKILL.md")); err == nil {
+		return []string{root}, nil
+	}
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(d.Name(), "SKILL.md") {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no SKILL.md found under %s", root)
+	}
+	return dirs, nil
+}
+
+// validator accumulates diagnostics for one skill.
+type validator struct {
+	path  string
+	fm    skillFrontMatter
+	diags []Diagnostic
+}
+
+func (v *validator) add(path string, line int, kind DiagnosticKind, severity Severity, format string, args ...any) {
+	v.diags = append(v.diags, Diagnostic{
+		Path:     path,
+		Line:     line,
+		Kind:     kind,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) checkKeys() {
+	var unexpected []string
+	for key := range v.fm.Extra {
+		unexpected = append(unexpected, key)
+	}
+	sort.Strings(unexpected)
+	for _, key := range unexpected {
+		v.add(v.path, v.fm.Lines[key], KindFrontMatter, SeverityError,
+			"unexpected key %q; allowed keys are %s", key, strings.Join(sortedKeys(allowedFrontMatterKeys), ", "))
+	}
+}
+
+func (v *validator) checkName(skillDir string) {
+	name := v.fm.Name
+	line := v.fm.Lines[fieldName]
+	switch {
+	case name == "":
+		v.add(v.path, 1, KindName, SeverityError, "missing name")
+		return
+	case utf8.RuneCountInString(name) > MaxNameLength:
+		v.add(v.path, line, KindName, SeverityError, "name is %d characters; maximum is %d", utf8.RuneCountInString(name), MaxNameLength)
+	case !namePattern.MatchString(name):
+		v.add(v.path, line, KindName, SeverityError, "name %q must be hyphen-case: lowercase letters, digits and single hyphens", name)
+	}
+	abs, err := filepath.Abs(skillDir)
+	if err != nil {
+		abs = skillDir
+	}
+	if base := filepath.Base(abs); base != name {
+		v.add(v.path, line, KindName, SeverityError, "directory %q does not match skill name %q", base, name)
+	}
+}
+
+func (v *validator) checkDescription() {
+	desc := v.fm.Description
+	line := v.fm.Lines[fieldDescription]
+	if desc == "" {
+		v.add(v.path, 1, KindDescription, SeverityError, "missing description")
+		return
+	}
+	if n := utf8.RuneCountInString(desc); n > MaxDescriptionLength {
+		v.add(v.path, line, KindDescription, SeverityError, "description is %d characters; maximum is %d", n, MaxDescriptionLength)
+	}
+	if strings.ContainsAny(desc, "<>") {
+		v.add(v.path, line, KindDescription, SeverityError, "description cannot contain angle brackets")
+	}
+}
+
+// checkResources reports relative links and resource paths in code spans that do
+// not exist inside the skill dir. Fenced code blocks are skipped.
+func (v *validator) checkResources(skillDir, body string) {
+	absDir, err := filepath.Abs(skillDir)
+	if err != nil {
+		return
+	}
+	seen := map[string]bool{}
+	forEachBodyLine(body, func(i int, line string) {
+		// Links are explicit references; code spans are often examples, so a
+		// missing file there is only a warning.
+		var refs []resourceRef
+		for _, m := range markdownLinkPattern.FindAllStringSubmatch(line, -1) {
+			if isLocalLink(m[1]) {
+				refs = append(refs, resourceRef{path: m[1], severity: SeverityError})
+			}
+		}
+		for _, m := range codeSpanPattern.FindAllStringSubmatch(line, -1) {
+			if isResourcePath(m[1]) {
+				refs = append(refs, resourceRef{path: m[1], severity: SeverityWarning})
+			}
+		}
+		for _, ref := range refs {
+			path := strings.SplitN(ref.path, "#", 2)[0]
+			if path == "" || seen[path] {
+				continue
+			}
+			seen[path] = true
+			lineNo := v.fm.BodyLine + i
+			target := filepath.Join(absDir, filepath.FromSlash(path))
+			if !isWithin(absDir, target) {
+				v.add(v.path, lineNo, KindResource, SeverityError, "reference %q points outside the skill directory", path)
+				continue
+			}
+			if _, err := os.Stat(target); err != nil {
+				v.add(v.path, lineNo, KindResource, ref.severity, "referenced file %q does not exist", path)
+			}
+		}
+	})
+}
+
+// resourceRef is a file path found in the body.
+type resourceRef struct {
+	path     string
+	severity Severity
+}
+
+// isLocalLink reports whether a link target is a relative file path.
+func isLocalLink(target string) bool {
+	if target == "" || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "/") {
+		return false
+	}
+	return !strings.Contains(target, "://") && !strings.HasPrefix(target, "mailto:")
+}
+
+// isResourcePath reports whether a code span names a concrete file in a resource dir.
+func isResourcePath(text string) bool {
+	text = strings.TrimPrefix(strings.TrimSpace(text), "./")
+	if strings.ContainsAny(text, " \t*<>{}$") {
+		return false
+	}
+	for _, dir := range resourceDirs {
+		if rest, ok := strings.CutPrefix(text, dir+"/"); ok && rest != "" && !strings.HasSuffix(rest, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkInjection reports content that the load-time scan would flag.
+func (v *validator) checkInjection(content []byte) {
+	for _, f := range ScanText(string(content)) {
+		v.add(v.path, f.Line, KindInjection, SeverityWarning, "possible prompt injection (%s): %q", f.Rule, f.Excerpt)
+	}
+}
+
+// checkScripts reports declared scripts whose file is missing.
+func (v *validator) checkScripts(skillDir string) {
+	for _, script := range v.fm.Scripts {
+		info, err := os.Stat(filepath.Join(skillDir, filepath.FromSlash(script.Path)))
+		if err != nil || !info.Mode().IsRegular() {
+			v.add(v.path, v.fm.Lines[fieldScripts], KindResource, SeverityError, "script %s: %s does not exist", script.Name, script.Path)
+		}
+	}
+}
+
+// checkAgentMetadata applies the loader's agents/openai.yaml checks plus the
+// authoring constraints from skill-creator's openai_yaml.md reference.
+func (v *validator) checkAgentMetadata(skillDir string) {
+	metaPath := filepath.Join(skillDir, agentMetadataFile)
+	iface, deps, err := loadAgentMetadata(skillDir)
+	if err != nil {
+		v.diags = append(v.diags, diagnosticFor(v.path, agentMetadataError(err)))
+		return
+	}
+
+	if short := iface.ShortDescription; short != "" {
+		if n := utf8.RuneCountInString(short); n < MinInterfaceShortDescChar || n > MaxInterfaceShortDescChar {
+			v.add(metaPath, 0, KindAgentMetadata, SeverityWarning, "interface.short_description is %d characters; want %d-%d",
+				n, MinInterfaceShortDescChar, MaxInterfaceShortDescChar)
+		}
+	}
+	if prompt := iface.DefaultPrompt; prompt != "" && v.fm.Name != "" && !strings.Contains(prompt, "$"+v.fm.Name) {
+		v.add(metaPath, 0, KindAgentMetadata, SeverityWarning, "interface.default_prompt should mention the skill as $%s", v.fm.Name)
+	}
+	for i, dep := range deps {
+		if dep.Type != DependencyTypeMCP {
+			v.add(metaPath, 0, KindAgentMetadata, SeverityError, "dependencies.tools[%d].type %q is not supported; only %q is", i, dep.Type, DependencyTypeMCP)
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
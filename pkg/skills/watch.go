@@ -0,0 +1,126 @@
+package skills
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileStamp identifies a version of a watched file.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watcher polls skill directories for added, removed or edited SKILL.md,
+// agents/openai.yaml and signature files. Polling works on every platform without CGO and
+// catches edits made through editors that replace files.
+type Watcher struct {
+	dirs     []string
+	interval time.Duration
+	changes  chan struct{}
+
+	mu       sync.Mutex
+	snapshot map[string]fileStamp
+}
+
+// NewWatcher records the current state of dirs. Run reports later changes.
+func NewWatcher(dirs []string, interval time.Duration) *Watcher {
+	w := &Watcher{
+		dirs:     append([]string(nil), dirs...),
+		interval: interval,
+		changes:  make(chan struct{}, 1),
+	}
+	w.snapshot = w.scan()
+	return w
+}
+
+// Changes receives a value after one or more changes. Signals are coalesced,
+// so a single receive covers every change since the previous one.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Run polls until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Poll()
+		}
+	}
+}
+
+// Poll checks the directories once and reports whether anything changed
+// since the last check. A change is also signaled on Changes.
+func (w *Watcher) Poll() bool {
+	current := w.scan()
+	w.mu.Lock()
+	changed := !sameSnapshot(w.snapshot, current)
+	w.snapshot = current
+	w.mu.Unlock()
+	if changed {
+		select {
+		case w.changes <- struct{}{}:
+		default:
+		}
+	}
+	return changed
+}
+
+// scan stamps every file the loader reads. Unreadable paths are skipped; they
+// show up as removals and the reload reports the error.
+func (w *Watcher) scan() map[string]fileStamp {
+	out := map[string]fileStamp{}
+	for _, dir := range w.dirs {
+		if strings.TrimSpace(dir) == "" {
+			continue
+		}
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if d != nil && d.IsDir() && path != dir {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || !isWatchedFile(path) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			out[path] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+			return nil
+		})
+	}
+	return out
+}
+
+// isWatchedFile reports whether path is a SKILL.md, agents/openai.yaml or SignatureFile.
+func isWatchedFile(path string) bool {
+	if strings.EqualFold(filepath.Base(path), "SKILL.md") || filepath.Base(path) == SignatureFile {
+		return true
+	}
+	return strings.HasSuffix(filepath.ToSlash(path), "/"+filepath.ToSlash(agentMetadataFile))
+}
+
+func sameSnapshot(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		other, ok := b[path]
+		if !ok || other.size != stamp.size || !other.modTime.Equal(stamp.modTime) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+// Atomic file replacement helpers used by write_file.
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes data to a temp file next to path and renames it into place.
+// An existing file keeps its permission bits and ownership; new files use perm.
+// When syncFile is set, the temp file and its parent directory are fsynced.
+func writeFileAtomic(path string, data []byte, perm os.FileMode, syncFile bool) error {
+	target := path
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		// Follow symlinks so the link itself is not replaced by a regular file.
+		target = resolved
+	}
+
+	existing, err := os.Stat(target)
+	switch {
+	case err == nil:
+		if existing.IsDir() {
+			return fmt.Errorf("path is a directory: %s", path)
+		}
+		perm = existing.Mode().Perm()
+	case errors.Is(err, os.ErrNotExist):
+		existing = nil
+	default:
+		return err
+	}
+
+	dir := filepath.Dir(target)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if syncFile {
+		if err := tmp.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if existing != nil {
+		if err := copyOwnership(tmpPath, existing); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		return err
+	}
+	committed = true
+
+	if syncFile {
+		syncDir(dir)
+	}
+	return nil
+}
+
+// syncDir flushes directory metadata so a completed rename survives a crash.
+// Errors are ignored because not every platform supports fsync on directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// bytesSHA256 returns the hex-encoded SHA-256 digest of data.
+func bytesSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
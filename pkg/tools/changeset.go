@@ -0,0 +1,242 @@
+// Reviewable changesets for copy-on-write overlays.
+package tools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ChangeKind classifies a pending overlay change.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeModified ChangeKind = "modified"
+	ChangeDeleted  ChangeKind = "deleted"
+)
+
+// FileChange is one pending change recorded by an OverlayFS.
+type FileChange struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	Diff string     `json:"diff,omitempty"`
+}
+
+// Changeset lists the pending changes of an overlay, sorted by path.
+type Changeset struct {
+	Changes []FileChange `json:"changes"`
+}
+
+// Empty reports whether the changeset has no changes.
+func (c Changeset) Empty() bool {
+	return len(c.Changes) == 0
+}
+
+// Summary renders one line per change, e.g. "M /repo/main.go".
+func (c Changeset) Summary() string {
+	var sb strings.Builder
+	for _, change := range c.Changes {
+		mark := "M"
+		switch change.Kind {
+		case ChangeAdded:
+			mark = "A"
+		case ChangeDeleted:
+			mark = "D"
+		}
+		fmt.Fprintf(&sb, "%s %s\n", mark, change.Path)
+	}
+	return sb.String()
+}
+
+// Diff concatenates the unified diffs of all changes.
+func (c Changeset) Diff() string {
+	var sb strings.Builder
+	for _, change := range c.Changes {
+		sb.WriteString(change.Diff)
+	}
+	return sb.String()
+}
+
+// Changes computes the pending changes relative to the lower layer.
+// Files rewritten with identical content are not reported.
+func (o *OverlayFS) Changes() (Changeset, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var changes []FileChange
+	for path := range o.written {
+		newData, err := readAllFS(o.upper, path)
+		if err != nil {
+			return Changeset{}, fmt.Errorf("read overlay %s: %w", path, err)
+		}
+		oldData, err := readAllFS(o.lower, path)
+		switch {
+		case err == nil:
+			if bytes.Equal(oldData, newData) {
+				continue
+			}
+			changes = append(changes, FileChange{
+				Path: path,
+				Kind: ChangeModified,
+				Diff: unifiedDiff("a"+path, "b"+path, oldData, newData),
+			})
+		case errors.Is(err, fs.ErrNotExist):
+			changes = append(changes, FileChange{
+				Path: path,
+				Kind: ChangeAdded,
+				Diff: unifiedDiff("", "b"+path, nil, newData),
+			})
+		default:
+			return Changeset{}, fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+	for path := range o.deleted {
+		info, err := o.lower.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		oldData, err := readAllFS(o.lower, path)
+		if err != nil {
+			return Changeset{}, fmt.Errorf("read %s: %w", path, err)
+		}
+		changes = append(changes, FileChange{
+			Path: path,
+			Kind: ChangeDeleted,
+			Diff: unifiedDiff("a"+path, "", oldData, nil),
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return Changeset{Changes: changes}, nil
+}
+
+// CommitError reports a Commit that stopped after some changes reached the
+// lower layer. Applied changes are no longer pending; the rest stay staged.
+type CommitError struct {
+	Path    string
+	Applied []string
+	Pending []string
+	Err     error
+}
+
+func (e *CommitError) Error() string {
+	return fmt.Sprintf("commit %s: %v (partial commit: %d applied, %d still pending)", e.Path, e.Err, len(e.Applied), len(e.Pending))
+}
+
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// stagedWrite is an overlay file read into memory before Commit touches lower.
+type stagedWrite struct {
+	path string
+	data []byte
+	perm fs.FileMode
+}
+
+// Commit writes all pending changes to the lower layer and clears the overlay.
+// Every file is read, validated and given its parent directories before the
+// first write, so most failures leave lower untouched. Each file is replaced
+// atomically, but the changeset as a whole is not: a failure while applying
+// returns a *CommitError listing what was applied and what is still pending.
+// Writes are applied before deletions; deleted directories are removed deepest first.
+func (o *OverlayFS) Commit() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	written := sortedKeys(o.written)
+	deleted := sortedKeys(o.deleted)
+	staged := make([]stagedWrite, 0, len(written))
+	for _, path := range written {
+		data, err := readAllFS(o.upper, path)
+		if err != nil {
+			return fmt.Errorf("read overlay %s: %w", path, err)
+		}
+		info, err := o.upper.Stat(path)
+		if err != nil {
+			return fmt.Errorf("stat overlay %s: %w", path, err)
+		}
+		if _, err := o.lower.ValidatePath(path); err != nil {
+			return fmt.Errorf("commit %s: %w", path, err)
+		}
+		staged = append(staged, stagedWrite{path: path, data: data, perm: info.Mode().Perm()})
+	}
+	for _, path := range deleted {
+		if _, err := o.lower.ValidatePath(path); err != nil {
+			return fmt.Errorf("commit delete %s: %w", path, err)
+		}
+	}
+	for _, w := range staged {
+		if err := o.lower.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+			return fmt.Errorf("commit %s: %w", w.path, err)
+		}
+	}
+
+	var applied []string
+	fail := func(path string, err error) error {
+		return &CommitError{Path: path, Applied: applied, Pending: o.pendingLocked(), Err: err}
+	}
+	for _, w := range staged {
+		if err := o.lower.WriteFile(w.path, w.data, WriteOptions{Perm: w.perm, Sync: true}); err != nil {
+			return fail(w.path, err)
+		}
+		delete(o.written, w.path)
+		applied = append(applied, w.path)
+	}
+	for i := len(deleted) - 1; i >= 0; i-- {
+		path := deleted[i]
+		if err := o.lower.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fail(path, err)
+		}
+		delete(o.deleted, path)
+		applied = append(applied, path)
+	}
+
+	return o.clearUpperLocked(written)
+}
+
+// pendingLocked lists the paths still written or deleted in the overlay.
+func (o *OverlayFS) pendingLocked() []string {
+	pending := append(sortedKeys(o.written), sortedKeys(o.deleted)...)
+	sort.Strings(pending)
+	return pending
+}
+
+// Discard drops all pending changes without touching the lower layer.
+func (o *OverlayFS) Discard() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	written := sortedKeys(o.written)
+	o.written = map[string]struct{}{}
+	o.deleted = map[string]struct{}{}
+	return o.clearUpperLocked(written)
+}
+
+// clearUpperLocked empties the upper layer after its changes were committed or dropped.
+func (o *OverlayFS) clearUpperLocked(written []string) error {
+	if o.resetUpper != nil {
+		return o.resetUpper()
+	}
+	for _, path := range written {
+		if err := o.upper.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a set in lexical order.
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,224 @@
+// Tests for overlay changesets and snapshot execution.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestDirOverlayChangeset covers tool writes, run_shell writes, apply and discard.
+func TestDirOverlayChangeset(t *testing.T) {
+	root := t.TempDir()
+	overlayDir := t.TempDir()
+	editedPath := filepath.Join(root, "edited.txt")
+	removedPath := filepath.Join(root, "removed.txt")
+	if err := os.WriteFile(editedPath, []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatalf("seed edited: %v", err)
+	}
+	if err := os.WriteFile(removedPath, []byte("bye\n"), 0o644); err != nil {
+		t.Fatalf("seed removed: %v", err)
+	}
+
+	overlay, err := NewDirOverlayFS(NewOSFS([]string{root}), root, overlayDir)
+	if err != nil {
+		t.Fatalf("NewDirOverlayFS: %v", err)
+	}
+	t.Cleanup(func() { _ = overlay.Close() })
+	toolCtx := Context{
+		MaxReadBytes: DefaultMaxReadBytes,
+		AllowedDirs:  []string{root},
+		FS:           overlay,
+		Ctx:          context.Background(),
+	}
+	writeTool := &writeFileTool{ctx: toolCtx}
+	shellTool := &runShellTool{ctx: toolCtx}
+
+	resp := decodeToolResponse(t, mustExecute(t, writeTool.execute, `{"path":"`+editedPath+`","content":"one\nTWO\n","mode":"overwrite"}`))
+	if !resp.OK {
+		t.Fatalf("write failed: %s", resp.Err)
+	}
+	resp = decodeToolResponse(t, mustExecute(t, shellTool.execute, `{"command":"mv `+removedPath+` `+filepath.Join(root, "moved.txt")+`","working_dir":"`+root+`"}`))
+	if !resp.OK {
+		t.Fatalf("run_shell failed: %s", resp.Err)
+	}
+
+	if _, err := os.Stat(removedPath); err != nil {
+		t.Fatalf("run_shell must not touch the real tree: %v", err)
+	}
+	data, err := os.ReadFile(editedPath)
+	if err != nil || string(data) != "one\ntwo\n" {
+		t.Fatalf("real file changed before apply: %q (%v)", string(data), err)
+	}
+
+	changes, err := overlay.Changes()
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+	summary := changes.Summary()
+	for _, want := range []string{
+		"M " + editedPath,
+		"A " + filepath.Join(root, "moved.txt"),
+		"D " + removedPath,
+	} {
+		if !strings.Contains(summary, want) {
+			t.Fatalf("summary missing %q:\n%s", want, summary)
+		}
+	}
+	if diff := changes.Diff(); !strings.Contains(diff, "-two\n+TWO\n") {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+
+	if err := overlay.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	data, err = os.ReadFile(editedPath)
+	if err != nil || string(data) != "one\nTWO\n" {
+		t.Fatalf("expected committed content, got %q (%v)", string(data), err)
+	}
+	if _, err := os.Stat(removedPath); !os.IsNotExist(err) {
+		t.Fatalf("expected removed file to be deleted, got %v", err)
+	}
+	if moved, err := os.ReadFile(filepath.Join(root, "moved.txt")); err != nil || string(moved) != "bye\n" {
+		t.Fatalf("expected moved file, got %q (%v)", string(moved), err)
+	}
+
+	if err := overlay.WriteFile(editedPath, []byte("scrap\n"), WriteOptions{}); err != nil {
+		t.Fatalf("overlay write: %v", err)
+	}
+	if err := overlay.Discard(); err != nil {
+		t.Fatalf("Discard: %v", err)
+	}
+	changes, err = overlay.Changes()
+	if err != nil || !changes.Empty() {
+		t.Fatalf("expected no changes after discard, got %+v (%v)", changes, err)
+	}
+	entries, err := os.ReadDir(overlayDir)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected empty overlay dir, got %v (%v)", entries, err)
+	}
+}
+
+// TestUnifiedDiff checks hunk headers and context lines.
+func TestUnifiedDiff(t *testing.T) {
+	diff := unifiedDiff("a/f", "b/f", []byte("1\n2\n3\n4\n5\n6\n7\n8\n"), []byte("1\n2\n3\n4\nfive\n6\n7\n8\n"))
+	want := "--- a/f\n+++ b/f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"
+	if diff != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", diff, want)
+	}
+}
+
+// TestOverlaySnapshotSync checks that the run_shell snapshot is reused, picks up
+// overlay writes between calls and reports symlinks instead of dropping them.
+func TestOverlaySnapshotSync(t *testing.T) {
+	root := t.TempDir()
+	notePath := filepath.Join(root, "note.txt")
+	if err := os.WriteFile(notePath, []byte("one\n"), 0o644); err != nil {
+		t.Fatalf("seed note: %v", err)
+	}
+	if err := os.Symlink("note.txt", filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	overlay, err := NewDirOverlayFS(NewOSFS([]string{root}), root, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirOverlayFS: %v", err)
+	}
+	t.Cleanup(func() { _ = overlay.Close() })
+	shellTool := &runShellTool{ctx: Context{
+		MaxReadBytes: DefaultMaxReadBytes,
+		AllowedDirs:  []string{root},
+		FS:           overlay,
+		Ctx:          context.Background(),
+	}}
+	run := func(command string) commandResult {
+		t.Helper()
+		resp := decodeToolResponse(t, mustExecute(t, shellTool.execute, `{"command":"`+command+`","working_dir":"`+root+`"}`))
+		if !resp.OK {
+			t.Fatalf("run_shell %q failed: %s", command, resp.Err)
+		}
+		var result commandResult
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			t.Fatalf("decode result: %v", err)
+		}
+		return result
+	}
+
+	result := run("cat note.txt")
+	if result.Stdout != "one\n" || len(result.Omitted) != 1 || result.Omitted[0] != filepath.Join(root, "link") {
+		t.Fatalf("unexpected first run: %+v", result)
+	}
+	snapshotDir := overlay.snapshot.dir
+
+	if err := overlay.WriteFile(notePath, []byte("two\n"), WriteOptions{}); err != nil {
+		t.Fatalf("overlay write: %v", err)
+	}
+	result = run("cat note.txt")
+	if result.Stdout != "two\n" {
+		t.Fatalf("expected snapshot to pick up the overlay write, got %q", result.Stdout)
+	}
+	if overlay.snapshot.dir != snapshotDir {
+		t.Fatalf("expected snapshot to be reused")
+	}
+
+	result = run("ln -s note.txt made")
+	if !slices.Contains(result.Omitted, filepath.Join(root, "made")) {
+		t.Fatalf("expected created symlink to be reported, got %+v", result.Omitted)
+	}
+	if _, err := overlay.Stat(filepath.Join(root, "made")); !os.IsNotExist(err) {
+		t.Fatalf("symlink must not be staged, got %v", err)
+	}
+
+	if err := overlay.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(snapshotDir); !os.IsNotExist(err) {
+		t.Fatalf("expected snapshot dir to be removed, got %v", err)
+	}
+}
+
+// failingWriteFS fails WriteFile for one path.
+type failingWriteFS struct {
+	FileSystem
+	fail string
+}
+
+func (f failingWriteFS) WriteFile(path string, data []byte, opts WriteOptions) error {
+	if path == f.fail {
+		return errors.New("disk full")
+	}
+	return f.FileSystem.WriteFile(path, data, opts)
+}
+
+// TestOverlayPartialCommit checks that a failed write reports what was applied
+// and leaves the rest staged.
+func TestOverlayPartialCommit(t *testing.T) {
+	root := t.TempDir()
+	paths := []string{filepath.Join(root, "a.txt"), filepath.Join(root, "b.txt"), filepath.Join(root, "c.txt")}
+	overlay := NewOverlayFS(failingWriteFS{FileSystem: NewOSFS([]string{root}), fail: paths[1]}, nil)
+	for _, path := range paths {
+		if err := overlay.WriteFile(path, []byte("new\n"), WriteOptions{}); err != nil {
+			t.Fatalf("overlay write: %v", err)
+		}
+	}
+
+	err := overlay.Commit()
+	var partial *CommitError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected CommitError, got %v", err)
+	}
+	if partial.Path != paths[1] || !slices.Equal(partial.Applied, paths[:1]) || !slices.Equal(partial.Pending, paths[1:]) {
+		t.Fatalf("unexpected partial commit: %+v", partial)
+	}
+	if _, err := os.Stat(paths[0]); err != nil {
+		t.Fatalf("expected applied file on disk: %v", err)
+	}
+	changes, err := overlay.Changes()
+	if err != nil || len(changes.Changes) != 2 || changes.Changes[0].Path != paths[1] {
+		t.Fatalf("expected remaining changes to stay staged, got %+v (%v)", changes, err)
+	}
+}
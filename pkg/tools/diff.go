@@ -0,0 +1,168 @@
+// Line-based unified diff rendering for overlay changesets.
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContextLines is the number of unchanged lines shown around each hunk.
+	diffContextLines = 3
+	// diffMaxCells bounds the LCS table so huge files do not exhaust memory.
+	diffMaxCells = 4_000_000
+)
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+}
+
+// unifiedDiff renders a unified diff between a and b. Empty names default to /dev/null.
+func unifiedDiff(oldName, newName string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	if oldName == "" {
+		oldName = "/dev/null"
+	}
+	if newName == "" {
+		newName = "/dev/null"
+	}
+	header := fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName)
+	if bytes.IndexByte(a, 0) >= 0 || bytes.IndexByte(b, 0) >= 0 {
+		return header + "Binary files differ\n"
+	}
+
+	oldLines := splitLines(string(a))
+	newLines := splitLines(string(b))
+	if len(oldLines)*len(newLines) > diffMaxCells {
+		return header + fmt.Sprintf("Diff omitted: files too large (%d and %d lines)\n", len(oldLines), len(newLines))
+	}
+
+	ops := diffLines(oldLines, newLines)
+	var sb strings.Builder
+	sb.WriteString(header)
+	writeHunks(&sb, ops)
+	return sb.String()
+}
+
+// splitLines splits text into lines, keeping a marker for a missing trailing newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	last := lines[len(lines)-1]
+	if !strings.HasSuffix(last, "\n") {
+		lines[len(lines)-1] = last + "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
+
+// diffLines computes an edit script with a longest-common-subsequence table.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', line: a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{kind: '-', line: a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{kind: '+', line: b[j]})
+	}
+	return ops
+}
+
+// writeHunks groups an edit script into @@ hunks with surrounding context.
+func writeHunks(sb *strings.Builder, ops []diffOp) {
+	for start := 0; start < len(ops); {
+		// Find the next change.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			return
+		}
+
+		// Extend the hunk until the gap between changes exceeds twice the context.
+		last := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				last = k
+			} else if k-last > 2*diffContextLines {
+				break
+			}
+		}
+
+		from := max(first-diffContextLines, start)
+		to := min(last+diffContextLines+1, len(ops))
+
+		oldStart, newStart := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				oldStart++
+			}
+			if op.kind != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+
+		fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range ops[from:to] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+		}
+		start = to
+	}
+}
@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/openai/openai-go"
+)
+
+// EnvelopeMode selects which tool results are wrapped in a <tool_result>
+// envelope before they reach the model.
+type EnvelopeMode string
+
+const (
+	// EnvelopeUntrusted wraps results of tools that return outside content:
+	// files, command output, skill scripts, untrusted skills, and MCP and
+	// external tools not marked Trusted. It is the default.
+	EnvelopeUntrusted EnvelopeMode = "untrusted"
+	// EnvelopeAll wraps every result; trusted tools are labeled as such.
+	EnvelopeAll EnvelopeMode = "all"
+	// EnvelopeOff passes results through unchanged.
+	EnvelopeOff EnvelopeMode = "off"
+)
+
+// ParseEnvelopeMode validates a mode; empty means EnvelopeUntrusted.
+func ParseEnvelopeMode(value string) (EnvelopeMode, error) {
+	switch mode := EnvelopeMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return EnvelopeUntrusted, nil
+	case EnvelopeUntrusted, EnvelopeAll, EnvelopeOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown tool result envelope %q (want untrusted, all or off)", value)
+	}
+}
+
+// trustedOutput is implemented by tools whose results can carry no outside
+// content for some calls: their own metadata, or the files of a skill that is
+// not untrusted.
+type trustedOutput interface {
+	trustedOutput(argText string) bool
+}
+
+func (t *writeFileTool) trustedOutput(string) bool    { return true }
+func (t *searchSkillsTool) trustedOutput(string) bool { return true }
+func (e *externalTool) trustedOutput(string) bool     { return e.spec.Trusted }
+
+func (t *loadSkillTool) trustedOutput(argText string) bool {
+	var args struct {
+		Name string `json:"name"`
+	}
+	return json.Unmarshal([]byte(argText), &args) == nil && trustedSkill(t.skills[args.Name])
+}
+
+func (t *readSkillResourceTool) trustedOutput(argText string) bool {
+	var args struct {
+		Skill string `json:"skill"`
+	}
+	return json.Unmarshal([]byte(argText), &args) == nil && trustedSkill(t.skills[args.Skill])
+}
+
+// trustedOutput trusts skill:// paths of skills that are not untrusted, like
+// read_skill_resource. Other files are outside content.
+func (t *readFileTool) trustedOutput(argText string) bool {
+	var args struct {
+		Path string `json:"path"`
+	}
+	if json.Unmarshal([]byte(argText), &args) != nil || t.ctx.lookupSkill == nil {
+		return false
+	}
+	name, _, ok := skills.ParseURI(args.Path)
+	return ok && trustedSkill(t.ctx.lookupSkill(name))
+}
+
+// trustedSkill reports whether the content of skill may reach the model
+// unwrapped. Skills whose trust was never evaluated count as trusted, as they
+// do for tool permissions.
+func trustedSkill(skill *skills.Skill) bool {
+	return skill != nil && skill.Trust != skills.TrustUntrusted && !skill.Quarantined
+}
+
+// TrustedOutput reports whether the result of call is trusted. Unknown tools
+// are not.
+func (t *Registry) TrustedOutput(call openai.ChatCompletionMessageToolCall) bool {
+	t.mu.RLock()
+	impl := t.registry[call.Function.Name]
+	t.mu.RUnlock()
+	trusted, ok := impl.(trustedOutput)
+	return ok && trusted.trustedOutput(call.Function.Arguments)
+}
+
+// WrapResult returns output as the model should see it under mode. Wrapped
+// results look like
+//
+//	<tool_result tool="read_file" trust="untrusted" path="notes.md">
+//	{"ok":true,...}
+//	</tool_result>
+//
+// with the skill, path, command and working_dir arguments of the call, and
+// the skill of a script tool, as provenance. Envelope tags inside output are escaped so
+// content cannot close the envelope or forge another one.
+func (t *Registry) WrapResult(mode EnvelopeMode, call openai.ChatCompletionMessageToolCall, output string) string {
+	trusted := t.TrustedOutput(call)
+	if mode == EnvelopeOff || (mode != EnvelopeAll && trusted) {
+		return output
+	}
+	trust := "untrusted"
+	if trusted {
+		trust = "trusted"
+	}
+	attrs := [][2]string{{"tool", call.Function.Name}, {"trust", trust}}
+	var args map[string]any
+	_ = json.Unmarshal([]byte(call.Function.Arguments), &args)
+	skill, _ := args["skill"].(string)
+	if call.Function.Name == "load_skill" {
+		skill, _ = args["name"].(string)
+	}
+	if owner := t.scriptToolOwner(call.Function.Name); owner != nil {
+		skill = owner.Name
+	}
+	if skill != "" {
+		attrs = append(attrs, [2]string{"skill", skill})
+	}
+	for _, key := range []string{"path", "command", "working_dir"} {
+		if value, ok := args[key].(string); ok && value != "" {
+			attrs = append(attrs, [2]string{key, value})
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<tool_result")
+	for _, attr := range attrs {
+		fmt.Fprintf(&sb, " %s=\"%s\"", attr[0], escapeAttr(attr[1]))
+	}
+	sb.WriteString(">\n")
+	sb.WriteString(escapeEnvelopeTags(output))
+	sb.WriteString("\n</tool_result>")
+	return sb.String()
+}
+
+// envelopeTag matches opening and closing envelope tags in any case.
+var envelopeTag = regexp.MustCompile(`(?i)<(/?\s*tool_result)`)
+
+// escapeEnvelopeTags turns "<tool_result" and "</tool_result" into
+// "&lt;tool_result" and "&lt;/tool_result".
+func escapeEnvelopeTags(s string) string {
+	return envelopeTag.ReplaceAllString(s, "&lt;$1")
+}
+
+var attrEscaper = strings.NewReplacer(`&`, "&amp;", `<`, "&lt;", `>`, "&gt;", `"`, "&quot;", "\n", "&#10;", "\r", "&#13;")
+
+// escapeAttr escapes a provenance value for a double-quoted attribute.
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package tools
+
+import "os"
+
+// copyOwnership is a no-op on platforms without POSIX ownership.
+func copyOwnership(string, os.FileInfo) error {
+	return nil
+}
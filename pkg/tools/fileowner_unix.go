@@ -0,0 +1,22 @@
+//go:build unix
+
+package tools
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// copyOwnership applies the uid/gid of info to path.
+// Permission errors are ignored so unprivileged users can still replace files they may write.
+func copyOwnership(path string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if err := os.Lchown(path, int(stat.Uid), int(stat.Gid)); err != nil && !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Filesystem abstraction used by the file tools.
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// ErrReadOnly is returned by write operations on a read-only filesystem.
+var ErrReadOnly = errors.New("filesystem is read-only")
+
+// FileSystem is the storage backend behind read_file and write_file.
+// Paths passed to methods other than ValidatePath must already be validated.
+type FileSystem interface {
+	// ValidatePath checks that path is permitted and returns its canonical absolute form.
+	ValidatePath(path string) (string, error)
+	Stat(path string) (fs.FileInfo, error)
+	Open(path string) (io.ReadCloser, error)
+	ReadDir(path string) ([]fs.DirEntry, error)
+	MkdirAll(path string, perm fs.FileMode) error
+	// WriteFile replaces the file content as a single step.
+	WriteFile(path string, data []byte, opts WriteOptions) error
+	Remove(path string) error
+}
+
+// WriteOptions controls how FileSystem.WriteFile stores data.
+type WriteOptions struct {
+	// Perm is used for new files; existing files keep their permissions.
+	Perm fs.FileMode
+	// Sync requests the data be flushed to stable storage.
+	Sync bool
+}
+
+// fileSystem returns the configured filesystem, defaulting to the OS restricted
+// to AllowedDirs. Inside a Registry, skill:// paths resolve to the loaded skills.
+func (c Context) fileSystem() FileSystem {
+	fsys := c.FS
+	if fsys == nil {
+		fsys = NewOSFS(c.AllowedDirs)
+	}
+	if c.lookupSkill != nil {
+		return &skillFS{base: fsys, lookup: c.lookupSkill}
+	}
+	return fsys
+}
+
+// validateFileExistsFS checks if a file exists in fsys and is not a directory.
+func validateFileExistsFS(fsys FileSystem, path string) error {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("path is a directory: %s", path)
+	}
+	return nil
+}
+
+// readAllFS reads a whole file from fsys.
+func readAllFS(fsys FileSystem, path string) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return io.ReadAll(f)
+}
+
+// fileInfo is a static fs.FileInfo used by the non-OS filesystems.
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) Mode() fs.FileMode  { return i.mode }
+func (i fileInfo) ModTime() time.Time { return i.modTime }
+func (i fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i fileInfo) Sys() any           { return nil }
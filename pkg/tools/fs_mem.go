@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFS is an in-memory FileSystem, useful as a scratch space or in tests.
+// Paths are absolute host-style paths; AllowedDirs restricts them like OSFS.
+type MemFS struct {
+	AllowedDirs []string
+
+	mu    sync.RWMutex
+	nodes map[string]*memNode
+}
+
+type memNode struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemFS builds an empty in-memory filesystem restricted to allowedDirs.
+func NewMemFS(allowedDirs ...string) *MemFS {
+	return &MemFS{
+		AllowedDirs: allowedDirs,
+		nodes:       map[string]*memNode{},
+	}
+}
+
+func (m *MemFS) ValidatePath(path string) (string, error) {
+	return validatePathWithAllowedDirs(path, m.AllowedDirs)
+}
+
+// lookup returns the node for path; filesystem roots always exist as directories.
+func (m *MemFS) lookup(path string) (*memNode, bool) {
+	path = filepath.Clean(path)
+	if node, ok := m.nodes[path]; ok {
+		return node, true
+	}
+	if filepath.Dir(path) == path {
+		return &memNode{mode: fs.ModeDir | 0o755}, true
+	}
+	return nil, false
+}
+
+func (m *MemFS) Stat(path string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	node, ok := m.lookup(path)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+	}
+	return node.info(filepath.Base(path)), nil
+}
+
+func (m *MemFS) Open(path string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	node, ok := m.lookup(path)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	if node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fmt.Errorf("is a directory")}
+	}
+	return io.NopCloser(bytes.NewReader(bytes.Clone(node.data))), nil
+}
+
+func (m *MemFS) ReadDir(path string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	path = filepath.Clean(path)
+	node, ok := m.lookup(path)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: path, Err: fs.ErrNotExist}
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: path, Err: fmt.Errorf("not a directory")}
+	}
+
+	var entries []fs.DirEntry
+	for p, child := range m.nodes {
+		if p != path && filepath.Dir(p) == path {
+			entries = append(entries, fs.FileInfoToDirEntry(child.info(filepath.Base(p))))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *MemFS) MkdirAll(path string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mkdirAllLocked(filepath.Clean(path), perm)
+}
+
+func (m *MemFS) mkdirAllLocked(path string, perm fs.FileMode) error {
+	if node, ok := m.lookup(path); ok {
+		if !node.mode.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: path, Err: fmt.Errorf("not a directory")}
+		}
+		return nil
+	}
+	if err := m.mkdirAllLocked(filepath.Dir(path), perm); err != nil {
+		return err
+	}
+	m.nodes[path] = &memNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+	return nil
+}
+
+func (m *MemFS) WriteFile(path string, data []byte, opts WriteOptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	parent, ok := m.lookup(filepath.Dir(path))
+	if !ok || !parent.mode.IsDir() {
+		return &fs.PathError{Op: "write", Path: path, Err: fs.ErrNotExist}
+	}
+
+	perm := opts.Perm.Perm()
+	if perm == 0 {
+		perm = 0o644
+	}
+	if existing, ok := m.nodes[path]; ok {
+		if existing.mode.IsDir() {
+			return &fs.PathError{Op: "write", Path: path, Err: fmt.Errorf("is a directory")}
+		}
+		perm = existing.mode.Perm()
+	}
+	m.nodes[path] = &memNode{data: bytes.Clone(data), mode: perm, modTime: time.Now()}
+	return nil
+}
+
+func (m *MemFS) Remove(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	node, ok := m.nodes[path]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: path, Err: fs.ErrNotExist}
+	}
+	if node.mode.IsDir() {
+		prefix := path + string(filepath.Separator)
+		for p := range m.nodes {
+			if strings.HasPrefix(p, prefix) {
+				return &fs.PathError{Op: "remove", Path: path, Err: fmt.Errorf("directory not empty")}
+			}
+		}
+	}
+	delete(m.nodes, path)
+	return nil
+}
+
+func (n *memNode) info(name string) fs.FileInfo {
+	return fileInfo{
+		name:    name,
+		size:    int64(len(n.data)),
+		mode:    n.mode,
+		modTime: n.modTime,
+	}
+}
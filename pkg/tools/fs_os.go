@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// OSFS is a FileSystem backed by the host filesystem and restricted to AllowedDirs.
+// An empty AllowedDirs permits any path.
+type OSFS struct {
+	AllowedDirs []string
+}
+
+// NewOSFS builds an OS filesystem restricted to the given directories.
+func NewOSFS(allowedDirs []string) *OSFS {
+	return &OSFS{AllowedDirs: allowedDirs}
+}
+
+func (f *OSFS) ValidatePath(path string) (string, error) {
+	return validatePathWithAllowedDirs(path, f.AllowedDirs)
+}
+
+func (f *OSFS) Stat(path string) (fs.FileInfo, error) {
+	return os.Stat(path)
+}
+
+func (f *OSFS) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+func (f *OSFS) ReadDir(path string) ([]fs.DirEntry, error) {
+	return os.ReadDir(path)
+}
+
+func (f *OSFS) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// WriteFile replaces path atomically, preserving the mode and ownership of an existing file.
+func (f *OSFS) WriteFile(path string, data []byte, opts WriteOptions) error {
+	perm := opts.Perm
+	if perm == 0 {
+		perm = 0o644
+	}
+	return writeFileAtomic(path, data, perm, opts.Sync)
+}
+
+func (f *OSFS) Remove(path string) error {
+	return os.Remove(path)
+}
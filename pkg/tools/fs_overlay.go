@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// OverlayFS is a copy-on-write FileSystem: reads fall through to lower,
+// while writes and deletions are recorded in upper and never touch lower.
+// Path validation is delegated to lower so the overlay keeps its policy.
+type OverlayFS struct {
+	lower FileSystem
+	upper FileSystem
+	// root is the tree snapshotted for run_shell; empty disables snapshots.
+	root string
+	// resetUpper empties the upper layer after Commit or Discard.
+	resetUpper func() error
+
+	// shellMu serializes run_shell snapshots; snapshot is reused between calls.
+	shellMu  sync.Mutex
+	snapshot *shellSnapshot
+
+	mu      sync.RWMutex
+	written map[string]struct{}
+	deleted map[string]struct{}
+}
+
+// NewOverlayFS layers upper on top of lower. A nil upper uses an in-memory filesystem.
+func NewOverlayFS(lower, upper FileSystem) *OverlayFS {
+	o := &OverlayFS{
+		lower:   lower,
+		upper:   upper,
+		written: map[string]struct{}{},
+		deleted: map[string]struct{}{},
+	}
+	if upper == nil {
+		o.upper = NewMemFS()
+		o.resetUpper = func() error {
+			o.upper = NewMemFS()
+			return nil
+		}
+	}
+	return o
+}
+
+// Root returns the directory tree that run_shell snapshots, if any.
+func (o *OverlayFS) Root() string {
+	return o.root
+}
+
+func (o *OverlayFS) ValidatePath(path string) (string, error) {
+	return o.lower.ValidatePath(path)
+}
+
+// isDeletedLocked reports whether path or one of its ancestors is whited out.
+func (o *OverlayFS) isDeletedLocked(path string) bool {
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		if _, ok := o.deleted[p]; ok {
+			return true
+		}
+		if filepath.Dir(p) == p {
+			return false
+		}
+	}
+}
+
+func (o *OverlayFS) Stat(path string) (fs.FileInfo, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if info, err := o.upper.Stat(path); err == nil {
+		return info, nil
+	}
+	if o.isDeletedLocked(path) {
+		return nil, &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+	}
+	return o.lower.Stat(path)
+}
+
+func (o *OverlayFS) Open(path string) (io.ReadCloser, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if info, err := o.upper.Stat(path); err == nil && !info.IsDir() {
+		return o.upper.Open(path)
+	}
+	if o.isDeletedLocked(path) {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return o.lower.Open(path)
+}
+
+func (o *OverlayFS) ReadDir(path string) ([]fs.DirEntry, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	merged := map[string]fs.DirEntry{}
+	found := false
+	if !o.isDeletedLocked(path) {
+		if entries, err := o.lower.ReadDir(path); err == nil {
+			found = true
+			for _, entry := range entries {
+				if _, gone := o.deleted[filepath.Join(path, entry.Name())]; !gone {
+					merged[entry.Name()] = entry
+				}
+			}
+		}
+	}
+	if entries, err := o.upper.ReadDir(path); err == nil {
+		found = true
+		for _, entry := range entries {
+			merged[entry.Name()] = entry
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: path, Err: fs.ErrNotExist}
+	}
+
+	out := make([]fs.DirEntry, 0, len(merged))
+	for _, entry := range merged {
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, nil
+}
+
+func (o *OverlayFS) MkdirAll(path string, perm fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.mkdirAllLocked(path, perm)
+}
+
+func (o *OverlayFS) mkdirAllLocked(path string, perm fs.FileMode) error {
+	if err := o.upper.MkdirAll(path, perm); err != nil {
+		return err
+	}
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		delete(o.deleted, p)
+		if filepath.Dir(p) == p {
+			break
+		}
+	}
+	return nil
+}
+
+// WriteFile stores data in upper; a file copied up from lower keeps its permissions.
+func (o *OverlayFS) WriteFile(path string, data []byte, opts WriteOptions) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	path = filepath.Clean(path)
+
+	if _, err := o.upper.Stat(path); err != nil && !o.isDeletedLocked(path) {
+		if info, err := o.lower.Stat(path); err == nil {
+			if info.IsDir() {
+				return &fs.PathError{Op: "write", Path: path, Err: fmt.Errorf("is a directory")}
+			}
+			opts.Perm = info.Mode().Perm()
+		}
+	}
+	if err := o.mkdirAllLocked(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := o.upper.WriteFile(path, data, opts); err != nil {
+		return err
+	}
+	o.written[path] = struct{}{}
+	delete(o.deleted, path)
+	return nil
+}
+
+// Remove deletes path from upper and hides any copy in lower.
+func (o *OverlayFS) Remove(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	path = filepath.Clean(path)
+
+	inUpper := false
+	if info, err := o.upper.Stat(path); err == nil {
+		inUpper = true
+		if info.IsDir() {
+			if err := o.checkEmptyLocked(path); err != nil {
+				return err
+			}
+		}
+	}
+	inLower := false
+	if !o.isDeletedLocked(path) {
+		if info, err := o.lower.Stat(path); err == nil {
+			inLower = true
+			if info.IsDir() {
+				if err := o.checkEmptyLocked(path); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if !inUpper && !inLower {
+		return &fs.PathError{Op: "remove", Path: path, Err: fs.ErrNotExist}
+	}
+	if inUpper {
+		if err := o.upper.Remove(path); err != nil {
+			return err
+		}
+		delete(o.written, path)
+	}
+	if inLower {
+		o.deleted[path] = struct{}{}
+	}
+	return nil
+}
+
+// checkEmptyLocked fails when a directory still has visible children.
+func (o *OverlayFS) checkEmptyLocked(path string) error {
+	if entries, err := o.upper.ReadDir(path); err == nil && len(entries) > 0 {
+		return &fs.PathError{Op: "remove", Path: path, Err: fmt.Errorf("directory not empty")}
+	}
+	if entries, err := o.lower.ReadDir(path); err == nil {
+		for _, entry := range entries {
+			if _, gone := o.deleted[filepath.Join(path, entry.Name())]; !gone {
+				return &fs.PathError{Op: "remove", Path: path, Err: fmt.Errorf("directory not empty")}
+			}
+		}
+	}
+	return nil
+}
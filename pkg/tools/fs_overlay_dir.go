@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewDirOverlayFS builds a copy-on-write overlay whose upper layer is stored on disk.
+// Writes to paths under root land in dir instead, mirroring the tree layout.
+// run_shell commands are executed in a snapshot of root so their writes are captured too.
+// Files already present in dir are picked up as pending changes; deletions are tracked in memory only.
+func NewDirOverlayFS(lower FileSystem, root, dir string) (*OverlayFS, error) {
+	root, err := filepath.Abs(strings.TrimSpace(root))
+	if err != nil {
+		return nil, fmt.Errorf("overlay root: %w", err)
+	}
+	dir, err = filepath.Abs(strings.TrimSpace(dir))
+	if err != nil {
+		return nil, fmt.Errorf("overlay dir: %w", err)
+	}
+	if isWithinDir(dir, root) {
+		return nil, fmt.Errorf("overlay dir %s must not be inside overlay root %s", dir, root)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create overlay dir: %w", err)
+	}
+
+	layer := &dirLayer{root: root, dir: dir}
+	o := NewOverlayFS(lower, layer)
+	o.root = root
+	o.resetUpper = func() error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		o.written[filepath.Join(root, rel)] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan overlay dir: %w", err)
+	}
+	return o, nil
+}
+
+// dirLayer stores files for paths under root inside dir.
+type dirLayer struct {
+	root string
+	dir  string
+}
+
+func (l *dirLayer) ValidatePath(path string) (string, error) {
+	if _, err := l.hostPath(path); err != nil {
+		return "", err
+	}
+	return filepath.Clean(path), nil
+}
+
+// hostPath maps a path under root to its location inside dir.
+func (l *dirLayer) hostPath(path string) (string, error) {
+	rel, err := filepath.Rel(l.root, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &fs.PathError{Op: "overlay", Path: path, Err: fmt.Errorf("path outside overlay root %s", l.root)}
+	}
+	return filepath.Join(l.dir, rel), nil
+}
+
+func (l *dirLayer) Stat(path string) (fs.FileInfo, error) {
+	host, err := l.hostPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(host)
+}
+
+func (l *dirLayer) Open(path string) (io.ReadCloser, error) {
+	host, err := l.hostPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(host)
+}
+
+func (l *dirLayer) ReadDir(path string) ([]fs.DirEntry, error) {
+	host, err := l.hostPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(host)
+}
+
+func (l *dirLayer) MkdirAll(path string, perm fs.FileMode) error {
+	host, err := l.hostPath(path)
+	if err != nil {
+		// Ancestors of root are implied; only paths inside root are materialized.
+		if isWithinDir(l.root, path) {
+			return nil
+		}
+		return err
+	}
+	return os.MkdirAll(host, perm)
+}
+
+func (l *dirLayer) WriteFile(path string, data []byte, opts WriteOptions) error {
+	host, err := l.hostPath(path)
+	if err != nil {
+		return err
+	}
+	perm := opts.Perm
+	if perm == 0 {
+		perm = 0o644
+	}
+	return writeFileAtomic(host, data, perm, opts.Sync)
+}
+
+func (l *dirLayer) Remove(path string) error {
+	host, err := l.hostPath(path)
+	if err != nil {
+		return err
+	}
+	return os.Remove(host)
+}
+
+// isWithinDir reports whether path equals dir or lies beneath it.
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
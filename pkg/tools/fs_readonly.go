@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"io"
+	"io/fs"
+)
+
+// ReadOnlyFS wraps a FileSystem and rejects every write with ErrReadOnly.
+type ReadOnlyFS struct {
+	base FileSystem
+}
+
+// NewReadOnlyFS builds a read-only view of base.
+func NewReadOnlyFS(base FileSystem) *ReadOnlyFS {
+	return &ReadOnlyFS{base: base}
+}
+
+func (r *ReadOnlyFS) ValidatePath(path string) (string, error) {
+	return r.base.ValidatePath(path)
+}
+
+func (r *ReadOnlyFS) Stat(path string) (fs.FileInfo, error) {
+	return r.base.Stat(path)
+}
+
+func (r *ReadOnlyFS) Open(path string) (io.ReadCloser, error) {
+	return r.base.Open(path)
+}
+
+func (r *ReadOnlyFS) ReadDir(path string) ([]fs.DirEntry, error) {
+	return r.base.ReadDir(path)
+}
+
+func (r *ReadOnlyFS) MkdirAll(path string, _ fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: path, Err: ErrReadOnly}
+}
+
+func (r *ReadOnlyFS) WriteFile(path string, _ []byte, _ WriteOptions) error {
+	return &fs.PathError{Op: "write", Path: path, Err: ErrReadOnly}
+}
+
+func (r *ReadOnlyFS) Remove(path string) error {
+	return &fs.PathError{Op: "remove", Path: path, Err: ErrReadOnly}
+}
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+)
+
+// skillFS serves skill://<name>/<path> from the loaded skills, so read_file
+// works on skills that have no directory on disk. Other paths go to base.
+// Skill files are read-only.
+type skillFS struct {
+	base   FileSystem
+	lookup func(name string) *skills.Skill
+}
+
+// skillFile returns the skill and cleaned path of a skill:// URI; ok is false
+// for other paths.
+func (f *skillFS) skillFile(p string) (skill *skills.Skill, rel string, ok bool, err error) {
+	name, rel, ok := skills.ParseURI(p)
+	if !ok {
+		return nil, "", false, nil
+	}
+	skill = f.lookup(name)
+	if skill == nil {
+		return nil, "", true, fmt.Errorf("unknown skill: %s", name)
+	}
+	return skill, path.Clean(rel), true, nil
+}
+
+func (f *skillFS) ValidatePath(p string) (string, error) {
+	skill, rel, ok, err := f.skillFile(p)
+	if !ok {
+		return f.base.ValidatePath(p)
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := skill.ResolveResource(rel); err != nil {
+		return "", err
+	}
+	return skills.URIScheme + skill.Name + "/" + rel, nil
+}
+
+func (f *skillFS) Stat(p string) (fs.FileInfo, error) {
+	skill, rel, ok, err := f.skillFile(p)
+	if !ok {
+		return f.base.Stat(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	file, err := skill.OpenResource(rel)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return file.Stat()
+}
+
+func (f *skillFS) Open(p string) (io.ReadCloser, error) {
+	skill, rel, ok, err := f.skillFile(p)
+	if !ok {
+		return f.base.Open(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return skill.OpenResource(rel)
+}
+
+func (f *skillFS) ReadDir(p string) ([]fs.DirEntry, error) {
+	skill, rel, ok, err := f.skillFile(p)
+	if !ok {
+		return f.base.ReadDir(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(skill.FS(), rel)
+}
+
+func (f *skillFS) MkdirAll(p string, perm fs.FileMode) error {
+	if _, _, ok, _ := f.skillFile(p); ok {
+		return &fs.PathError{Op: "mkdir", Path: p, Err: ErrReadOnly}
+	}
+	return f.base.MkdirAll(p, perm)
+}
+
+func (f *skillFS) WriteFile(p string, data []byte, opts WriteOptions) error {
+	if _, _, ok, _ := f.skillFile(p); ok {
+		return &fs.PathError{Op: "write", Path: p, Err: ErrReadOnly}
+	}
+	return f.base.WriteFile(p, data, opts)
+}
+
+func (f *skillFS) Remove(p string) error {
+	if _, _, ok, _ := f.skillFile(p); ok {
+		return &fs.PathError{Op: "remove", Path: p, Err: ErrReadOnly}
+	}
+	return f.base.Remove(p)
+}
@@ -0,0 +1,108 @@
+// Tests for filesystem implementations behind the file tools.
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestToolsWithMemFS runs read_file and write_file against an in-memory filesystem.
+func TestToolsWithMemFS(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "scratch")
+	toolCtx := Context{
+		MaxReadBytes: DefaultMaxReadBytes,
+		FS:           NewMemFS(root),
+		Ctx:          context.Background(),
+	}
+	writeTool := &writeFileTool{ctx: toolCtx}
+	readTool := &readFileTool{ctx: toolCtx}
+
+	filePath := filepath.Join(root, "nested", "note.txt")
+	resp := decodeToolResponse(t, mustExecute(t, writeTool.execute, `{"path":"`+filePath+`","content":"in memory"}`))
+	if !resp.OK {
+		t.Fatalf("write failed: %s", resp.Err)
+	}
+	if _, err := os.Stat(filePath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected nothing on disk, stat err = %v", err)
+	}
+
+	resp = decodeToolResponse(t, mustExecute(t, readTool.execute, `{"path":"`+filePath+`"}`))
+	if !resp.OK || !strings.Contains(string(resp.Data), "in memory") {
+		t.Fatalf("unexpected read response: %+v", resp)
+	}
+
+	outside := filepath.Join(string(filepath.Separator), "elsewhere", "x.txt")
+	resp = decodeToolResponse(t, mustExecute(t, writeTool.execute, `{"path":"`+outside+`","content":"x"}`))
+	if resp.OK {
+		t.Fatalf("expected path outside allowed dirs to be rejected")
+	}
+}
+
+// TestReadOnlyFS ensures writes are rejected while reads pass through.
+func TestReadOnlyFS(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "note.txt")
+	if err := os.WriteFile(filePath, []byte("keep"), 0o644); err != nil {
+		t.Fatalf("seed file: %v", err)
+	}
+	fsys := NewReadOnlyFS(NewOSFS([]string{dir}))
+
+	if _, err := readAllFS(fsys, filePath); err != nil {
+		t.Fatalf("read through read-only fs: %v", err)
+	}
+	if err := fsys.WriteFile(filePath, []byte("changed"), WriteOptions{}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	if err := fsys.Remove(filePath); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly on remove, got %v", err)
+	}
+}
+
+// TestOverlayFS verifies copy-on-write semantics over a real directory.
+func TestOverlayFS(t *testing.T) {
+	dir := t.TempDir()
+	keptPath := filepath.Join(dir, "kept.txt")
+	gonePath := filepath.Join(dir, "gone.txt")
+	if err := os.WriteFile(keptPath, []byte("original"), 0o600); err != nil {
+		t.Fatalf("seed kept: %v", err)
+	}
+	if err := os.WriteFile(gonePath, []byte("bye"), 0o644); err != nil {
+		t.Fatalf("seed gone: %v", err)
+	}
+	overlay := NewOverlayFS(NewOSFS([]string{dir}), nil)
+
+	if err := overlay.WriteFile(keptPath, []byte("edited"), WriteOptions{Perm: 0o644}); err != nil {
+		t.Fatalf("overlay write: %v", err)
+	}
+	if err := overlay.Remove(gonePath); err != nil {
+		t.Fatalf("overlay remove: %v", err)
+	}
+
+	data, err := readAllFS(overlay, keptPath)
+	if err != nil || string(data) != "edited" {
+		t.Fatalf("expected overlay content, got %q (%v)", string(data), err)
+	}
+	info, err := overlay.Stat(keptPath)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected copied-up file to keep mode 0600, got %v (%v)", info, err)
+	}
+	if _, err := overlay.Stat(gonePath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected deleted file to be hidden, got %v", err)
+	}
+	entries, err := overlay.ReadDir(dir)
+	if err != nil || len(entries) != 1 || entries[0].Name() != "kept.txt" {
+		t.Fatalf("unexpected merged listing: %v (%v)", entries, err)
+	}
+
+	onDisk, err := os.ReadFile(keptPath)
+	if err != nil || string(onDisk) != "original" {
+		t.Fatalf("lower layer must be untouched, got %q (%v)", string(onDisk), err)
+	}
+	if _, err := os.Stat(gonePath); err != nil {
+		t.Fatalf("lower file must still exist: %v", err)
+	}
+}
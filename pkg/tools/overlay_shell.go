@@ -0,0 +1,358 @@
+// Copy-the-workdir strategy that keeps run_shell writes inside an overlay.
+package tools
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// overlaySnapshotMaxBytes caps how much data a run_shell snapshot may hold.
+const overlaySnapshotMaxBytes int64 = 256 * 1024 * 1024
+
+// overlayOmittedMax caps how many omitted entries a run_shell result lists.
+const overlayOmittedMax = 20
+
+// shellSnapshot is the copy of the overlay root that run_shell commands run in.
+// It is kept between calls and only files whose merged view changed are copied again.
+type shellSnapshot struct {
+	dir   string
+	files map[string]snapshotEntry
+	dirs  map[string]struct{}
+}
+
+// snapshotEntry records a materialized file so changes can be detected afterwards.
+type snapshotEntry struct {
+	digest [sha256.Size]byte
+	perm   fs.FileMode
+	// size and modTime are the merged-view stat the copy was taken from.
+	size    int64
+	modTime time.Time
+}
+
+// current reports whether the snapshot copy still matches the merged view info.
+func (e snapshotEntry) current(info fs.FileInfo) bool {
+	return e.size == info.Size() && e.modTime.Equal(info.ModTime()) && e.perm == info.Mode().Perm()
+}
+
+// forget drops rel and everything below it from the snapshot records.
+func (s *shellSnapshot) forget(rel string) {
+	prefix := rel + string(filepath.Separator)
+	for path := range s.files {
+		if path == rel || strings.HasPrefix(path, prefix) {
+			delete(s.files, path)
+		}
+	}
+	for path := range s.dirs {
+		if path == rel || strings.HasPrefix(path, prefix) {
+			delete(s.dirs, path)
+		}
+	}
+}
+
+// runInSnapshot syncs the merged view of the overlay root into the snapshot dir, runs
+// the command there with root-prefixed arguments rewritten, and records file additions,
+// modifications and deletions back into the overlay. Symlinks and special files cannot
+// be staged; they are listed in the result's Omitted field instead.
+func (o *OverlayFS) runInSnapshot(workingDir string, argv []string, run func(dir string, argv []string) commandResult) (commandResult, error) {
+	if workingDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return commandResult{}, err
+		}
+		workingDir = wd
+	}
+	if !isWithinDir(workingDir, o.root) {
+		return commandResult{}, fmt.Errorf("working directory %s is outside overlay root %s", workingDir, o.root)
+	}
+
+	o.shellMu.Lock()
+	defer o.shellMu.Unlock()
+	if o.snapshot == nil {
+		tmp, err := os.MkdirTemp("", "agent-overlay-*")
+		if err != nil {
+			return commandResult{}, err
+		}
+		o.snapshot = &shellSnapshot{dir: tmp, files: map[string]snapshotEntry{}, dirs: map[string]struct{}{}}
+	}
+	snap := o.snapshot
+
+	omitted, err := o.sync(snap)
+	if err != nil {
+		o.dropSnapshotLocked()
+		return commandResult{}, fmt.Errorf("snapshot overlay: %w", err)
+	}
+
+	rel, _ := filepath.Rel(o.root, workingDir)
+	snapshotDir := filepath.Join(snap.dir, rel)
+	if err := os.MkdirAll(snapshotDir, 0o755); err != nil {
+		return commandResult{}, err
+	}
+
+	mapped := make([]string, len(argv))
+	for i, arg := range argv {
+		mapped[i] = rebasePathPrefix(arg, o.root, snap.dir)
+	}
+	result := run(snapshotDir, mapped)
+	result.WorkingDir = workingDir
+	result.Args = argv[1:]
+	result.Stdout = rebasePathPrefix(result.Stdout, snap.dir, o.root)
+	result.Stderr = rebasePathPrefix(result.Stderr, snap.dir, o.root)
+
+	created, err := o.absorb(snap)
+	if err != nil {
+		o.dropSnapshotLocked()
+		return commandResult{}, fmt.Errorf("record overlay changes: %w", err)
+	}
+	result.Omitted = limitOmitted(append(omitted, created...))
+	return result, nil
+}
+
+// Close removes the run_shell snapshot, if any. Pending changes are kept.
+func (o *OverlayFS) Close() error {
+	o.shellMu.Lock()
+	defer o.shellMu.Unlock()
+	return o.dropSnapshotLocked()
+}
+
+// dropSnapshotLocked deletes the snapshot so the next run_shell starts from scratch.
+func (o *OverlayFS) dropSnapshotLocked() error {
+	if o.snapshot == nil {
+		return nil
+	}
+	err := os.RemoveAll(o.snapshot.dir)
+	o.snapshot = nil
+	return err
+}
+
+// sync brings the snapshot in line with the merged root view. Files whose size,
+// modification time and permissions are unchanged since the last sync are not
+// copied again. It returns the root paths of entries that were left out.
+func (o *OverlayFS) sync(snap *shellSnapshot) ([]string, error) {
+	seenFiles := map[string]struct{}{}
+	seenDirs := map[string]struct{}{}
+	var omitted []string
+	var total int64
+
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		entries, err := o.ReadDir(filepath.Join(o.root, rel))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			childRel := filepath.Join(rel, entry.Name())
+			src := filepath.Join(o.root, childRel)
+			target := filepath.Join(snap.dir, childRel)
+			switch {
+			case entry.IsDir():
+				seenDirs[childRel] = struct{}{}
+				if _, known := snap.dirs[childRel]; !known {
+					if err := os.RemoveAll(target); err != nil {
+						return err
+					}
+					snap.forget(childRel)
+					if err := os.MkdirAll(target, 0o755); err != nil {
+						return err
+					}
+					snap.dirs[childRel] = struct{}{}
+				}
+				if err := walk(childRel); err != nil {
+					return err
+				}
+			case entry.Type().IsRegular():
+				seenFiles[childRel] = struct{}{}
+				info, err := o.Stat(src)
+				if err != nil {
+					return err
+				}
+				total += info.Size()
+				if total > overlaySnapshotMaxBytes {
+					return fmt.Errorf("overlay root exceeds snapshot limit of %d bytes", overlaySnapshotMaxBytes)
+				}
+				if prev, known := snap.files[childRel]; known && prev.current(info) {
+					continue
+				}
+				data, err := readAllFS(o, src)
+				if err != nil {
+					return err
+				}
+				if err := os.RemoveAll(target); err != nil {
+					return err
+				}
+				snap.forget(childRel)
+				if err := os.WriteFile(target, data, info.Mode().Perm()); err != nil {
+					return err
+				}
+				snap.files[childRel] = snapshotEntry{
+					digest:  sha256.Sum256(data),
+					perm:    info.Mode().Perm(),
+					size:    info.Size(),
+					modTime: info.ModTime(),
+				}
+			default:
+				omitted = append(omitted, src)
+			}
+		}
+		return nil
+	}
+	if err := walk("."); err != nil {
+		return nil, err
+	}
+
+	for rel := range snap.files {
+		if _, ok := seenFiles[rel]; !ok {
+			if err := os.Remove(filepath.Join(snap.dir, rel)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			delete(snap.files, rel)
+		}
+	}
+	for rel := range snap.dirs {
+		if _, ok := seenDirs[rel]; !ok {
+			if err := os.RemoveAll(filepath.Join(snap.dir, rel)); err != nil {
+				return nil, err
+			}
+			delete(snap.dirs, rel)
+		}
+	}
+	return omitted, nil
+}
+
+// absorb compares the snapshot with what was synced and replays the differences.
+// Symlinks and special files the command created are removed from the snapshot
+// and their root paths returned, since the overlay cannot stage them.
+func (o *OverlayFS) absorb(snap *shellSnapshot) ([]string, error) {
+	seenFiles := map[string]struct{}{}
+	seenDirs := map[string]struct{}{}
+	var created []string
+
+	err := filepath.WalkDir(snap.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(snap.dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(o.root, rel)
+		if d.IsDir() {
+			seenDirs[rel] = struct{}{}
+			if _, known := snap.dirs[rel]; !known {
+				snap.dirs[rel] = struct{}{}
+				return o.MkdirAll(target, 0o755)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			created = append(created, target)
+			return os.Remove(path)
+		}
+		seenFiles[rel] = struct{}{}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256(data)
+		if prev, known := snap.files[rel]; known && prev.digest == digest {
+			return nil
+		}
+		if err := o.WriteFile(target, data, WriteOptions{Perm: info.Mode().Perm()}); err != nil {
+			return err
+		}
+		staged, err := o.Stat(target)
+		if err != nil {
+			return err
+		}
+		snap.files[rel] = snapshotEntry{
+			digest:  digest,
+			perm:    staged.Mode().Perm(),
+			size:    staged.Size(),
+			modTime: staged.ModTime(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for rel := range snap.files {
+		if _, ok := seenFiles[rel]; !ok {
+			if err := o.Remove(filepath.Join(o.root, rel)); err != nil {
+				return nil, err
+			}
+			delete(snap.files, rel)
+		}
+	}
+	var goneDirs []string
+	for rel := range snap.dirs {
+		if _, ok := seenDirs[rel]; !ok {
+			goneDirs = append(goneDirs, rel)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(goneDirs)))
+	for _, rel := range goneDirs {
+		// Directories that still hold omitted entries (e.g. symlinks) stay.
+		_ = o.Remove(filepath.Join(o.root, rel))
+		delete(snap.dirs, rel)
+	}
+	return created, nil
+}
+
+// limitOmitted sorts omitted paths and keeps at most overlayOmittedMax of them.
+func limitOmitted(paths []string) []string {
+	sort.Strings(paths)
+	if len(paths) <= overlayOmittedMax {
+		return paths
+	}
+	more := len(paths) - overlayOmittedMax
+	return append(paths[:overlayOmittedMax], fmt.Sprintf("... and %d more", more))
+}
+
+// rebasePathPrefix replaces occurrences of the from directory with to, only where
+// from is followed by a path separator, a non-path character, or the end of text.
+func rebasePathPrefix(text, from, to string) string {
+	if from == "" || !strings.Contains(text, from) {
+		return text
+	}
+	var sb strings.Builder
+	for {
+		idx := strings.Index(text, from)
+		if idx < 0 {
+			sb.WriteString(text)
+			return sb.String()
+		}
+		end := idx + len(from)
+		boundary := end == len(text) || !isPathNameByte(text[end])
+		sb.WriteString(text[:idx])
+		if boundary {
+			sb.WriteString(to)
+		} else {
+			sb.WriteString(from)
+		}
+		text = text[end:]
+	}
+}
+
+// isPathNameByte reports whether b can continue a file name component.
+func isPathNameByte(b byte) bool {
+	return b == '-' || b == '_' || b == '.' ||
+		(b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,211 @@
+// Per-skill tool permissions from the allowed-tools front matter field.
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+)
+
+// ErrToolNotAllowed is wrapped by errors for calls denied by the active skill.
+var ErrToolNotAllowed = errors.New("tool not allowed by active skill")
+
+// toolAliases maps common allowed-tools names from other agents to the built-in tools.
+var toolAliases = map[string]string{
+	"read":  "read_file",
+	"write": "write_file",
+	"edit":  "write_file",
+	"bash":  "run_shell",
+	"shell": "run_shell",
+}
+
+// alwaysAllowedTools stay usable under any skill so the model can read the
+// skill's own resources and switch to another skill.
+var alwaysAllowedTools = map[string]bool{
+	"load_skill":          true,
+	"read_skill_resource": true,
+	"search_skills":       true,
+}
+
+// untrustedDeniedTools are unavailable while an untrusted skill is active:
+// it may read files but not run commands or change the workspace. External
+// tools, such as MCP tools, are also denied unless its allowed-tools lists them.
+var untrustedDeniedTools = map[string]bool{
+	"run_shell":  true,
+	"write_file": true,
+}
+
+// AuditEvent records a tool call decision.
+type AuditEvent struct {
+	Time time.Time `json:"time"`
+	Tool string    `json:"tool"`
+	// Skill is the active skill, empty when none is active.
+	Skill     string `json:"skill,omitempty"`
+	Arguments string `json:"arguments"`
+	Allowed   bool   `json:"allowed"`
+	// Reason explains a denial.
+	Reason string `json:"reason,omitempty"`
+}
+
+// toolPermission is one allowed-tools entry: a tool and an optional scope.
+// For run_shell the scope is a command pattern, for read_file and write_file a
+// path pattern. In both, * matches any run of characters. "git:*" allows git
+// with any arguments.
+type toolPermission struct {
+	tool     string
+	scope    string
+	hasScope bool
+}
+
+// parsePermissions parses allowed-tools entries such as "read_file",
+// "run_shell(git:*)" and "Write(output/*)".
+func parsePermissions(entries []string) []toolPermission {
+	perms := make([]toolPermission, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		var perm toolPermission
+		if open := strings.Index(entry, "("); open > 0 && strings.HasSuffix(entry, ")") {
+			perm.scope = strings.TrimSpace(entry[open+1 : len(entry)-1])
+			perm.hasScope = true
+			entry = entry[:open]
+		}
+		perm.tool = strings.TrimSpace(entry)
+		if alias, ok := toolAliases[strings.ToLower(perm.tool)]; ok {
+			perm.tool = alias
+		}
+		if perm.tool != "" {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+// restricts reports whether skill limits the tools that may be called.
+func restricts(skill *skills.Skill) bool {
+	return skill.Trust == skills.TrustUntrusted || len(skill.AllowedTools) > 0
+}
+
+// authorize checks a call against a skill's trust level and allowed-tools.
+// owner is the skill that declares tool when it is a script tool; a skill may
+// always run its own scripts. external marks tools mounted with Register.
+// Skills without allowed-tools are otherwise unrestricted.
+func authorize(skill *skills.Skill, tool string, owner *skills.Skill, external bool, argText string) error {
+	if skill == nil || alwaysAllowedTools[tool] {
+		return nil
+	}
+	perms := parsePermissions(skill.AllowedTools)
+	if skill.Trust == skills.TrustUntrusted {
+		if untrustedDeniedTools[tool] || owner != nil {
+			return fmt.Errorf("%w: skill %s is untrusted and cannot use %s", ErrToolNotAllowed, skill.Name, tool)
+		}
+		if external && !slices.ContainsFunc(perms, func(perm toolPermission) bool { return perm.tool == tool }) {
+			return fmt.Errorf("%w: skill %s is untrusted and cannot use external tool %s unless its allowed-tools lists it", ErrToolNotAllowed, skill.Name, tool)
+		}
+	}
+	if owner != nil && owner.Name == skill.Name {
+		return nil
+	}
+	if len(skill.AllowedTools) == 0 {
+		return nil
+	}
+	listed := false
+	var scopeErr error
+	for _, perm := range perms {
+		if perm.tool != tool {
+			continue
+		}
+		listed = true
+		if !perm.hasScope {
+			return nil
+		}
+		ok, err := scopeAllows(perm, argText)
+		if ok {
+			return nil
+		}
+		if err != nil {
+			scopeErr = err
+		}
+	}
+	if !listed {
+		return fmt.Errorf("%w: skill %s does not allow %s (allowed-tools: %s)", ErrToolNotAllowed, skill.Name, tool, strings.Join(skill.AllowedTools, ", "))
+	}
+	if scopeErr != nil {
+		return fmt.Errorf("%w: skill %s: %v", ErrToolNotAllowed, skill.Name, scopeErr)
+	}
+	return fmt.Errorf("%w: skill %s does not allow this %s call (allowed-tools: %s)", ErrToolNotAllowed, skill.Name, tool, strings.Join(skill.AllowedTools, ", "))
+}
+
+// scopeAllows matches the call arguments against a scoped permission. A
+// run_shell scope covers the command line only; working_dir is left to the
+// allowed-directory checks of run_shell itself.
+func scopeAllows(perm toolPermission, argText string) (bool, error) {
+	var args struct {
+		Path    string `json:"path"`
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal([]byte(argText), &args); err != nil {
+		return false, err
+	}
+	switch perm.tool {
+	case "run_shell":
+		return commandMatches(perm.scope, args.Command), nil
+	case "read_file", "write_file":
+		return pathMatches(perm.scope, args.Path)
+	default:
+		return false, fmt.Errorf("scoped permission %s(%s) is not supported", perm.tool, perm.scope)
+	}
+}
+
+// commandMatches reports whether command fits pattern. "cmd:*" matches cmd
+// alone or followed by any arguments.
+func commandMatches(pattern, command string) bool {
+	command = strings.Join(strings.Fields(command), " ")
+	pattern = strings.Join(strings.Fields(pattern), " ")
+	if prefix, ok := strings.CutSuffix(pattern, ":*"); ok {
+		return command == prefix || strings.HasPrefix(command, prefix+" ")
+	}
+	return wildcardMatch(pattern, command)
+}
+
+// pathMatches compares absolute, cleaned forms of pattern and path, so
+// relative patterns resolve like relative tool paths.
+func pathMatches(pattern, path string) (bool, error) {
+	if path == "" {
+		return false, nil
+	}
+	absPattern, err := filepath.Abs(pattern)
+	if err != nil {
+		return false, err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	return wildcardMatch(filepath.ToSlash(absPattern), filepath.ToSlash(absPath)), nil
+}
+
+// wildcardMatch matches s against pattern, where * matches any run of characters.
+func wildcardMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
@@ -3,7 +3,6 @@ package tools
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -191,10 +190,32 @@ func isShellExecutable(executable string) bool {
 	return isShell
 }
 
+// blockedShellSyntax are the shell control operators and expansions that
+// run_shell rejects.
+var blockedShellSyntax = []string{"&&", "||", ";", "|", ">", "<", "`", "$(", "\n", "\r"}
+
+// ShellPolicy describes the restrictions run_shell enforces.
+type ShellPolicy struct {
+	// BlockedSyntax are rejected anywhere in a command; newlines included.
+	BlockedSyntax []string
+	// BlockedExecutables are destructive commands that are refused.
+	BlockedExecutables []string
+	// Shells are the interpreters refused, which would bypass BlockedSyntax.
+	Shells []string
+}
+
+// RunShellPolicy returns the restrictions of run_shell, with names sorted.
+func RunShellPolicy() ShellPolicy {
+	return ShellPolicy{
+		BlockedSyntax:      slices.Clone(blockedShellSyntax),
+		BlockedExecutables: sortedKeys(dangerousCommands),
+		Shells:             sortedKeys(shellExecutables),
+	}
+}
+
 // containsBlockedShellSyntax checks for shell control operators and expansions.
 func containsBlockedShellSyntax(command string) (string, bool) {
-	blocked := []string{"&&", "||", ";", "|", ">", "<", "`", "$(", "\n", "\r"}
-	for _, token := range blocked {
+	for _, token := range blockedShellSyntax {
 		if strings.Contains(command, token) {
 			return token, true
 		}
@@ -204,14 +225,7 @@ func containsBlockedShellSyntax(command string) (string, bool) {
 
 // validateFileExists checks if a file exists and is not a directory.
 func validateFileExists(path string) error {
-	info, err := os.Stat(path)
-	if err != nil {
-		return err
-	}
-	if info.IsDir() {
-		return fmt.Errorf("path is a directory: %s", path)
-	}
-	return nil
+	return validateFileExistsFS(NewOSFS(nil), path)
 }
 
 // parseCommandLine parses a command string into argv without shell execution.
@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sync"
+	"time"
 
 	loggerpkg "github.com/minhyannv/agent-skills-go/pkg/logger"
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
 	"github.com/openai/openai-go"
 )
 
@@ -21,8 +25,25 @@ type Context struct {
 	MaxReadBytes int64
 	Verbose      bool
 	AllowedDirs  []string
-	Ctx          context.Context
-	Logger       loggerpkg.Logger
+	// FS backs read_file and write_file; nil means the OS filesystem restricted to AllowedDirs.
+	FS     FileSystem
+	Ctx    context.Context
+	Logger loggerpkg.Logger
+	// Skills backs load_skill, read_skill_resource and search_skills, which are
+	// only registered when at least one skill is loaded.
+	Skills []*skills.Skill
+	// SkillIndex is searched by search_skills; nil builds one from Skills.
+	SkillIndex *skills.Index
+	// ResourceScan is applied to text read from skill resources with
+	// read_skill_resource or read_file on skill:// paths. The zero value warns.
+	ResourceScan skills.ScanPolicy
+	// Audit, when set, receives every tool call decision, including calls
+	// denied by the active skill's allowed-tools.
+	Audit func(AuditEvent)
+
+	// lookupSkill finds a loaded skill by name for skill:// paths. The
+	// Registry sets it so tools see skills swapped in by SetSkills.
+	lookupSkill func(name string) *skills.Skill
 }
 
 func (c Context) debugf(format string, args ...any) {
@@ -30,10 +51,22 @@ func (c Context) debugf(format string, args ...any) {
 }
 
 // Registry holds registered tools and handles execution.
+// It is safe for concurrent use, so tools can be mounted while a session runs.
 type Registry struct {
+	mu       sync.RWMutex
 	registry map[string]tool
+	order    []string
 	ctx      Context
-	params   []openai.ChatCompletionToolParam
+	// active is the skill last loaded with load_skill; its allowed-tools
+	// restrict every call.
+	active *skills.Skill
+	// replaced are the restricted or untrusted skills load_skill switched away
+	// from since StartTurn. Their restrictions still apply, so loading a
+	// looser skill cannot lift them.
+	replaced []*skills.Skill
+	// scriptTools are the names of the registered skill script tools, which
+	// SetSkills replaces.
+	scriptTools []string
 }
 
 type toolResponse struct {
@@ -43,30 +76,131 @@ type toolResponse struct {
 	Err  string      `json:"error,omitempty"`
 }
 
+// Tool describes an externally provided tool that can be mounted into a Registry.
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON schema of the arguments object.
+	Parameters map[string]any
+	// Execute runs the tool with the raw JSON arguments. The returned data is
+	// wrapped in the standard {"ok":...} envelope.
+	Execute func(ctx context.Context, argText string) (any, error)
+	// Trusted exempts the results from the untrusted-data envelope applied by
+	// WrapResult. Set it only for tools whose results carry no content from
+	// files, commands or other outside sources.
+	Trusted bool
+}
+
+// externalTool adapts a Tool to the internal tool interface.
+type externalTool struct {
+	spec Tool
+	ctx  Context
+}
+
+func (e *externalTool) name() string {
+	return e.spec.Name
+}
+
+func (e *externalTool) definition() openai.ChatCompletionToolParam {
+	params := e.spec.Parameters
+	if params == nil {
+		params = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	def := openai.FunctionDefinitionParam{
+		Name:       e.spec.Name,
+		Parameters: openai.FunctionParameters(params),
+	}
+	if e.spec.Description != "" {
+		def.Description = openai.String(e.spec.Description)
+	}
+	return openai.ChatCompletionToolParam{Function: def}
+}
+
+func (e *externalTool) execute(argText string) (string, error) {
+	ctx := e.ctx.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	data, err := e.spec.Execute(ctx, argText)
+	return marshalToolResponse(e.spec.Name, data, err)
+}
+
 // New builds a registry with the built-in tools.
 func New(ctx Context) *Registry {
 	if ctx.Logger == nil {
 		ctx.Logger = loggerpkg.NopLogger{}
 	}
+	if ctx.FS == nil {
+		ctx.FS = NewOSFS(ctx.AllowedDirs)
+	}
 	t := &Registry{
 		registry: make(map[string]tool),
-		ctx:      ctx,
 	}
+	ctx.lookupSkill = t.lookupSkill
+	t.ctx = ctx
 
 	t.register(&readFileTool{ctx: ctx})
 	t.register(&writeFileTool{ctx: ctx})
 	t.register(&runShellTool{ctx: ctx})
+	t.SetSkills(ctx.Skills, ctx.SkillIndex)
 	return t
 }
 
 func (t *Registry) register(toolImpl tool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, exists := t.registry[toolImpl.name()]; !exists {
+		t.order = append(t.order, toolImpl.name())
+	}
 	t.registry[toolImpl.name()] = toolImpl
-	t.params = append(t.params, toolImpl.definition())
 	t.ctx.debugf("[verbose] registered tool: %s", toolImpl.name())
 }
 
+// Register mounts an external tool. Names must be unique and match ^[a-zA-Z0-9_-]{1,64}$.
+func (t *Registry) Register(spec Tool) error {
+	if !validToolName(spec.Name) {
+		return fmt.Errorf("invalid tool name: %q", spec.Name)
+	}
+	if spec.Execute == nil {
+		return fmt.Errorf("tool %s has no Execute function", spec.Name)
+	}
+	t.mu.RLock()
+	_, exists := t.registry[spec.Name]
+	t.mu.RUnlock()
+	if exists {
+		return fmt.Errorf("tool already registered: %s", spec.Name)
+	}
+	t.register(&externalTool{spec: spec, ctx: t.ctx})
+	return nil
+}
+
+// Unregister removes a tool by name. Unknown names are ignored.
+func (t *Registry) Unregister(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.registry[name]; !ok {
+		return
+	}
+	delete(t.registry, name)
+	t.order = slices.DeleteFunc(t.order, func(n string) bool { return n == name })
+	t.ctx.debugf("[verbose] unregistered tool: %s", name)
+}
+
+// Names returns registered tool names in registration order.
+func (t *Registry) Names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return slices.Clone(t.order)
+}
+
 func (t *Registry) Definitions() []openai.ChatCompletionToolParam {
-	return t.params
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	params := make([]openai.ChatCompletionToolParam, 0, len(t.order))
+	for _, name := range t.order {
+		params = append(params, t.registry[name].definition())
+	}
+	return params
 }
 
 func (t *Registry) Execute(call openai.ChatCompletionMessageToolCall) (string, error) {
@@ -78,14 +212,114 @@ func (t *Registry) Execute(call openai.ChatCompletionMessageToolCall) (string, e
 		}
 	}
 
+	t.mu.RLock()
 	toolImpl, ok := t.registry[call.Function.Name]
+	active := t.active
+	restricting := append(slices.Clone(t.replaced), active)
+	t.mu.RUnlock()
 	if !ok {
 		return marshalToolResponse(call.Function.Name, nil, fmt.Errorf("unknown tool: %s", call.Function.Name))
 	}
 
+	var owner *skills.Skill
+	if script, ok := toolImpl.(*skillScriptTool); ok {
+		owner = script.skill
+	}
+	_, external := toolImpl.(*externalTool)
+	for _, skill := range restricting {
+		if err := authorize(skill, call.Function.Name, owner, external, call.Function.Arguments); err != nil {
+			t.audit(active, call, err)
+			t.ctx.Logger.Warn("tool call denied", map[string]any{
+				"tool":   call.Function.Name,
+				"skill":  skill.Name,
+				"reason": err.Error(),
+			})
+			return marshalToolResponse(call.Function.Name, nil, err)
+		}
+	}
+	t.audit(active, call, nil)
 	return toolImpl.execute(call.Function.Arguments)
 }
 
+// lookupSkill returns the loaded skill called name, or nil.
+func (t *Registry) lookupSkill(name string) *skills.Skill {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, skill := range t.ctx.Skills {
+		if skill.Name == name {
+			return skill
+		}
+	}
+	return nil
+}
+
+// ActiveSkill returns the skill whose allowed-tools currently apply, or nil.
+func (t *Registry) ActiveSkill() *skills.Skill {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.active
+}
+
+// SetActiveSkill makes skill the active skill; nil lifts all restrictions.
+// load_skill calls it for the skill it loads. A restricted or untrusted skill
+// it replaces keeps restricting calls until StartTurn.
+func (t *Registry) SetActiveSkill(skill *skills.Skill) {
+	t.mu.Lock()
+	switch {
+	case skill == nil:
+		t.replaced = nil
+	case t.active != nil && t.active != skill && restricts(t.active) && !slices.Contains(t.replaced, t.active):
+		t.replaced = append(t.replaced, t.active)
+	}
+	t.active = skill
+	t.mu.Unlock()
+	if skill != nil {
+		t.ctx.debugf("[verbose] active skill: %s (allowed-tools: %v)", skill.Name, skill.AllowedTools)
+	}
+}
+
+// StartTurn drops the restrictions of skills replaced during the previous
+// turn. The active skill stays in force. The agent calls it before each user
+// message.
+func (t *Registry) StartTurn() {
+	t.mu.Lock()
+	t.replaced = nil
+	t.mu.Unlock()
+}
+
+// audit reports a call decision to Context.Audit.
+func (t *Registry) audit(active *skills.Skill, call openai.ChatCompletionMessageToolCall, denied error) {
+	if t.ctx.Audit == nil {
+		return
+	}
+	event := AuditEvent{
+		Time:      time.Now().UTC(),
+		Tool:      call.Function.Name,
+		Arguments: call.Function.Arguments,
+		Allowed:   denied == nil,
+	}
+	if active != nil {
+		event.Skill = active.Name
+	}
+	if denied != nil {
+		event.Reason = denied.Error()
+	}
+	t.ctx.Audit(event)
+}
+
+// validToolName reports whether name is accepted by the chat completions API.
+func validToolName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r == '-' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')) {
+			return false
+		}
+	}
+	return true
+}
+
 func marshalToolResponse(toolName string, data interface{}, err error) (string, error) {
 	resp := toolResponse{
 		OK:   err == nil,
@@ -1,12 +1,14 @@
 package tools
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"os"
 
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
 	"github.com/openai/openai-go"
 )
 
@@ -55,20 +57,22 @@ func (t *readFileTool) execute(argText string) (string, error) {
 		return marshalToolResponse("read_file", nil, errors.New("path is required"))
 	}
 
+	fsys := t.ctx.fileSystem()
+
 	// Validate and sanitize path
-	validatedPath, err := validatePathWithAllowedDirs(args.Path, t.ctx.AllowedDirs)
+	validatedPath, err := fsys.ValidatePath(args.Path)
 	if err != nil {
 		t.ctx.debugf("[verbose] read_file: path validation failed: %v", err)
 		return marshalToolResponse("read_file", nil, fmt.Errorf("path validation failed: %w", err))
 	}
 
 	// Check if file exists and is not a directory
-	if err := validateFileExists(validatedPath); err != nil {
+	if err := validateFileExistsFS(fsys, validatedPath); err != nil {
 		t.ctx.debugf("[verbose] read_file: file validation failed: %v", err)
 		return marshalToolResponse("read_file", nil, err)
 	}
 
-	info, err := os.Stat(validatedPath)
+	info, err := fsys.Stat(validatedPath)
 	if err != nil {
 		t.ctx.debugf("[verbose] read_file: stat failed: %v", err)
 		return marshalToolResponse("read_file", nil, err)
@@ -84,7 +88,7 @@ func (t *readFileTool) execute(argText string) (string, error) {
 		return marshalToolResponse("read_file", nil, errors.New("max_bytes must be greater than 0"))
 	}
 
-	file, err := os.Open(validatedPath)
+	file, err := fsys.Open(validatedPath)
 	if err != nil {
 		t.ctx.debugf("[verbose] read_file: open failed: %v", err)
 		return marshalToolResponse("read_file", nil, err)
@@ -98,24 +102,50 @@ func (t *readFileTool) execute(argText string) (string, error) {
 		return marshalToolResponse("read_file", nil, err)
 	}
 
+	// The hash lets write_file use a full read as a precondition. The rest of
+	// a truncated file is never read, so it has no hash.
 	truncated := false
+	digest := ""
 	if int64(len(data)) > maxBytes {
 		truncated = true
 		originalLen := len(data)
 		data = data[:maxBytes]
 		t.ctx.debugf("[verbose] read_file: truncated from %d to %d bytes", originalLen, maxBytes)
+	} else {
+		sum := sha256.Sum256(data)
+		digest = hex.EncodeToString(sum[:])
+	}
+
+	content := string(data)
+	var findings []skills.Finding
+	quarantined := false
+	if name, rel, ok := skills.ParseURI(validatedPath); ok {
+		findings, quarantined, err = t.ctx.scanResource(name, rel, content)
+		if err != nil {
+			return marshalToolResponse("read_file", nil, err)
+		}
+		if quarantined {
+			content = ""
+		}
 	}
 
 	result := struct {
 		Path      string `json:"path"`
 		Bytes     int    `json:"bytes"`
 		Truncated bool   `json:"truncated"`
+		SHA256    string `json:"sha256,omitempty"`
 		Content   string `json:"content"`
+		// Findings and Quarantined are set for skill:// paths, as in read_skill_resource.
+		Findings    []skills.Finding `json:"injection_findings,omitempty"`
+		Quarantined bool             `json:"quarantined,omitempty"`
 	}{
-		Path:      validatedPath,
-		Bytes:     len(data),
-		Truncated: truncated,
-		Content:   string(data),
+		Path:        validatedPath,
+		Bytes:       len(data),
+		Truncated:   truncated,
+		SHA256:      digest,
+		Content:     content,
+		Findings:    findings,
+		Quarantined: quarantined,
 	}
 	t.ctx.debugf("[verbose] read_file: success, read %d bytes (truncated=%v)", result.Bytes, truncated)
 	return marshalToolResponse("read_file", result, nil)
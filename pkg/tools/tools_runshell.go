@@ -28,6 +28,9 @@ type commandResult struct {
 	Stderr     string   `json:"stderr,omitempty"`
 	DurationMs int64    `json:"duration_ms"`
 	Error      string   `json:"error,omitempty"`
+	// Omitted lists entries, such as symlinks, that the overlay snapshot could
+	// not carry; changes to them are not staged.
+	Omitted []string `json:"omitted,omitempty"`
 }
 
 func (t *runShellTool) name() string {
@@ -95,17 +98,35 @@ func (t *runShellTool) execute(argText string) (string, error) {
 	}
 
 	timeout := time.Duration(args.TimeoutSeconds) * time.Second
+	result, err := t.ctx.runSandboxed(argv, validatedWorkingDir, timeout)
+	if err != nil {
+		t.ctx.debugf("[verbose] run_shell: %v", err)
+		return marshalToolResponse("run_shell", nil, err)
+	}
+	t.ctx.debugf("[verbose] run_shell: completed, exit_code=%d, duration=%dms", result.ExitCode, result.DurationMs)
+	return marshalToolResponse("run_shell", result, nil)
+}
+
+// runSandboxed applies the executable policy to argv and runs it, inside an
+// overlay snapshot when the filesystem is an overlay so writes stay staged.
+// workingDir must already be validated.
+func (ctx Context) runSandboxed(argv []string, workingDir string, timeout time.Duration) (commandResult, error) {
 	if isShellExecutable(argv[0]) {
-		return marshalToolResponse("run_shell", nil, fmt.Errorf("shell executables are not allowed: %s", argv[0]))
+		return commandResult{}, fmt.Errorf("shell executables are not allowed: %s", argv[0])
 	}
 	if isDangerousExecutable(argv[0]) {
-		t.ctx.debugf("[verbose] run_shell: dangerous command blocked: %s", argv[0])
-		return marshalToolResponse("run_shell", nil, fmt.Errorf("dangerous command not allowed: %s", argv[0]))
+		return commandResult{}, fmt.Errorf("dangerous command not allowed: %s", argv[0])
+	}
+	if overlay, ok := ctx.FS.(*OverlayFS); ok && overlay.Root() != "" {
+		result, err := overlay.runInSnapshot(workingDir, argv, func(dir string, argv []string) commandResult {
+			return ctx.runCommand(argv[0], argv[1:], dir, timeout)
+		})
+		if err != nil {
+			return commandResult{}, fmt.Errorf("overlay snapshot failed: %w", err)
+		}
+		return result, nil
 	}
-
-	result := t.ctx.runCommand(argv[0], argv[1:], validatedWorkingDir, timeout)
-	t.ctx.debugf("[verbose] run_shell: completed, exit_code=%d, duration=%dms", result.ExitCode, result.DurationMs)
-	return marshalToolResponse("run_shell", result, nil)
+	return ctx.runCommand(argv[0], argv[1:], workingDir, timeout), nil
 }
 
 // runCommand executes a command with timeout and captures stdout/stderr.
@@ -118,7 +139,7 @@ func (ctx Context) runCommand(command string, args []string, workingDir string,
 	defer cancel()
 
 	cmd := exec.CommandContext(execCtx, command, args...)
-	cmd.Env = sanitizedEnv()
+	cmd.Env = SanitizedEnv()
 	if workingDir != "" {
 		cmd.Dir = workingDir
 	}
@@ -172,8 +193,8 @@ func (ctx Context) runCommand(command string, args []string, workingDir string,
 	}
 }
 
-// sanitizedEnv keeps only low-risk environment variables for subprocesses.
-func sanitizedEnv() []string {
+// SanitizedEnv keeps only low-risk environment variables for subprocesses.
+func SanitizedEnv() []string {
 	allowedPrefixes := []string{
 		"PATH=",
 		"HOME=",
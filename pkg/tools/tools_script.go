@@ -0,0 +1,313 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/openai/openai-go"
+)
+
+// ScriptToolName returns the tool name of a skill script: the skill name with
+// characters other than letters, digits and '_' replaced by '_', then "__" and
+// the script name, e.g. skill_creator__init_skill. Tool names cannot contain dots.
+func ScriptToolName(skill *skills.Skill, script skills.Script) string {
+	prefix := strings.Map(func(r rune) rune {
+		if r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, skill.Name)
+	return prefix + "__" + script.Name
+}
+
+// skillScriptTool runs a script declared by a skill with validated arguments.
+type skillScriptTool struct {
+	ctx      Context
+	skill    *skills.Skill
+	script   skills.Script
+	toolName string
+}
+
+func (t *skillScriptTool) name() string {
+	return t.toolName
+}
+
+func (t *skillScriptTool) definition() openai.ChatCompletionToolParam {
+	description := t.script.Description
+	if description == "" {
+		description = fmt.Sprintf("Run %s from the %s skill", t.script.Path, t.skill.Name)
+	}
+	return openai.ChatCompletionToolParam{
+		Function: openai.FunctionDefinitionParam{
+			Name:        t.toolName,
+			Description: openai.String(description),
+			Parameters:  openai.FunctionParameters(t.script.Arguments),
+		},
+	}
+}
+
+func (t *skillScriptTool) execute(argText string) (string, error) {
+	t.ctx.debugf("[verbose] %s: args_bytes=%d", t.toolName, len(argText))
+	args := map[string]any{}
+	if strings.TrimSpace(argText) != "" {
+		dec := json.NewDecoder(strings.NewReader(argText))
+		dec.UseNumber()
+		if err := dec.Decode(&args); err != nil {
+			return marshalToolResponse(t.toolName, nil, fmt.Errorf("arguments must be a JSON object: %w", err))
+		}
+	}
+	if err := validateArguments(t.script.Arguments, args); err != nil {
+		t.ctx.debugf("[verbose] %s: invalid arguments: %v", t.toolName, err)
+		return marshalToolResponse(t.toolName, nil, fmt.Errorf("invalid arguments: %w", err))
+	}
+	scriptArgs, err := scriptArgv(t.script, args)
+	if err != nil {
+		return marshalToolResponse(t.toolName, nil, fmt.Errorf("invalid arguments: %w", err))
+	}
+
+	scriptPath, err := t.skill.ResolveResource(t.script.Path)
+	if err == nil {
+		// Workspace scripts run elsewhere than a relative skill directory.
+		scriptPath, err = filepath.Abs(scriptPath)
+	}
+	if err != nil {
+		return marshalToolResponse(t.toolName, nil, fmt.Errorf("script %s: %w", t.script.Path, err))
+	}
+	argv := append([]string{scriptPath}, scriptArgs...)
+	if t.script.Interpreter != "" {
+		argv = append([]string{t.script.Interpreter}, argv...)
+	}
+
+	dir := t.skill.Dir()
+	if t.script.WorkingDir == skills.ScriptDirWorkspace {
+		dir = ""
+		if len(t.ctx.AllowedDirs) > 0 {
+			dir = t.ctx.AllowedDirs[0]
+		}
+	}
+	workingDir, err := validateWorkingDirWithAllowedDirs(dir, t.ctx.AllowedDirs)
+	if err != nil {
+		return marshalToolResponse(t.toolName, nil, fmt.Errorf("working directory validation failed: %w", err))
+	}
+
+	result, err := t.ctx.runSandboxed(argv, workingDir, t.script.Timeout)
+	if err != nil {
+		t.ctx.debugf("[verbose] %s: %v", t.toolName, err)
+		return marshalToolResponse(t.toolName, nil, err)
+	}
+	t.ctx.debugf("[verbose] %s: completed, exit_code=%d, duration=%dms", t.toolName, result.ExitCode, result.DurationMs)
+	return marshalToolResponse(t.toolName, result, nil)
+}
+
+// validateArguments checks args against the subset of JSON schema that maps
+// to command lines: required, properties with type string, integer, number,
+// boolean or array of those, and enum. Unknown arguments are rejected unless
+// additionalProperties is true.
+func validateArguments(schema map[string]any, args map[string]any) error {
+	properties, _ := schema["properties"].(map[string]any)
+	for _, name := range stringList(schema["required"]) {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("%s is required", name)
+		}
+	}
+	extra, _ := schema["additionalProperties"].(bool)
+	for _, name := range sortedArgNames(args) {
+		prop, ok := properties[name].(map[string]any)
+		if !ok {
+			if extra {
+				continue
+			}
+			return fmt.Errorf("unknown argument %s", name)
+		}
+		if err := validateValue(name, prop, args[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateValue(name string, prop map[string]any, value any) error {
+	typ, _ := prop["type"].(string)
+	switch typ {
+	case "", "string", "integer", "number", "boolean":
+		if err := checkScalar(name, typ, value); err != nil {
+			return err
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s must be an array", name)
+		}
+		itemSchema, _ := prop["items"].(map[string]any)
+		for i, item := range items {
+			if err := validateValue(fmt.Sprintf("%s[%d]", name, i), itemSchema, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s: type %q is not supported for script arguments", name, typ)
+	}
+	if enum, ok := prop["enum"].([]any); ok {
+		text := scalarText(value)
+		for _, allowed := range enum {
+			if scalarText(allowed) == text {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of %v", name, enum)
+	}
+	return nil
+}
+
+// checkScalar checks a decoded JSON value against a scalar type; an empty type
+// accepts any scalar.
+func checkScalar(name, typ string, value any) error {
+	switch v := value.(type) {
+	case string:
+		if typ == "" || typ == "string" {
+			return nil
+		}
+	case bool:
+		if typ == "" || typ == "boolean" {
+			return nil
+		}
+	case json.Number:
+		if typ == "" || typ == "number" {
+			return nil
+		}
+		if typ == "integer" {
+			if _, err := v.Int64(); err == nil {
+				return nil
+			}
+			if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+				return nil
+			}
+		}
+	}
+	if typ == "" {
+		return fmt.Errorf("%s must be a string, number or boolean", name)
+	}
+	return fmt.Errorf("%s must be of type %s", name, typ)
+}
+
+// scriptArgv maps validated arguments to command line arguments: positional
+// arguments in declaration order, then --name=value in name order. A true
+// boolean is --name, false is omitted, and arrays repeat the flag.
+func scriptArgv(script skills.Script, args map[string]any) ([]string, error) {
+	var argv []string
+	positional := map[string]bool{}
+	missing := ""
+	for _, name := range script.Positional {
+		positional[name] = true
+		value, ok := args[name]
+		if !ok {
+			missing = name
+			continue
+		}
+		if missing != "" {
+			return nil, fmt.Errorf("%s is required when %s is set", missing, name)
+		}
+		text := scalarText(value)
+		if strings.HasPrefix(text, "-") {
+			return nil, fmt.Errorf("%s must not start with '-'", name)
+		}
+		argv = append(argv, text)
+	}
+	for _, name := range sortedArgNames(args) {
+		if positional[name] {
+			continue
+		}
+		switch value := args[name].(type) {
+		case bool:
+			if value {
+				argv = append(argv, "--"+name)
+			}
+		case []any:
+			for _, item := range value {
+				argv = append(argv, "--"+name+"="+scalarText(item))
+			}
+		default:
+			argv = append(argv, "--"+name+"="+scalarText(value))
+		}
+	}
+	return argv, nil
+}
+
+// scalarText formats a decoded JSON scalar for a command line.
+func scalarText(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(bytes.Trim(data, `"`))
+	}
+}
+
+func sortedArgNames(args map[string]any) []string {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// stringList converts a decoded []any of strings.
+func stringList(value any) []string {
+	items, _ := value.([]any)
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// scriptTools builds the tools for the scripts of skills that can run them:
+// skills on disk that are not untrusted. Scripts whose tool name is invalid
+// or already taken are skipped.
+func scriptTools(ctx Context, list []*skills.Skill, taken func(string) bool) []*skillScriptTool {
+	var out []*skillScriptTool
+	seen := map[string]bool{}
+	for _, skill := range list {
+		if len(skill.Scripts) == 0 {
+			continue
+		}
+		if skill.Virtual() || skill.Trust == skills.TrustUntrusted {
+			ctx.debugf("[verbose] scripts of skill %s not exposed (virtual=%v, trust=%s)", skill.Name, skill.Virtual(), skill.Trust)
+			continue
+		}
+		for _, script := range skill.Scripts {
+			name := ScriptToolName(skill, script)
+			if !validToolName(name) || seen[name] || taken(name) {
+				ctx.Logger.Warn("skill script not exposed as a tool", map[string]any{
+					"skill":  skill.Name,
+					"script": script.Name,
+					"tool":   name,
+				})
+				continue
+			}
+			seen[name] = true
+			out = append(out, &skillScriptTool{ctx: ctx, skill: skill, script: script, toolName: name})
+		}
+	}
+	return out
+}
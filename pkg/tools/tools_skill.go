@@ -0,0 +1,456 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
+	"github.com/openai/openai-go"
+)
+
+// maxSkillResources caps the resource index returned by load_skill.
+const maxSkillResources = 200
+
+// Result limits for search_skills.
+const (
+	defaultSkillSearchLimit = 5
+	maxSkillSearchLimit     = 20
+)
+
+// skillToolNames are the tools backed by Context.Skills.
+var skillToolNames = []string{"load_skill", "read_skill_resource", "search_skills"}
+
+// SetSkills replaces the skills served by load_skill, read_skill_resource and
+// search_skills. The tools are registered when list is non-empty and removed
+// otherwise. A nil index is built from list. The scripts declared by the
+// skills replace the previous script tools.
+func (t *Registry) SetSkills(list []*skills.Skill, index *skills.Index) {
+	t.mu.Lock()
+	t.ctx.Skills = list
+	t.ctx.SkillIndex = index
+	ctx := t.ctx
+	previous := t.scriptTools
+	t.scriptTools = nil
+	t.mu.Unlock()
+
+	for _, name := range previous {
+		t.Unregister(name)
+	}
+	if len(list) == 0 {
+		t.SetActiveSkill(nil)
+		for _, name := range skillToolNames {
+			t.Unregister(name)
+		}
+		return
+	}
+	if index == nil {
+		index = skills.NewIndex(list)
+	}
+	byName := newSkillIndex(list)
+	// Keep a reloaded active skill in force under its new definition.
+	t.mu.Lock()
+	if t.active != nil {
+		t.active = byName[t.active.Name]
+	}
+	t.mu.Unlock()
+	t.register(&loadSkillTool{ctx: ctx, skills: byName, activate: t.SetActiveSkill, scriptOwner: t.scriptToolOwner})
+	t.register(&readSkillResourceTool{ctx: ctx, skills: byName})
+	t.register(&searchSkillsTool{ctx: ctx, index: index})
+
+	t.mu.RLock()
+	scripts := scriptTools(ctx, list, func(name string) bool {
+		_, taken := t.registry[name]
+		return taken
+	})
+	t.mu.RUnlock()
+	for _, script := range scripts {
+		t.register(script)
+	}
+	t.mu.Lock()
+	for _, script := range scripts {
+		t.scriptTools = append(t.scriptTools, script.toolName)
+	}
+	t.mu.Unlock()
+}
+
+// scriptToolOwner returns the skill that declares the script tool called
+// name, or nil.
+func (t *Registry) scriptToolOwner(name string) *skills.Skill {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if script, ok := t.registry[name].(*skillScriptTool); ok {
+		return script.skill
+	}
+	return nil
+}
+
+// skillIndex looks up loaded skills by name.
+type skillIndex map[string]*skills.Skill
+
+func newSkillIndex(list []*skills.Skill) skillIndex {
+	index := make(skillIndex, len(list))
+	for _, skill := range list {
+		if _, exists := index[skill.Name]; !exists {
+			index[skill.Name] = skill
+		}
+	}
+	return index
+}
+
+func (s skillIndex) lookup(name string) (*skills.Skill, error) {
+	if name == "" {
+		return nil, errors.New("skill is required")
+	}
+	skill, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown skill: %s", name)
+	}
+	return skill, nil
+}
+
+type loadSkillTool struct {
+	ctx    Context
+	skills skillIndex
+	// activate, when set, is called with the loaded skill.
+	activate func(*skills.Skill)
+	// scriptOwner, when set, returns the skill of a registered script tool.
+	scriptOwner func(name string) *skills.Skill
+}
+
+func (t *loadSkillTool) name() string {
+	return "load_skill"
+}
+
+func (t *loadSkillTool) definition() openai.ChatCompletionToolParam {
+	return openai.ChatCompletionToolParam{
+		Function: openai.FunctionDefinitionParam{
+			Name:        "load_skill",
+			Description: openai.String("Load a skill's instructions by name, with an index of its bundled resources"),
+			Parameters: openai.FunctionParameters{
+				"type": "object",
+				"properties": map[string]any{
+					"name": map[string]any{
+						"type":        "string",
+						"description": "Skill name from the Available Skills list.",
+					},
+				},
+				"required": []string{"name"},
+			},
+		},
+	}
+}
+
+func (t *loadSkillTool) execute(argText string) (string, error) {
+	var args struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(argText), &args); err != nil {
+		t.ctx.debugf("[verbose] load_skill: failed to parse arguments: %v", err)
+		return marshalToolResponse("load_skill", nil, err)
+	}
+	t.ctx.debugf("[verbose] load_skill: name=%s", args.Name)
+	skill, err := t.skills.lookup(args.Name)
+	if err != nil {
+		return marshalToolResponse("load_skill", nil, err)
+	}
+
+	resources, truncated, err := skill.Resources(maxSkillResources)
+	if err != nil {
+		t.ctx.debugf("[verbose] load_skill: resource index failed: %v", err)
+		return marshalToolResponse("load_skill", nil, err)
+	}
+	var findings []skills.Finding
+	if skill.Quarantined {
+		if findings, err = skills.ScanSkill(skill); err != nil {
+			t.ctx.debugf("[verbose] load_skill: scan failed: %v", err)
+		}
+	}
+	result := struct {
+		Name               string            `json:"name"`
+		Description        string            `json:"description"`
+		AllowedTools       []string          `json:"allowed_tools,omitempty"`
+		Trust              skills.TrustLevel `json:"trust,omitempty"`
+		Body               string            `json:"body"`
+		Resources          []skills.Resource `json:"resources"`
+		ResourcesTruncated bool              `json:"resources_truncated,omitempty"`
+		Links              []skills.Link     `json:"links,omitempty"`
+		// Tools are the skill's scripts exposed as tools.
+		Tools []string `json:"tools,omitempty"`
+		// Dir is the skill's skill:// root, so the host layout stays private.
+		Dir string `json:"dir"`
+		// Findings are the suspected prompt injections in SKILL.md that got
+		// the skill Quarantined at load time.
+		Findings    []skills.Finding `json:"injection_findings,omitempty"`
+		Quarantined bool             `json:"quarantined,omitempty"`
+	}{
+		Name:               skill.Name,
+		Description:        skill.Description,
+		AllowedTools:       skill.AllowedTools,
+		Trust:              skill.Trust,
+		Body:               skill.Body,
+		Resources:          resources,
+		ResourcesTruncated: truncated,
+		Links:              skill.Links(),
+		Tools:              t.scriptTools(skill),
+		Dir:                skills.URIScheme + skill.Name + "/",
+		Findings:           findings,
+		Quarantined:        skill.Quarantined,
+	}
+	if result.Resources == nil {
+		result.Resources = []skills.Resource{}
+	}
+	if t.activate != nil {
+		t.activate(skill)
+	}
+	t.ctx.debugf("[verbose] load_skill: success, %d resources, %d links", len(resources), len(result.Links))
+	return marshalToolResponse("load_skill", result, nil)
+}
+
+// scriptTools lists the tool names of the skill's registered scripts.
+func (t *loadSkillTool) scriptTools(skill *skills.Skill) []string {
+	var names []string
+	for _, script := range skill.Scripts {
+		name := ScriptToolName(skill, script)
+		if owner := t.scriptOwner; owner != nil && owner(name) == skill {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+type readSkillResourceTool struct {
+	ctx    Context
+	skills skillIndex
+}
+
+func (t *readSkillResourceTool) name() string {
+	return "read_skill_resource"
+}
+
+func (t *readSkillResourceTool) definition() openai.ChatCompletionToolParam {
+	return openai.ChatCompletionToolParam{
+		Function: openai.FunctionDefinitionParam{
+			Name:        "read_skill_resource",
+			Description: openai.String("Read a file bundled with a skill, such as references/guide.md"),
+			Parameters: openai.FunctionParameters{
+				"type": "object",
+				"properties": map[string]any{
+					"skill": map[string]any{
+						"type":        "string",
+						"description": "Skill name.",
+					},
+					"path": map[string]any{
+						"type":        "string",
+						"description": "Path relative to the skill directory, as listed by load_skill.",
+					},
+					"max_bytes": map[string]any{
+						"type":        "integer",
+						"description": "Maximum bytes to read (defaults to tool limit).",
+					},
+				},
+				"required": []string{"skill", "path"},
+			},
+		},
+	}
+}
+
+func (t *readSkillResourceTool) execute(argText string) (string, error) {
+	var args struct {
+		Skill    string `json:"skill"`
+		Path     string `json:"path"`
+		MaxBytes int64  `json:"max_bytes"`
+	}
+	if err := json.Unmarshal([]byte(argText), &args); err != nil {
+		t.ctx.debugf("[verbose] read_skill_resource: failed to parse arguments: %v", err)
+		return marshalToolResponse("read_skill_resource", nil, err)
+	}
+	t.ctx.debugf("[verbose] read_skill_resource: skill=%s, path=%s, max_bytes=%d", args.Skill, args.Path, args.MaxBytes)
+	skill, err := t.skills.lookup(args.Skill)
+	if err != nil {
+		return marshalToolResponse("read_skill_resource", nil, err)
+	}
+	file, err := skill.OpenResource(args.Path)
+	if err != nil {
+		t.ctx.debugf("[verbose] read_skill_resource: path validation failed: %v", err)
+		return marshalToolResponse("read_skill_resource", nil, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	maxBytes := args.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = t.ctx.MaxReadBytes
+	}
+	if maxBytes <= 0 {
+		return marshalToolResponse("read_skill_resource", nil, errors.New("max_bytes must be greater than 0"))
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return marshalToolResponse("read_skill_resource", nil, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return marshalToolResponse("read_skill_resource", nil, err)
+	}
+	truncated := int64(len(data)) > maxBytes
+	if truncated {
+		data = data[:maxBytes]
+	}
+
+	// Binary assets are returned base64-encoded so the JSON stays lossless.
+	encoding, content := "base64", base64.StdEncoding.EncodeToString(data)
+	var findings []skills.Finding
+	quarantined := false
+	if text := trimPartialRune(data, truncated); utf8.Valid(text) {
+		encoding, content = "utf-8", string(text)
+		findings, quarantined, err = t.ctx.scanResource(skill.Name, args.Path, content)
+		if err != nil {
+			return marshalToolResponse("read_skill_resource", nil, err)
+		}
+		if quarantined {
+			content = ""
+		}
+	}
+	result := struct {
+		Skill     string `json:"skill"`
+		Path      string `json:"path"`
+		Size      int64  `json:"size"`
+		Bytes     int    `json:"bytes"`
+		Truncated bool   `json:"truncated"`
+		Encoding  string `json:"encoding"`
+		Content   string `json:"content"`
+		// Findings are suspected prompt injections in the content, which is
+		// withheld when Quarantined.
+		Findings    []skills.Finding `json:"injection_findings,omitempty"`
+		Quarantined bool             `json:"quarantined,omitempty"`
+	}{
+		Skill:       skill.Name,
+		Path:        args.Path,
+		Size:        info.Size(),
+		Bytes:       len(data),
+		Truncated:   truncated,
+		Encoding:    encoding,
+		Content:     content,
+		Findings:    findings,
+		Quarantined: quarantined,
+	}
+	t.ctx.debugf("[verbose] read_skill_resource: success, read %d bytes (truncated=%v, encoding=%s)", result.Bytes, truncated, encoding)
+	return marshalToolResponse("read_skill_resource", result, nil)
+}
+
+// scanResource applies ResourceScan to text read from a skill resource. It
+// returns the findings to report and whether the text must be withheld;
+// blocked text is an error.
+func (c Context) scanResource(skill, rel, text string) ([]skills.Finding, bool, error) {
+	action, findings := c.ResourceScan.Apply(skills.ScanText(text))
+	if len(findings) == 0 {
+		return nil, false, nil
+	}
+	c.debugf("[verbose] skill resource %s/%s: %d possible prompt injection(s), action=%s", skill, rel, len(findings), action)
+	switch action {
+	case skills.ScanBlock:
+		return nil, false, fmt.Errorf("skill resource %s/%s blocked: possible prompt injection at %s", skill, rel, findings[0])
+	case skills.ScanQuarantine:
+		return findings, true, nil
+	}
+	return findings, false, nil
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence cut off by truncation.
+func trimPartialRune(data []byte, truncated bool) []byte {
+	if !truncated {
+		return data
+	}
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				return data[:len(data)-i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+type searchSkillsTool struct {
+	ctx   Context
+	index *skills.Index
+}
+
+func (t *searchSkillsTool) name() string {
+	return "search_skills"
+}
+
+func (t *searchSkillsTool) definition() openai.ChatCompletionToolParam {
+	return openai.ChatCompletionToolParam{
+		Function: openai.FunctionDefinitionParam{
+			Name:        "search_skills",
+			Description: openai.String("Search all installed skills by keywords, including skills not shown in the Available Skills list"),
+			Parameters: openai.FunctionParameters{
+				"type": "object",
+				"properties": map[string]any{
+					"query": map[string]any{
+						"type":        "string",
+						"description": "Keywords describing the task.",
+					},
+					"limit": map[string]any{
+						"type":        "integer",
+						"description": fmt.Sprintf("Maximum results (default %d, max %d).", defaultSkillSearchLimit, maxSkillSearchLimit),
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+	}
+}
+
+func (t *searchSkillsTool) execute(argText string) (string, error) {
+	var args struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal([]byte(argText), &args); err != nil {
+		t.ctx.debugf("[verbose] search_skills: failed to parse arguments: %v", err)
+		return marshalToolResponse("search_skills", nil, err)
+	}
+	t.ctx.debugf("[verbose] search_skills: query=%q, limit=%d", args.Query, args.Limit)
+	if strings.TrimSpace(args.Query) == "" {
+		return marshalToolResponse("search_skills", nil, errors.New("query is required"))
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultSkillSearchLimit
+	}
+	limit = min(limit, maxSkillSearchLimit)
+
+	type match struct {
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		Score       float64 `json:"score"`
+	}
+	matches := []match{}
+	for _, result := range t.index.Search(args.Query, limit) {
+		matches = append(matches, match{
+			Name:        result.Skill.Name,
+			Description: result.Skill.Description,
+			Score:       math.Round(result.Score*100) / 100,
+		})
+	}
+	result := struct {
+		Query   string  `json:"query"`
+		Total   int     `json:"total"`
+		Matches []match `json:"matches"`
+	}{
+		Query:   args.Query,
+		Total:   t.index.Len(),
+		Matches: matches,
+	}
+	t.ctx.debugf("[verbose] search_skills: %d match(es)", len(matches))
+	return marshalToolResponse("search_skills", result, nil)
+}
@@ -4,12 +4,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/minhyannv/agent-skills-go/pkg/skills"
 	"github.com/openai/openai-go"
 )
 
+// Write modes accepted by write_file.
+const (
+	writeModeCreate    = "create"
+	writeModeOverwrite = "overwrite"
+	writeModeAppend    = "append"
+)
+
 type writeFileTool struct {
 	ctx Context
 }
@@ -22,7 +30,7 @@ func (t *writeFileTool) definition() openai.ChatCompletionToolParam {
 	return openai.ChatCompletionToolParam{
 		Function: openai.FunctionDefinitionParam{
 			Name:        "write_file",
-			Description: openai.String("Write content to a file on disk"),
+			Description: openai.String("Write content to a file on disk atomically"),
 			Parameters: openai.FunctionParameters{
 				"type": "object",
 				"properties": map[string]any{
@@ -32,11 +40,24 @@ func (t *writeFileTool) definition() openai.ChatCompletionToolParam {
 					},
 					"content": map[string]any{
 						"type":        "string",
-						"description": "Full file contents to write.",
+						"description": "Content to write. Full file contents, or the text to append in append mode.",
+					},
+					"mode": map[string]any{
+						"type":        "string",
+						"enum":        []string{writeModeCreate, writeModeOverwrite, writeModeAppend},
+						"description": "create fails if the file exists, overwrite replaces it, append adds content to the end. Defaults to create.",
 					},
 					"overwrite": map[string]any{
 						"type":        "boolean",
-						"description": "Whether to overwrite if the file already exists.",
+						"description": "Deprecated: equivalent to mode=overwrite when mode is not set.",
+					},
+					"expected_sha256": map[string]any{
+						"type":        "string",
+						"description": "Fail unless the current file content has this SHA-256, as returned by a read_file call that was not truncated.",
+					},
+					"fsync": map[string]any{
+						"type":        "boolean",
+						"description": "Flush the file to stable storage before returning.",
 					},
 				},
 				"required": []string{"path", "content"},
@@ -47,54 +68,128 @@ func (t *writeFileTool) definition() openai.ChatCompletionToolParam {
 
 func (t *writeFileTool) execute(argText string) (string, error) {
 	var args struct {
-		Path      string `json:"path"`
-		Content   string `json:"content"`
-		Overwrite bool   `json:"overwrite"`
+		Path           string `json:"path"`
+		Content        string `json:"content"`
+		Mode           string `json:"mode"`
+		Overwrite      bool   `json:"overwrite"`
+		ExpectedSHA256 string `json:"expected_sha256"`
+		Fsync          bool   `json:"fsync"`
 	}
 	if err := json.Unmarshal([]byte(argText), &args); err != nil {
 		t.ctx.debugf("[verbose] write_file: failed to parse arguments: %v", err)
 		return marshalToolResponse("write_file", nil, err)
 	}
-	t.ctx.debugf("[verbose] write_file: path=%s, bytes=%d, overwrite=%v", args.Path, len(args.Content), args.Overwrite)
+	t.ctx.debugf("[verbose] write_file: path=%s, bytes=%d, mode=%s, overwrite=%v", args.Path, len(args.Content), args.Mode, args.Overwrite)
 	if args.Path == "" {
 		return marshalToolResponse("write_file", nil, errors.New("path is required"))
 	}
 
+	mode, err := resolveWriteMode(args.Mode, args.Overwrite)
+	if err != nil {
+		return marshalToolResponse("write_file", nil, err)
+	}
+	expected := strings.ToLower(strings.TrimSpace(args.ExpectedSHA256))
+	if expected != "" && mode == writeModeCreate {
+		return marshalToolResponse("write_file", nil, errors.New("expected_sha256 cannot be combined with mode=create"))
+	}
+
+	fsys := t.ctx.fileSystem()
+
 	// Validate and sanitize path
-	validatedPath, err := validatePathWithAllowedDirs(args.Path, t.ctx.AllowedDirs)
+	validatedPath, err := fsys.ValidatePath(args.Path)
 	if err != nil {
 		t.ctx.debugf("[verbose] write_file: path validation failed: %v", err)
 		return marshalToolResponse("write_file", nil, fmt.Errorf("path validation failed: %w", err))
 	}
 
-	if !args.Overwrite {
-		if _, err := os.Stat(validatedPath); err == nil {
-			t.ctx.debugf("[verbose] write_file: file already exists and overwrite=false")
-			return marshalToolResponse("write_file", nil, fmt.Errorf("file exists: %s", validatedPath))
+	exists := false
+	if info, err := fsys.Stat(validatedPath); err == nil {
+		if info.IsDir() {
+			return marshalToolResponse("write_file", nil, fmt.Errorf("path is a directory: %s", validatedPath))
 		}
+		exists = true
+	}
+	if exists && mode == writeModeCreate {
+		t.ctx.debugf("[verbose] write_file: file already exists and mode=create")
+		return marshalToolResponse("write_file", nil, fmt.Errorf("file exists: %s", validatedPath))
 	}
 
-	dir := filepath.Dir(validatedPath)
+	var current []byte
+	if exists && (expected != "" || mode == writeModeAppend) {
+		current, err = readAllFS(fsys, validatedPath)
+		if err != nil {
+			return marshalToolResponse("write_file", nil, err)
+		}
+	}
+	if expected != "" {
+		if !exists {
+			return marshalToolResponse("write_file", nil, fmt.Errorf("expected_sha256 set but file does not exist: %s", validatedPath))
+		}
+		if actual := bytesSHA256(current); actual != expected {
+			t.ctx.debugf("[verbose] write_file: sha256 precondition failed")
+			return marshalToolResponse("write_file", nil, fmt.Errorf("file changed since it was read: expected sha256 %s, got %s", expected, actual))
+		}
+	}
+
+	data := []byte(args.Content)
+	if mode == writeModeAppend && exists {
+		data = append(current, data...)
+	}
+
+	dir := parentDir(validatedPath)
 	if dir != "." && dir != "" {
 		t.ctx.debugf("[verbose] write_file: creating directory: %s", dir)
-		if err := os.MkdirAll(dir, 0o755); err != nil {
+		if err := fsys.MkdirAll(dir, 0o755); err != nil {
 			t.ctx.debugf("[verbose] write_file: mkdir failed: %v", err)
 			return marshalToolResponse("write_file", nil, err)
 		}
 	}
 
-	if err := os.WriteFile(validatedPath, []byte(args.Content), 0o644); err != nil {
+	if err := fsys.WriteFile(validatedPath, data, WriteOptions{Perm: 0o644, Sync: args.Fsync}); err != nil {
 		t.ctx.debugf("[verbose] write_file: write failed: %v", err)
 		return marshalToolResponse("write_file", nil, err)
 	}
 
 	result := struct {
-		Path  string `json:"path"`
-		Bytes int    `json:"bytes"`
+		Path    string `json:"path"`
+		Mode    string `json:"mode"`
+		Bytes   int    `json:"bytes"`
+		Size    int    `json:"size"`
+		Created bool   `json:"created"`
+		SHA256  string `json:"sha256"`
 	}{
-		Path:  validatedPath,
-		Bytes: len(args.Content),
+		Path:    validatedPath,
+		Mode:    mode,
+		Bytes:   len(args.Content),
+		Size:    len(data),
+		Created: !exists,
+		SHA256:  bytesSHA256(data),
 	}
 	t.ctx.debugf("[verbose] write_file: success, wrote %d bytes", result.Bytes)
 	return marshalToolResponse("write_file", result, nil)
 }
+
+// resolveWriteMode maps the mode argument and legacy overwrite flag to a write mode.
+func resolveWriteMode(mode string, overwrite bool) (string, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "":
+		if overwrite {
+			return writeModeOverwrite, nil
+		}
+		return writeModeCreate, nil
+	case writeModeCreate, writeModeOverwrite, writeModeAppend:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid mode %q (expected create, overwrite or append)", mode)
+	}
+}
+
+// parentDir is filepath.Dir, except that it keeps skill:// URIs intact, so
+// the skill file system still recognizes and refuses them.
+func parentDir(p string) string {
+	if _, _, ok := skills.ParseURI(p); ok {
+		return p[:strings.LastIndex(p, "/")]
+	}
+	return filepath.Dir(p)
+}
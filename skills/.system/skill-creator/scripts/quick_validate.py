@@ -37,7 +37,7 @@ def validate_skill(skill_path):
     except yaml.YAMLError as e:
         return False, f"Invalid YAML in frontmatter: {e}"
 
-    allowed_properties = {"name", "description", "license", "allowed-tools", "metadata"}
+    allowed_properties = {"name", "description", "license", "allowed-tools", "metadata", "scripts"}
 
     unexpected_keys = set(frontmatter.keys()) - allowed_properties
     if unexpected_keys: